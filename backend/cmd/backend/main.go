@@ -7,15 +7,25 @@ package main
 import (
 	"log"
 	"backend/internal/config"
+	"backend/internal/cursor"
 	"backend/internal/db"
 	"backend/internal/handler"
+	"backend/internal/mailer"
 	"backend/internal/repository"
 	"backend/internal/router"
 	"backend/internal/service"
 	"backend/internal/middleware"
+	"backend/internal/queue"
 	"backend/internal/recommendation"
+	"backend/internal/realtime"
 	"context"
+	"expvar"
+	"flag"
+	"net/http"
+	"os"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 // startTrendingRecommendationGenerator 在背景定期執行推薦演算法
@@ -47,6 +57,177 @@ func startTrendingRecommendationGenerator(recommender *recommendation.TrendingRe
 	}
 }
 
+// startTrendingTagsGenerator 在背景定期計算熱門標籤
+func startTrendingTagsGenerator(recommender *recommendation.TrendingTagsRecommender, interval time.Duration) {
+	log.Printf("Starting periodic trending tags generator with interval %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Println("Running initial trending tags generation on startup...")
+	if err := recommender.GenerateTrendingTags(context.Background()); err != nil {
+		log.Printf("Error during initial trending tags generation: %v", err)
+	}
+
+	for range ticker.C {
+		if err := recommender.GenerateTrendingTags(context.Background()); err != nil {
+			log.Printf("Error during scheduled trending tags generation: %v", err)
+		} else {
+			log.Println("Scheduled trending tags generation finished successfully.")
+		}
+	}
+}
+
+// startDigestScheduler 在背景定期寄送到期的電子郵件摘要
+func startDigestScheduler(digestService *service.DigestService, interval time.Duration) {
+	log.Printf("Starting periodic notification digest scheduler with interval %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sent, err := digestService.SendDueDigests(context.Background())
+		if err != nil {
+			log.Printf("Error during scheduled digest run: %v", err)
+			continue
+		}
+		log.Printf("Scheduled digest run finished, %d digests sent.", sent)
+	}
+}
+
+// startScheduledPostPublisher 在背景定期發布到期的排程貼文
+func startScheduledPostPublisher(draftService *service.DraftService, interval time.Duration) {
+	log.Printf("Starting periodic scheduled post publisher with interval %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		published, err := draftService.PublishDuePosts(context.Background())
+		if err != nil {
+			log.Printf("Error during scheduled post publishing: %v", err)
+			continue
+		}
+		if published > 0 {
+			log.Printf("Scheduled post run finished, %d posts published.", published)
+		}
+	}
+}
+
+// runReconcileOrphans 找出刪除貼文後殘留在三張表中的資料，加上 -fix 時一併刪除
+func runReconcileOrphans(cleanupService *service.PostCleanupService, args []string) {
+	fs := flag.NewFlagSet("reconcile-orphans", flag.ExitOnError)
+	fix := fs.Bool("fix", false, "delete the orphaned items instead of only reporting them")
+	fs.Parse(args)
+
+	report, err := cleanupService.ReconcileOrphans(context.Background(), *fix)
+	if err != nil {
+		log.Fatalf("Reconcile failed: %v", err)
+	}
+
+	log.Printf("Stale soft-deleted posts: %d %v", len(report.StaleDeletedPosts), report.StaleDeletedPosts)
+	log.Printf("Orphaned likes/comments (by post): %d %v", len(report.OrphanChildPostIDs), report.OrphanChildPostIDs)
+	log.Printf("Orphaned feed items: %d", len(report.OrphanFeedItems))
+	for _, item := range report.OrphanFeedItems {
+		log.Printf("  %s %s -> %s", item.PK, item.SK, item.PostID)
+	}
+	log.Printf("Orphaned recommendation items: %d", len(report.OrphanRecommendations))
+	for _, item := range report.OrphanRecommendations {
+		log.Printf("  %s %s -> %s", item.PK, item.SK, item.PostID)
+	}
+	if !*fix {
+		log.Println("Dry run only; re-run with -fix to delete the items above.")
+	}
+}
+
+// runRebuildFeed 依追蹤關係重建一位或所有使用者的 Feed；-dry-run 只列出缺少、多餘與孤立的項目
+func runRebuildFeed(feedService *service.FeedService, userRepo repository.UserRepository, args []string) {
+	fs := flag.NewFlagSet("rebuild-feed", flag.ExitOnError)
+	userID := fs.String("user", "", "rebuild the feed of this user ID")
+	all := fs.Bool("all", false, "rebuild the feeds of all users")
+	dryRun := fs.Bool("dry-run", false, "only report differences, do not write")
+	fs.Parse(args)
+
+	var userIDs []string
+	switch {
+	case *userID != "" && !*all:
+		userIDs = []string{*userID}
+	case *userID == "" && *all:
+		users, err := userRepo.GetAllUsers()
+		if err != nil {
+			log.Fatalf("Failed to list users: %v", err)
+		}
+		for _, u := range users {
+			userIDs = append(userIDs, u.ID)
+		}
+	default:
+		log.Fatalf("Usage: rebuild-feed (-user <id> | -all) [-dry-run]")
+	}
+
+	var missing, extra, orphaned, failed int
+	for _, id := range userIDs {
+		report, err := feedService.RebuildFeed(context.Background(), id, *dryRun)
+		if err != nil {
+			log.Printf("User %s: rebuild failed: %v", id, err)
+			failed++
+			continue
+		}
+		missing += len(report.Missing)
+		extra += len(report.Extra)
+		orphaned += len(report.Orphaned)
+		if len(report.Missing)+len(report.Extra)+len(report.Orphaned) == 0 {
+			continue
+		}
+
+		log.Printf("User %s: expected %d, missing %d, extra %d, orphaned %d", id, report.Expected, len(report.Missing), len(report.Extra), len(report.Orphaned))
+		for _, item := range report.Missing {
+			log.Printf("  missing  %s %s -> %s (author %s)", item.PK, item.SK, item.PostID, item.AuthorID)
+		}
+		for _, item := range report.Extra {
+			log.Printf("  extra    %s %s -> %s (author %s)", item.PK, item.SK, item.PostID, item.AuthorID)
+		}
+		for _, item := range report.Orphaned {
+			log.Printf("  orphaned %s %s -> %s (author %s)", item.PK, item.SK, item.PostID, item.AuthorID)
+		}
+	}
+
+	log.Printf("Checked %d users: %d missing, %d extra, %d orphaned, %d failed", len(userIDs), missing, extra, orphaned, failed)
+	if *dryRun {
+		log.Println("Dry run only; re-run without -dry-run to apply the changes above.")
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// startAdminServer 在獨立的 listener 上提供內部監控 (背景工作佇列的 expvar 指標)，不掛在對外的 API 路由上
+func startAdminServer(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	log.Printf("Admin server listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Admin server stopped: %v", err)
+	}
+}
+
+// rankingWeights 以設定檔覆寫排序 Feed 的預設權重
+func rankingWeights(cfg *config.Config) service.RankingWeights {
+	weights := service.DefaultRankingWeights()
+	if v := cfg.Ranking.RecencyWeight; v != nil {
+		weights.Recency = *v
+	}
+	if v := cfg.Ranking.RecencyHalfLifeHours; v != nil {
+		weights.RecencyHalfLife = time.Duration(*v * float64(time.Hour))
+	}
+	if v := cfg.Ranking.AffinityWeight; v != nil {
+		weights.Affinity = *v
+	}
+	if v := cfg.Ranking.VelocityWeight; v != nil {
+		weights.Velocity = *v
+	}
+	if v := cfg.Ranking.SeenPenalty; v != nil {
+		weights.SeenPenalty = *v
+	}
+	return weights
+}
+
 func main() {
 	// ... 其他初始化程式碼 ...
 	cfg, err := config.LoadConfig("config/config.yaml")
@@ -75,43 +256,132 @@ func main() {
 	if err != nil {
 		log.Fatalf("Fail to connect to DynamoDB: %v", err)
 	}
+	// Redis 用於讓即時推播跨多個 backend replica 運作，並保存 fan-out 工作佇列；未設定時需明確開啟 queue.allow_memory
+	var redisClient *redis.Client
+	var fanOutQueue queue.Queue
+	if cfg.Redis.Addr != "" {
+		redisClient, err = db.InitRedis(cfg.Redis.Addr, cfg.Redis.Password, cfg.Redis.DB)
+		if err != nil {
+			log.Fatalf("Fail to connect to Redis: %v", err)
+		}
+		defer redisClient.Close()
+		fanOutQueue = queue.NewRedisQueue(redisClient, "fanout")
+	} else if cfg.Queue.AllowMemory {
+		log.Println("WARNING: Redis is not configured; realtime events are delivered to local connections only and the fan-out queue is kept in memory, so pending jobs are lost on restart")
+		fanOutQueue = queue.NewMemoryQueue()
+	} else {
+		// fan-out、清理與排程貼文的 fan-out 都依賴持久化佇列，不允許在沒有明確同意的情況下退回記憶體佇列
+		log.Fatalf("Redis is not configured; set redis.addr for the durable job queue, or queue.allow_memory: true for local development")
+	}
+	realtimeHub := realtime.NewHub(redisClient)
+	go realtimeHub.Run(context.Background())
+
 	// Repositories
 	userRepo := repository.NewMySQLUserRepository(mysqlDB)
 	tokenBlacklistRepo := repository.NewMemoryTokenBlacklist()
 	postRepo := repository.NewDynamoDBPostRepository(awsdynamoDB)
 	feedRepo := repository.NewDynamoDBFeedRepository(awsdynamoDB)
 	recoRepo := repository.NewDynamoDBRecommendationRepository(awsdynamoDB)
+	notificationRepo := repository.NewDynamoDBNotificationRepository(awsdynamoDB)
+	seenRepo := repository.NewDynamoDBSeenHistoryRepository(awsdynamoDB)
+	affinityRepo := repository.NewDynamoDBAffinityRepository(awsdynamoDB)
+	muteRepo := repository.NewDynamoDBMuteRepository(awsdynamoDB)
+	bookmarkRepo := repository.NewDynamoDBBookmarkRepository(awsdynamoDB)
+	draftRepo := repository.NewDynamoDBDraftRepository(awsdynamoDB)
+	feedService := service.NewFeedService(feedRepo, postRepo, userRepo, fanOutQueue, cfg.Feed.PullThreshold)
+	cleanupService := service.NewPostCleanupService(postRepo, feedRepo, recoRepo, userRepo, feedService)
+
+	// 管理用子指令，執行完畢後直接結束，不啟動伺服器與背景工作：
+	//   go run ./cmd/backend reconcile-orphans [-fix]
+	//   go run ./cmd/backend rebuild-feed (-user <id> | -all) [-dry-run]
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reconcile-orphans":
+			runReconcileOrphans(cleanupService, os.Args[2:])
+			return
+		case "rebuild-feed":
+			// 重建時不需要工作佇列，直接同步寫入
+			runRebuildFeed(service.NewFeedService(feedRepo, postRepo, userRepo, nil, cfg.Feed.PullThreshold), userRepo, os.Args[2:])
+			return
+		}
+	}
 
 	// Recommendation 
 
 	trendingRecommender := recommendation.NewTrendingRecommender(postRepo, userRepo, recoRepo) //
+	trendingTagsRecommender := recommendation.NewTrendingTagsRecommender(postRepo, recoRepo)
 
 	// --- 啟動背景任務 ---
 	// 使用 goroutine 執行，才不會阻塞主線程的 Web 伺服器啟動
 	go startTrendingRecommendationGenerator(trendingRecommender, 1*time.Hour) //
+	// 標籤的時間窗最短為 1 小時，因此更頻繁地重新計算
+	go startTrendingTagsGenerator(trendingTagsRecommender, 15*time.Minute)
 
 
 	// Services
 	authService := service.NewAuthService(userRepo, tokenBlacklistRepo, cfg.JWT.SecretKey, cfg.JWT.ExpiryMinutes)
 	profileService := service.NewProfileService(userRepo)
-	postService := service.NewPostService(postRepo, userRepo, feedRepo) 
-	userService := service.NewUserService(userRepo)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, realtimeHub)
+	postService := service.NewPostService(postRepo, userRepo, feedRepo, feedService, notificationService, realtimeHub, fanOutQueue, affinityRepo, bookmarkRepo) 
+	fanOutWorkers := queue.NewWorkerPool("fanout", fanOutQueue, queue.WorkerOptions{
+		Concurrency: cfg.Queue.Concurrency,
+		MaxAttempts: cfg.Queue.MaxAttempts,
+	})
+	fanOutWorkers.Handle(service.JobTypeFanOutPost, postService.HandleFanOutJob)
+	fanOutWorkers.Handle(service.JobTypeBackfillFeed, feedService.HandleBackfillJob)
+	fanOutWorkers.Handle(service.JobTypePruneFeed, feedService.HandlePruneJob)
+	fanOutWorkers.Handle(service.JobTypeCleanupPost, cleanupService.HandleCleanupJob)
+	go fanOutWorkers.Run(context.Background())
+	seenService := service.NewSeenService(seenRepo, postRepo)
+	muteService := service.NewMuteService(muteRepo)
+	bookmarkService := service.NewBookmarkService(bookmarkRepo, postRepo, postService)
+	draftService := service.NewDraftService(draftRepo, postRepo, postService)
+	// 排程貼文以條件更新領取，多個 replica 同時執行也只會發布一次；重啟後到期的貼文會在下一次執行補發
+	go startScheduledPostPublisher(draftService, 1*time.Minute)
+	rankingService := service.NewRankingService(feedService, postRepo, recoRepo, seenRepo, affinityRepo, rankingWeights(cfg))
+	userService := service.NewUserService(userRepo, notificationService, feedService)
+	recommendationService := service.NewRecommendationService(trendingRecommender, trendingTagsRecommender, recoRepo)
 
 	// Handlers
 	authHandler := handler.NewAuthHandler(*authService, cfg.JWT.ExpiryMinutes)
 	profileHandler := handler.NewProfileHandler(profileService)
-	postHandler := handler.NewPostHandler(postService, userRepo, feedRepo, postRepo, recoRepo)
+	cursorSigner := cursor.NewSigner(cfg.Feed.CursorSecret)
+	postHandler := handler.NewPostHandler(postService, feedService, userRepo, feedRepo, postRepo, recoRepo, seenService, rankingService, muteService, cursorSigner)
 	userHandler := handler.NewUserHandler(userService, mysqlDB, awsdynamoDB) 
+	recommendationHandler := handler.NewRecommendationHandler(recommendationService, muteService)
+	notificationHandler := handler.NewNotificationHandler(notificationService, cursorSigner)
+	streamHandler := handler.NewStreamHandler(realtimeHub, postService)
+	muteHandler := handler.NewMuteHandler(muteService)
+	bookmarkHandler := handler.NewBookmarkHandler(bookmarkService, cursorSigner)
+	draftHandler := handler.NewDraftHandler(draftService)
+
+	// 電子郵件摘要
+	var digestMailer mailer.Mailer
+	if cfg.Mail.Driver == "smtp" {
+		digestMailer = mailer.NewSMTPMailer(cfg.Mail.Host, cfg.Mail.Port, cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.From)
+	} else {
+		digestMailer, err = mailer.NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+		if err != nil {
+			log.Fatalf("Fail to initialize file mailer: %v", err)
+		}
+	}
+	digestService := service.NewDigestService(notificationRepo, userRepo, postRepo, recoRepo, digestMailer)
+	// 摘要以使用者各自的 last_digest_at 判斷是否到期，因此每小時檢查一次即可
+	go startDigestScheduler(digestService, 1*time.Hour)
 
 	// Middleware
 	authMiddleware := middleware.NewAuthMiddleware(tokenBlacklistRepo, cfg.JWT.SecretKey)
 
 
 	// 6. 初始化 Router
-	r := router.NewRouter(mysqlDB, awsdynamoDB, authHandler, profileHandler, postHandler, userHandler, userRepo, authMiddleware) //
+	r := router.NewRouter(mysqlDB, awsdynamoDB, authHandler, profileHandler, postHandler, userHandler, recommendationHandler, notificationHandler, streamHandler, muteHandler, bookmarkHandler, draftHandler, userRepo, authMiddleware) //
 
 
 
+	if cfg.Admin.Addr != "-" {
+		go startAdminServer(cfg.Admin.Addr)
+	}
+
 	// 7. 啟動伺服器
 	log.Println("Server starting on port :8080")
 	if err := r.Run(":8080"); err != nil {
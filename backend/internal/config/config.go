@@ -1,10 +1,16 @@
 package config
 
 import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/hex"
 	"os"
 	"gopkg.in/yaml.v3"
 )
 
+// cursorKeyInfo 是由 JWT 金鑰衍生 cursor 金鑰時的 HKDF info，讓兩者即使同源也不會是同一把金鑰
+const cursorKeyInfo = "feed-cursor-hmac-v1"
+
 type Config struct {
 	Database struct {
 		Username string `yaml:"username"`
@@ -23,6 +29,42 @@ type Config struct {
 		SecretKey     string `yaml:"secret_key"`
 		ExpiryMinutes int    `yaml:"expiry_minutes"`
 	} `yaml:"jwt"`
+	Redis struct { // 即時推播跨 replica 使用的 pub/sub 與持久化的工作佇列；未設定 addr 時必須開啟 queue.allow_memory
+		Addr     string `yaml:"addr"`
+		Password string `yaml:"password"`
+		DB       int    `yaml:"db"`
+	} `yaml:"redis"`
+	Mail struct { // 電子郵件摘要的寄送方式，driver 為 smtp 或 file (寫入 dir 目錄而不實際寄出)
+		Driver   string `yaml:"driver"`
+		From     string `yaml:"from"`
+		Dir      string `yaml:"dir"`
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+	} `yaml:"mail"`
+	Queue struct { // fan-out 工作佇列的 worker 設定，0 代表使用預設值
+		Concurrency int `yaml:"concurrency"`
+		MaxAttempts int `yaml:"max_attempts"`
+		// AllowMemory 允許在未設定 Redis 時改用記憶體佇列 (僅供本機開發)；重啟後尚未處理的工作會遺失
+		AllowMemory bool `yaml:"allow_memory"`
+	} `yaml:"queue"`
+	Admin struct { // 內部監控 (/debug/vars) 使用的獨立 listener，與對外的 API 分開
+		Addr string `yaml:"addr"` // 預設只監聽 127.0.0.1:9090，設為 "-" 代表停用
+	} `yaml:"admin"`
+	Feed struct {
+		// PullThreshold 是改用讀取時拉取 (不 fan-out) 的粉絲數門檻，負數代表停用混合模式
+		PullThreshold int `yaml:"pull_threshold"`
+		// CursorSecret 是簽發分頁 cursor (Feed、評論、標籤時間軸與通知等) 的 HMAC 金鑰，未設定時以 HKDF 由 JWT 金鑰衍生出獨立的金鑰
+		CursorSecret string `yaml:"cursor_secret"`
+	} `yaml:"feed"`
+	Ranking struct { // 排序 Feed (mode=ranked) 的評分權重，未設定的欄位使用預設值，設為 0 代表停用該訊號
+		RecencyWeight        *float64 `yaml:"recency_weight"`
+		RecencyHalfLifeHours *float64 `yaml:"recency_half_life_hours"`
+		AffinityWeight       *float64 `yaml:"affinity_weight"`
+		VelocityWeight       *float64 `yaml:"velocity_weight"`
+		SeenPenalty          *float64 `yaml:"seen_penalty"`
+	} `yaml:"ranking"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -41,5 +83,30 @@ func LoadConfig(path string) (*Config, error) {
     if cfg.JWT.ExpiryMinutes == 0 {
         cfg.JWT.ExpiryMinutes = 60
     }
+    if cfg.Feed.PullThreshold == 0 {
+        cfg.Feed.PullThreshold = 10000
+    }
+    if cfg.Feed.CursorSecret == "" {
+        key, err := hkdf.Key(sha256.New, []byte(cfg.JWT.SecretKey), nil, cursorKeyInfo, sha256.Size)
+        if err != nil {
+            return nil, err
+        }
+        cfg.Feed.CursorSecret = hex.EncodeToString(key)
+    }
+    if cfg.Admin.Addr == "" {
+        cfg.Admin.Addr = "127.0.0.1:9090"
+    }
+    if cfg.Mail.Driver == "" {
+        cfg.Mail.Driver = "file"
+    }
+    if cfg.Mail.Dir == "" {
+        cfg.Mail.Dir = "mail_outbox"
+    }
+    if cfg.Mail.From == "" {
+        cfg.Mail.From = "no-reply@localhost"
+    }
+    if cfg.Mail.Port == 0 {
+        cfg.Mail.Port = 587
+    }
     return &cfg, nil
 }
\ No newline at end of file
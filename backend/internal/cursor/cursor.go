@@ -0,0 +1,61 @@
+// internal/cursor/cursor.go
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidCursor 表示 cursor 格式錯誤或簽章不符 (可能被竄改，或是以其他金鑰簽發)
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Signer 將分頁狀態編碼為不透明的 cursor 字串，格式為 base64url(JSON).base64url(HMAC-SHA256)。
+// 客戶端只能原樣傳回 cursor，無法讀取或修改其中的分頁位置。
+type Signer struct {
+	key []byte
+}
+
+// NewSigner 是 Signer 的建構子
+func NewSigner(secret string) *Signer {
+	return &Signer{key: []byte(secret)}
+}
+
+// Encode 將 state 序列化並簽章
+func (s *Signer) Encode(state interface{}) (string, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded)), nil
+}
+
+// Decode 驗證簽章後將 cursor 還原到 state
+func (s *Signer) Decode(token string, state interface{}) error {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err := json.Unmarshal(payload, state); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func (s *Signer) sign(encoded string) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
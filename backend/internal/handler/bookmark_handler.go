@@ -0,0 +1,126 @@
+// internal/handler/bookmark_handler.go
+package handler
+
+import (
+	"backend/internal/cursor"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BookmarkHandler 結構
+type BookmarkHandler struct {
+	bookmarkService *service.BookmarkService
+	cursorSigner    *cursor.Signer // 簽發收藏列表的 next_key
+}
+
+// NewBookmarkHandler 是 BookmarkHandler 的建構子
+func NewBookmarkHandler(bookmarkService *service.BookmarkService, cursorSigner *cursor.Signer) *BookmarkHandler {
+	return &BookmarkHandler{
+		bookmarkService: bookmarkService,
+		cursorSigner:    cursorSigner,
+	}
+}
+
+// ListBookmarks 依收藏時間由新到舊列出收藏的貼文，可用 collection 只列出某個收藏集，使用 next_key 進行分頁
+func (h *BookmarkHandler) ListBookmarks(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	// 收藏集名稱不能含 '#'，因此 {user_id}#{collection} 可以唯一識別 cursor 的範圍
+	collection := c.Query("collection")
+	scope := userID + "#" + collection
+	lastEvaluatedKey, err := decodeKeyCursor(h.cursorSigner, c.Query("next_key"), keyCursorBookmarks, scope, "USER#"+userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
+		return
+	}
+
+	posts, nextEvaluatedKey, err := h.bookmarkService.ListBookmarkedPosts(c.Request.Context(), userID, collection, int32(limit), lastEvaluatedKey)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCollection) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookmarks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": posts, "next_key": encodeKeyCursor(h.cursorSigner, keyCursorBookmarks, scope, nextEvaluatedKey)})
+}
+
+// ListCollections 列出使用者的收藏集
+func (h *BookmarkHandler) ListCollections(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	collections, err := h.bookmarkService.ListCollections(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookmark collections"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": collections})
+}
+
+// AddBookmark 收藏貼文，request body 可選擇性帶入 collection；已收藏的貼文會被移到指定的收藏集
+func (h *BookmarkHandler) AddBookmark(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var payload models.BookmarkPayload
+	if err := c.ShouldBindJSON(&payload); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	bookmark, err := h.bookmarkService.AddBookmark(c.Request.Context(), userID, c.Param("postID"), payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCollection):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrPostNotVisible), strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		case errors.Is(err, repository.ErrAlreadyBookmarked), errors.Is(err, repository.ErrBookmarkConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark post"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, bookmark)
+}
+
+// RemoveBookmark 取消收藏
+func (h *BookmarkHandler) RemoveBookmark(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.bookmarkService.RemoveBookmark(c.Request.Context(), userID, c.Param("postID")); err != nil {
+		if errors.Is(err, repository.ErrBookmarkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed successfully"})
+}
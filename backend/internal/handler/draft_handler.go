@@ -0,0 +1,139 @@
+// internal/handler/draft_handler.go
+package handler
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DraftHandler 結構
+type DraftHandler struct {
+	draftService *service.DraftService
+}
+
+// NewDraftHandler 是 DraftHandler 的建構子
+func NewDraftHandler(draftService *service.DraftService) *DraftHandler {
+	return &DraftHandler{
+		draftService: draftService,
+	}
+}
+
+// respondDraftError 將草稿相關的錯誤轉換為 HTTP 回應
+func respondDraftError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidVisibility), errors.Is(err, service.ErrInvalidPublishAt), errors.Is(err, service.ErrDraftContentRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrDraftNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrDraftConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// ListDrafts 列出自己的草稿與排程貼文
+func (h *DraftHandler) ListDrafts(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	drafts, err := h.draftService.ListDrafts(c.Request.Context(), userID)
+	if err != nil {
+		respondDraftError(c, err, "Failed to get drafts")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": drafts})
+}
+
+// CreateDraft 建立草稿；帶入 publish_at 時成為排程貼文，到期後由排程自動發布
+func (h *DraftHandler) CreateDraft(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var payload models.SaveDraftPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	draft, err := h.draftService.CreateDraft(c.Request.Context(), userID, payload)
+	if err != nil {
+		respondDraftError(c, err, "Failed to create draft")
+		return
+	}
+	c.JSON(http.StatusCreated, draft)
+}
+
+// GetDraft 取得自己的一篇草稿
+func (h *DraftHandler) GetDraft(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	draft, err := h.draftService.GetDraft(c.Request.Context(), userID, c.Param("draftID"))
+	if err != nil {
+		respondDraftError(c, err, "Failed to get draft")
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+// UpdateDraft 以 request body 的內容覆寫草稿；省略 publish_at 會取消排程
+func (h *DraftHandler) UpdateDraft(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var payload models.SaveDraftPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	draft, err := h.draftService.UpdateDraft(c.Request.Context(), userID, c.Param("draftID"), payload)
+	if err != nil {
+		respondDraftError(c, err, "Failed to update draft")
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+// DeleteDraft 刪除草稿或取消排程貼文
+func (h *DraftHandler) DeleteDraft(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.draftService.DeleteDraft(c.Request.Context(), userID, c.Param("draftID")); err != nil {
+		respondDraftError(c, err, "Failed to delete draft")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Draft deleted successfully"})
+}
+
+// PublishDraft 立即發布草稿，回傳建立的貼文
+func (h *DraftHandler) PublishDraft(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	post, err := h.draftService.PublishDraftNow(c.Request.Context(), userID, c.Param("draftID"))
+	if err != nil {
+		respondDraftError(c, err, "Failed to publish draft")
+		return
+	}
+	c.JSON(http.StatusCreated, post)
+}
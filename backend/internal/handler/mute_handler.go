@@ -0,0 +1,82 @@
+// internal/handler/mute_handler.go
+package handler
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MuteHandler 結構
+type MuteHandler struct {
+	muteService *service.MuteService
+}
+
+// NewMuteHandler 是 MuteHandler 的建構子
+func NewMuteHandler(muteService *service.MuteService) *MuteHandler {
+	return &MuteHandler{
+		muteService: muteService,
+	}
+}
+
+// ListMuteFilters 列出使用者目前有效的靜音條件
+func (h *MuteHandler) ListMuteFilters(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	filters, err := h.muteService.ListFilters(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get mute filters"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": filters})
+}
+
+// CreateMuteFilter 新增靜音條件
+func (h *MuteHandler) CreateMuteFilter(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var payload models.CreateMuteFilterPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	filter, err := h.muteService.CreateFilter(c.Request.Context(), userID, payload)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidMuteFilter) || errors.Is(err, service.ErrTooManyMuteFilters) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create mute filter"})
+		return
+	}
+	c.JSON(http.StatusCreated, filter)
+}
+
+// DeleteMuteFilter 刪除靜音條件
+func (h *MuteHandler) DeleteMuteFilter(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.muteService.DeleteFilter(c.Request.Context(), userID, c.Param("filterID")); err != nil {
+		if errors.Is(err, repository.ErrMuteFilterNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete mute filter"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Mute filter deleted successfully"})
+}
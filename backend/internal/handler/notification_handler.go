@@ -0,0 +1,150 @@
+// internal/handler/notification_handler.go
+package handler
+
+import (
+	"backend/internal/cursor"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler 結構
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+	cursorSigner        *cursor.Signer // 簽發通知列表的 next_key
+}
+
+// NewNotificationHandler 是 NotificationHandler 的建構子
+func NewNotificationHandler(notificationService *service.NotificationService, cursorSigner *cursor.Signer) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		cursorSigner:        cursorSigner,
+	}
+}
+
+// ListNotifications 處理列出通知的請求，使用 next_key 進行分頁
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	lastEvaluatedKey, err := decodeKeyCursor(h.cursorSigner, c.Query("next_key"), keyCursorNotifications, userID, "USER#"+userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
+		return
+	}
+
+	page, err := h.notificationService.ListNotifications(c.Request.Context(), userID, int32(limit), lastEvaluatedKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": page.Items, "next_key": encodeKeyCursor(h.cursorSigner, keyCursorNotifications, userID, page.LastEvaluatedKey)})
+}
+
+// GetUnreadCount 處理取得未讀通知數量的請求
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.notificationService.GetUnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count unread notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": count})
+}
+
+// MarkAsRead 處理將單一通知標記為已讀的請求
+func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+	notificationSK := c.Param("notificationSK")
+
+	if err := h.notificationService.MarkAsRead(c.Request.Context(), userID, notificationSK); err != nil {
+		if errors.Is(err, repository.ErrNotificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification as read"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// MarkAllAsRead 處理將所有通知標記為已讀的請求
+func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.notificationService.MarkAllAsRead(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read", "updated": updated})
+}
+
+// GetPreferences 處理取得通知偏好的請求
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+// UpdatePreferences 處理更新通知偏好的請求，只更新請求中提供的類別與摘要頻率
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var payload models.UpdateNotificationPreferencesPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	prefs, err := h.notificationService.UpdatePreferences(c.Request.Context(), userID, payload)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidNotificationPreferences) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
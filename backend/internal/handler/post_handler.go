@@ -2,15 +2,18 @@
 package handler
 
 import (
+	"backend/internal/cursor"
 	"backend/internal/models"
 	"backend/internal/repository"
 	"backend/internal/service"
+	"backend/internal/textparse"
+	"errors"
 	"log"
 	"net/http"
 	"sort"
 	"strconv"
-	"encoding/base64"
-	"encoding/json"
+	"strings"
+	"time"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/gin-gonic/gin"
 )
@@ -19,30 +22,54 @@ const (
 	FeedThreshold         = 10 // 如果 Feed 項目少於此數，則補充推薦
 	FeedTotalTarget       = 20 // Feed 項目總數的目標
 	RecommendationLookout = 50 // 從多少個推薦項目中進行篩選
+	FeedRefillRounds      = 3  // 貼文被靜音過濾後，最多再讀幾次 Feed 來補滿一頁
 	TrendingAlgorithmKey  = "trending-v1.0" // 與推薦生成器中使用的金鑰保持一致
 )
 
+const (
+	// feedSinceGraceWindow 是輪詢新貼文時往回重新掃描的時間範圍。
+	// fan-out 是非同步的，項目寫入 UserFeed 時其 created_at 可能已早於客戶端上次輪詢的位置
+	feedSinceGraceWindow = 15 * time.Minute
+	// feedSinceRecentLimit 是 since cursor 最多記錄的已回傳貼文數，超過時寬限範圍內較舊的貼文可能重複回傳
+	feedSinceRecentLimit = 100
+)
+
 type PostHandler struct {
-	postService *service.PostService
-	userRepo    repository.UserRepository
-	feedRepo    repository.FeedRepository
-	postRepo    repository.PostRepository
-	recoRepo    repository.RecommendationRepository
+	postService    *service.PostService
+	feedService    *service.FeedService
+	userRepo       repository.UserRepository
+	feedRepo       repository.FeedRepository
+	postRepo       repository.PostRepository
+	recoRepo       repository.RecommendationRepository
+	seenService    *service.SeenService
+	rankingService *service.RankingService
+	muteService    *service.MuteService
+	cursorSigner   *cursor.Signer // 簽發 Feed 的 next_key / since_key
 }
 
 func NewPostHandler(
 	postService *service.PostService,
+	feedService *service.FeedService,
 	userRepo repository.UserRepository,
 	feedRepo repository.FeedRepository,
 	postRepo repository.PostRepository,
 	recoRepo repository.RecommendationRepository,
+	seenService *service.SeenService,
+	rankingService *service.RankingService,
+	muteService *service.MuteService,
+	cursorSigner *cursor.Signer,
 ) *PostHandler {
 	return &PostHandler{
-		postService: postService,
-		userRepo:    userRepo,
-		feedRepo:    feedRepo,
-		postRepo:    postRepo,
-		recoRepo:    recoRepo,
+		postService:    postService,
+		feedService:    feedService,
+		userRepo:       userRepo,
+		feedRepo:       feedRepo,
+		postRepo:       postRepo,
+		recoRepo:       recoRepo,
+		seenService:    seenService,
+		rankingService: rankingService,
+		muteService:    muteService,
+		cursorSigner:   cursorSigner,
 	}
 }
 
@@ -81,6 +108,10 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 
 	post, err := h.postService.CreatePost(c.Request.Context(), payload)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidVisibility) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
@@ -88,27 +119,92 @@ func (h *PostHandler) CreatePost(c *gin.Context) {
 	c.JSON(http.StatusCreated, post)
 }
 
-// GetPostsByUserID 處理獲取作者貼文的請求
+// GetPostsByUserID 處理獲取作者貼文的請求，依時間由新到舊並使用 next_key 進行分頁
 func (h *PostHandler) GetPostsByUserID(c *gin.Context) {
-	userID := c.Param("userID")
+	userID := c.Param("id")
 	if userID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "userID is required"})
 		return
 	}
 
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	page, err := h.decodeFeedCursor(c.Query("next_key"), feedCursorProfile, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
+		return
+	}
+	var lastEvaluatedKey map[string]types.AttributeValue
+	if page != nil {
+		// 作者貼文的主鍵為 PK = USER#{user_id}、SK = POST#...，cursor 只保存 SK
+		lastEvaluatedKey = map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: page.Before},
+		}
+	}
+
 	viewerID, _ := getAuthenticatedUserID(c)
-	posts, err := h.postService.GetPostsByUserID(c.Request.Context(), userID, viewerID)
+	posts, nextEvaluatedKey, err := h.postService.GetPostsByUserID(c.Request.Context(), userID, viewerID, int32(limit), lastEvaluatedKey)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get posts"})
 		return
 	}
 
-	c.JSON(http.StatusOK, posts)
+	nextKey := ""
+	if sk, ok := nextEvaluatedKey["SK"].(*types.AttributeValueMemberS); ok {
+		nextKey = h.encodeFeedCursor(feedCursor{Kind: feedCursorProfile, UserID: userID, Before: sk.Value})
+	}
+	c.JSON(http.StatusOK, gin.H{"data": posts, "next_key": nextKey})
 }
 
-// UpdatePost 處理編輯貼文的請求
-// 注意：此處的 service.UpdatePost 方法在原始設計中未包含權限驗證。
-// 一個更安全的設計是讓 service 層接收 authenticatedUserID 並在更新前進行比對。
+// GetPost 回傳單篇貼文 (permalink)，包含作者資訊、按讚狀態與第一頁評論
+func (h *PostHandler) GetPost(c *gin.Context) {
+	viewerID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+	h.respondPostDetail(c, viewerID)
+}
+
+// GetPublicPost 是 GetPost 的免登入版本，只回傳公開貼文，讓貼文連結可以分享給訪客
+func (h *PostHandler) GetPublicPost(c *gin.Context) {
+	h.respondPostDetail(c, "")
+}
+
+func (h *PostHandler) respondPostDetail(c *gin.Context, viewerID string) {
+	postID := c.Param("postID")
+	detail, err := h.postService.GetPostDetail(c.Request.Context(), postID, viewerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPostNotVisible) && viewerID != "":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrPostNotVisible), strings.Contains(err.Error(), "not found"):
+			// 訪客看不到的貼文一律視為不存在，不透露貼文是否存在
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post"})
+		}
+		return
+	}
+
+	if viewerID != "" {
+		for i := range detail.Comments {
+			detail.Comments[i].RepliesNextKey = h.encodeCommentCursor(commentCursor{
+				Kind:      commentCursorReplies,
+				PostID:    postID,
+				CommentID: detail.Comments[i].CommentID,
+			}, detail.Comments[i].RepliesLastEvaluatedKey)
+		}
+		detail.CommentsNextKey = h.encodeCommentCursor(commentCursor{Kind: commentCursorComments, PostID: postID}, detail.CommentsLastEvaluatedKey)
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// UpdatePost 處理編輯貼文的請求，可一併修改標籤、媒體與地點
 func (h *PostHandler) UpdatePost(c *gin.Context) {
 	var payload models.UpdatePostPayload
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -116,16 +212,27 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 		return
 	}
 
-	// 在理想情況下，service 層應該執行權限檢查。
-	// _, ok := getAuthenticatedUserID(c)
-	// if !ok {
-	// 	return
-	// }
-	// 建議修改 `UpdatePost` 服務，使其能接收並驗證操作者 ID
+	// 從 context 獲取已驗證的使用者 ID，交由 service 層進行權限驗證
+	authorID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+	payload.AuthorID = authorID
 
 	updatedPost, err := h.postService.UpdatePost(c.Request.Context(), payload)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case err.Error() == "user not authorized to edit this post":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidVisibility), errors.Is(err, service.ErrRepostNotEditable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrPostEditConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -135,6 +242,28 @@ func (h *PostHandler) UpdatePost(c *gin.Context) {
 	})
 }
 
+// GetPostHistory 回傳貼文的編輯紀錄
+func (h *PostHandler) GetPostHistory(c *gin.Context) {
+	viewerID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.postService.GetPostHistory(c.Request.Context(), c.Param("postID"), viewerID)
+	if err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post history"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
 // DeletePost 處理刪除貼文的請求
 func (h *PostHandler) DeletePost(c *gin.Context) {
 	var payload models.DeletePostPayload
@@ -152,13 +281,150 @@ func (h *PostHandler) DeletePost(c *gin.Context) {
 
 	err := h.postService.DeletePost(c.Request.Context(), payload)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrNotPostOwner):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrPostNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
 }
 
+// GetPostsByTag 處理依 hashtag 取得貼文的請求，使用 next_key 進行分頁
+func (h *PostHandler) GetPostsByTag(c *gin.Context) {
+	viewerID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+	tag := c.Param("tag")
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tag is required"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	// 標籤正規化後才是時間軸的分割區，cursor 綁定正規化後的標籤，大小寫不同的網址可以共用
+	normalized := textparse.NormalizeTag(tag)
+	lastEvaluatedKey, err := decodeKeyCursor(h.cursorSigner, c.Query("next_key"), keyCursorTag, normalized, "TAG#"+normalized)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
+		return
+	}
+
+	posts, nextEvaluatedKey, err := h.postService.GetPostsByTag(c.Request.Context(), tag, viewerID, h.muteFilters(c, viewerID), int32(limit), lastEvaluatedKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get posts for tag"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": posts, "next_key": encodeKeyCursor(h.cursorSigner, keyCursorTag, normalized, nextEvaluatedKey)})
+}
+
+// keyCursor 是依 PK/SK 分頁的列表 (標籤時間軸、收藏、通知) 的狀態，簽章後以不透明字串 (next_key) 交給客戶端。
+// 只保存 SK；PK 由伺服器依目前的請求重建，cursor 也不能拿來翻閱其他範圍的列表
+type keyCursor struct {
+	Kind  string `json:"k"` // keyCursorTag、keyCursorBookmarks 或 keyCursorNotifications
+	Scope string `json:"s"` // 簽發時的範圍，例如標籤或使用者
+	SK    string `json:"x"` // DynamoDB LastEvaluatedKey 的 SK
+}
+
+const (
+	keyCursorTag           = "tag"
+	keyCursorBookmarks     = "bookmarks"
+	keyCursorNotifications = "notifications"
+)
+
+// encodeKeyCursor 將 LastEvaluatedKey 的 SK 簽發為 cursor，沒有下一頁時回傳空字串
+func encodeKeyCursor(signer *cursor.Signer, kind, scope string, lastEvaluatedKey map[string]types.AttributeValue) string {
+	sk, ok := lastEvaluatedKey["SK"].(*types.AttributeValueMemberS)
+	if !ok {
+		return ""
+	}
+	token, err := signer.Encode(keyCursor{Kind: kind, Scope: scope, SK: sk.Value})
+	if err != nil {
+		log.Printf("Failed to encode %s cursor: %v", kind, err)
+		return ""
+	}
+	return token
+}
+
+// decodeKeyCursor 驗證 cursor 的種類與範圍，並以 pk 還原 ExclusiveStartKey；空字串代表第一頁
+func decodeKeyCursor(signer *cursor.Signer, token, kind, scope, pk string) (map[string]types.AttributeValue, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var kc keyCursor
+	if err := signer.Decode(token, &kc); err != nil {
+		return nil, err
+	}
+	if kc.Kind != kind || kc.Scope != scope || kc.SK == "" {
+		return nil, cursor.ErrInvalidCursor
+	}
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: pk},
+		"SK": &types.AttributeValueMemberS{Value: kc.SK},
+	}, nil
+}
+
+// feedCursor 是 Feed 分頁的狀態，以簽章後的不透明字串 (next_key / since_key) 交給客戶端
+type feedCursor struct {
+	Kind     string   `json:"k"`            // feedCursorNext、feedCursorSince、feedCursorRanked 或 feedCursorProfile，避免不同 cursor 混用
+	UserID   string   `json:"u"`            // 簽發對象的 Feed，不能拿來翻閱其他人的 Feed
+	Before   string   `json:"b,omitempty"`  // next：下一頁從此 created_at (不含) 之前開始
+	FeedDone bool     `json:"d,omitempty"`  // next：追蹤的 Feed 已讀完，之後只補充熱門貼文
+	Trending int      `json:"t,omitempty"`  // next：熱門列表中下一個要考慮的位置，讓之後的頁面不再重複推薦
+	TrendGen string   `json:"g,omitempty"`  // next：Trending 所對應的熱門列表版本 (產生時間)，列表重新產生後位置即失效
+	After    string   `json:"a,omitempty"`  // since：已掃描到的最新 created_at，下一次從此往回 feedSinceGraceWindow 開始掃描
+	Recent   []string `json:"rc,omitempty"` // since：寬限範圍內已回傳過的貼文 ID，重新掃描時略過
+	Offset   int      `json:"o,omitempty"`  // ranked：下一頁在排序結果中的位置
+	RankedAt string   `json:"r,omitempty"`  // ranked：第一頁的排序時間，之後的頁面沿用同一個時間點
+}
+
+const (
+	feedCursorNext    = "next"
+	feedCursorSince   = "since"
+	feedCursorRanked  = "ranked"
+	feedCursorProfile = "profile" // 作者貼文列表，Before 保存下一頁起始的貼文 SK
+)
+
+// decodeFeedCursor 驗證並還原 cursor，空字串代表沒有提供
+func (h *PostHandler) decodeFeedCursor(token, kind, userID string) (*feedCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	var fc feedCursor
+	if err := h.cursorSigner.Decode(token, &fc); err != nil {
+		return nil, err
+	}
+	if fc.Kind != kind || fc.UserID != userID {
+		return nil, cursor.ErrInvalidCursor
+	}
+	return &fc, nil
+}
+
+// encodeFeedCursor 簽發 cursor，失敗時回傳空字串 (客戶端只會拿不到下一頁)
+func (h *PostHandler) encodeFeedCursor(fc feedCursor) string {
+	token, err := h.cursorSigner.Encode(fc)
+	if err != nil {
+		log.Printf("Failed to encode feed cursor: %v", err)
+		return ""
+	}
+	return token
+}
+
+// GetFeedPosts 依時間由新到舊回傳 Feed。
+// 帶 next_key 時回傳下一頁；帶 since 時只回傳 since_key 之後的新貼文，供客戶端輪詢。
+// Feed 中含有只給粉絲看的貼文，因此只有本人能讀取自己的 Feed。
 func (h *PostHandler) GetFeedPosts(c *gin.Context) {
 	viewerID, ok := getAuthenticatedUserID(c)
 	if !ok {
@@ -169,123 +435,327 @@ func (h *PostHandler) GetFeedPosts(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "userID is required"})
 		return
 	}
+	if userID != viewerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot read another user's feed"})
+		return
+	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	exclusiveStartKey, _ := c.GetQuery("next_key")
+	// next、since 與 ranked 三種模式共用同一個上限；每次請求最多會讀取 FeedRefillRounds 輪這個大小的 Feed
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
 
-	var lastEvaluatedKey map[string]types.AttributeValue
-	if exclusiveStartKey != "" {
-		keyJSON, err := base64.StdEncoding.DecodeString(exclusiveStartKey)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key format"})
-			return
-		}
-		json.Unmarshal(keyJSON, &lastEvaluatedKey)
+	if since, ok := c.GetQuery("since"); ok {
+		h.getNewFeedPosts(c, viewerID, userID, int32(limit), since)
+		return
+	}
+	if c.Query("mode") == "ranked" {
+		h.getRankedFeedPosts(c, viewerID, userID, limit)
+		return
 	}
 
-	// 1. 從 UserFeed 表獲取基於追蹤的 Feed
-	paginatedFeed, err := h.feedRepo.GetUserFeed(c.Request.Context(), userID, int32(limit), lastEvaluatedKey)
+	page, err := h.decodeFeedCursor(c.Query("next_key"), feedCursorNext, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user feed"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
 		return
 	}
+	firstPage := page == nil
+	if firstPage {
+		page = &feedCursor{Kind: feedCursorNext, UserID: userID}
+	}
 
-	var postIDs []string
-	seenPostIDs := make(map[string]bool)
-	for _, item := range paginatedFeed.Items {
-		postIDs = append(postIDs, item.PostID)
-		seenPostIDs[item.PostID] = true
+	ctx := c.Request.Context()
+	filters := h.muteFilters(c, viewerID)
+
+	// 1. 獲取基於追蹤的 Feed (UserFeed 中 push 的項目與高粉絲帳號 pull 的貼文合併)。
+	// 被靜音或已刪除的貼文會被略過，因此繼續往下讀，讓使用者仍拿到 limit 篇貼文
+	var posts []models.Post
+	includedPostIDs := make(map[string]bool)
+	feedHasMore := !page.FeedDone
+	before := page.Before
+	newest := ""
+	for round := 0; feedHasMore && len(posts) < limit && round < FeedRefillRounds; round++ {
+		paginatedFeed, err := h.feedService.GetUserFeed(ctx, userID, int32(limit-len(posts)), before)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user feed"})
+			return
+		}
+		items := paginatedFeed.Items
+		feedHasMore = paginatedFeed.LastEvaluatedKey != nil && len(items) > 0
+		if len(items) == 0 {
+			break
+		}
+		if newest == "" {
+			newest = items[0].SK
+		}
+		before = items[len(items)-1].SK
+
+		postIDs := make([]string, 0, len(items))
+		for _, item := range items {
+			postIDs = append(postIDs, item.PostID)
+		}
+		fetched, err := h.fetchPostsInOrder(c, postIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch full posts for feed"})
+			return
+		}
+		for _, post := range service.FilterPosts(ctx, h.postRepo, fetched, filters) {
+			if !includedPostIDs[post.PostID] {
+				includedPostIDs[post.PostID] = true
+				posts = append(posts, post)
+			}
+		}
 	}
 
-	// --- 2. 檢查 Feed 是否過少，若是，則補充推薦內容 ---
-	if len(postIDs) < FeedThreshold {
-		needed := int32(FeedTotalTarget - len(postIDs))
-		log.Printf("Feed for user %s is sparse (%d items). Fetching up to %d recommendations.", userID, len(postIDs), needed)
+	// --- 2. 檢查 Feed 是否過少，若是，則從上次停下的位置繼續補充推薦內容 ---
+	trendingNext := page.Trending
+	trendingGen := page.TrendGen
+	trendingHasMore := true
+	if len(posts) < FeedThreshold {
+		needed := int32(FeedTotalTarget - len(posts))
+		log.Printf("Feed for user %s is sparse (%d items). Fetching up to %d recommendations.", userID, len(posts), needed)
 
 		// *** 重構核心變更：呼叫 GetGlobalTrending ***
-		recommendations, err := h.recoRepo.GetGlobalTrending(c.Request.Context(), TrendingAlgorithmKey, RecommendationLookout)
+		recommendations, err := h.recoRepo.GetGlobalTrending(ctx, TrendingAlgorithmKey, RecommendationLookout)
+		if err == nil {
+			if generation := trendingGeneration(recommendations); generation != trendingGen {
+				// 熱門列表在翻頁之間重新產生，舊的位置對不上新的列表，因此從頭開始；先前頁面推薦過的貼文由 SeenHistory 略過
+				trendingNext = 0
+				trendingGen = generation
+			}
+		}
 		if err != nil {
 			log.Printf("Could not fetch global recommendations: %v", err)
+		} else if trendingNext < len(recommendations) {
+			candidates := make([]string, 0, len(recommendations)-trendingNext)
+			for _, rec := range recommendations[trendingNext:] {
+				candidates = append(candidates, rec.PostID)
+			}
+			// 略過使用者已經看過的推薦貼文 (依 SeenHistory)
+			if h.seenService != nil {
+				candidates = h.seenService.FilterUnseen(ctx, viewerID, candidates)
+			}
+			// 略過被靜音或已刪除的推薦貼文
+			fetched, err := h.fetchPostsInOrder(c, candidates)
+			if err != nil {
+				log.Printf("Could not fetch recommended posts: %v", err)
+				fetched = nil
+			}
+			recommended := make(map[string]models.Post, len(fetched))
+			for _, post := range service.FilterPosts(ctx, h.postRepo, fetched, filters) {
+				recommended[post.PostID] = post
+			}
+
+			for ; trendingNext < len(recommendations) && len(posts) < FeedTotalTarget; trendingNext++ {
+				post, ok := recommended[recommendations[trendingNext].PostID]
+				if ok && !includedPostIDs[post.PostID] {
+					includedPostIDs[post.PostID] = true
+					posts = append(posts, post)
+				}
+			}
+			trendingHasMore = trendingNext < len(recommendations)
 		} else {
-			for _, rec := range recommendations {
-				if _, seen := seenPostIDs[rec.PostID]; !seen {
-					postIDs = append(postIDs, rec.PostID)
-					seenPostIDs[rec.PostID] = true
-					if len(postIDs) >= FeedTotalTarget {
-						break
-					}
+			trendingHasMore = false
+		}
+	}
+
+	// 3. 準備 cursor：Feed 還有下一頁時從最後讀到的項目繼續；Feed 已讀完但熱門列表還有剩時只翻熱門貼文
+	nextKey := ""
+	switch {
+	case feedHasMore:
+		nextKey = h.encodeFeedCursor(feedCursor{Kind: feedCursorNext, UserID: userID, Before: before, Trending: trendingNext, TrendGen: trendingGen})
+	case trendingHasMore:
+		nextKey = h.encodeFeedCursor(feedCursor{Kind: feedCursorNext, UserID: userID, FeedDone: true, Trending: trendingNext, TrendGen: trendingGen})
+	}
+	sinceKey := ""
+	if firstPage {
+		if newest == "" {
+			newest = time.Now().UTC().Format(time.RFC3339Nano)
+		}
+		sinceKey = h.encodeFeedCursor(feedCursor{Kind: feedCursorSince, UserID: userID, After: newest})
+	}
+
+	if len(posts) == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": []interface{}{}, "next_key": nextKey, "since_key": sinceKey})
+		return
+	}
+
+	// --- 4. 組合回傳給前端的內容 (維持 Feed 的順序) ---
+	frontendPosts := h.postService.BuildPostFeedDTOs(ctx, posts, viewerID)
+
+	c.JSON(http.StatusOK, gin.H{"data": frontendPosts, "next_key": nextKey, "since_key": sinceKey})
+}
+
+// trendingGeneration 回傳熱門列表的版本，也就是列表中最新的產生時間 (同一次產生的項目共用同一個時間)
+func trendingGeneration(recommendations []models.UserRecommendationItem) string {
+	generation := ""
+	for _, rec := range recommendations {
+		if rec.GeneratedAt > generation {
+			generation = rec.GeneratedAt
+		}
+	}
+	return generation
+}
+
+// muteFilters 取得瀏覽者目前有效的靜音條件
+func (h *PostHandler) muteFilters(c *gin.Context, viewerID string) []models.MuteFilter {
+	if h.muteService == nil {
+		return nil
+	}
+	return h.muteService.ActiveFilters(c.Request.Context(), viewerID)
+}
+
+// getNewFeedPosts 回傳 since cursor 之後的新貼文 (由新到舊)；has_more 為 true 時客戶端應以新的 since_key 立即再查詢。
+// 每次從上次的位置往回 feedSinceGraceWindow 重新掃描，補上 fan-out 延遲寫入的項目，並以 cursor 中的 Recent 去除重複
+func (h *PostHandler) getNewFeedPosts(c *gin.Context, viewerID, userID string, limit int32, token string) {
+	since, err := h.decodeFeedCursor(token, feedCursorSince, userID)
+	if err != nil || since == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since cursor"})
+		return
+	}
+
+	delivered := make(map[string]bool, len(since.Recent))
+	for _, postID := range since.Recent {
+		delivered[postID] = true
+	}
+	from := since.After
+	if after, err := time.Parse(time.RFC3339Nano, since.After); err == nil {
+		from = after.Add(-feedSinceGraceWindow).Format(time.RFC3339Nano)
+	}
+
+	var items []models.UserFeedItem
+	scanned := make(map[string]string) // 本次掃描到且已回傳的貼文 ID -> SK，用來產生下一個 cursor 的 Recent
+	watermark := since.After
+	hasMore := false
+	for round := 0; round < FeedRefillRounds; round++ {
+		// 寬限範圍內已回傳過的項目會被略過，多讀這些數量讓一次查詢就能取得 limit 篇新貼文
+		paginatedFeed, err := h.feedService.GetUserFeedSince(c.Request.Context(), userID, from, limit+int32(len(since.Recent)))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user feed"})
+			return
+		}
+		hasMore = paginatedFeed.LastEvaluatedKey != nil && len(paginatedFeed.Items) > 0
+		for _, item := range paginatedFeed.Items {
+			if !delivered[item.PostID] {
+				if int32(len(items)) >= limit {
+					hasMore = true
+					break
 				}
+				delivered[item.PostID] = true
+				items = append(items, item)
+			}
+			scanned[item.PostID] = item.SK
+			from = item.SK
+			if item.SK > watermark {
+				watermark = item.SK
 			}
 		}
+		if !hasMore || int32(len(items)) >= limit {
+			break
+		}
 	}
 
+	sinceKey := h.encodeFeedCursor(feedCursor{Kind: feedCursorSince, UserID: userID, After: watermark, Recent: recentFeedPostIDs(scanned, watermark)})
+
+	postIDs := make([]string, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		postIDs = append(postIDs, items[i].PostID)
+	}
 	if len(postIDs) == 0 {
-		c.JSON(http.StatusOK, gin.H{"data": []interface{}{}, "next_key": ""})
+		c.JSON(http.StatusOK, gin.H{"data": []interface{}{}, "since_key": sinceKey, "has_more": hasMore})
 		return
 	}
 
-	// --- 3. 批量獲取完整貼文內容 ---
-	posts, err := h.postRepo.GetPostsByIDs(c.Request.Context(), postIDs)
+	posts, err := h.fetchPostsInOrder(c, postIDs)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch full posts for feed"})
 		return
 	}
+	posts = service.FilterPosts(c.Request.Context(), h.postRepo, posts, h.muteFilters(c, viewerID))
+	frontendPosts := h.postService.BuildPostFeedDTOs(c.Request.Context(), posts, viewerID)
+	c.JSON(http.StatusOK, gin.H{"data": frontendPosts, "since_key": sinceKey, "has_more": hasMore})
+}
 
-	likedStatusMap, err := h.postRepo.CheckIfPostsLikedBy(c.Request.Context(), postIDs, viewerID)
-	if err != nil {
-		log.Printf("Could not check liked status for viewer %s: %v", viewerID, err)
-		likedStatusMap = make(map[string]bool)
-	}
-
-	authorCache := make(map[string]string)
-	var frontendPosts []models.PostFeedDTO
-	for _, post := range posts {
-		authorName, found := authorCache[post.AuthorID]
-		if !found {
-			user, userErr := h.userRepo.GetUserByID(post.AuthorID)
-			if userErr != nil {
-				log.Printf("Could not fetch author name for ID %s: %v", post.AuthorID, userErr)
-				authorName = "未知的使用者"
-			} else {
-				authorName = user.Username
-			}
-			authorCache[post.AuthorID] = authorName
+// recentFeedPostIDs 從已回傳的貼文中挑出仍在下一次寬限範圍內的，最新的在前，最多 feedSinceRecentLimit 筆
+func recentFeedPostIDs(scanned map[string]string, watermark string) []string {
+	cutoff := ""
+	if after, err := time.Parse(time.RFC3339Nano, watermark); err == nil {
+		cutoff = after.Add(-feedSinceGraceWindow).Format(time.RFC3339Nano)
+	}
+	postIDs := make([]string, 0, len(scanned))
+	for postID, sk := range scanned {
+		if sk > cutoff {
+			postIDs = append(postIDs, postID)
 		}
+	}
+	sort.Slice(postIDs, func(i, j int) bool {
+		return scanned[postIDs[i]] > scanned[postIDs[j]]
+	})
+	if len(postIDs) > feedSinceRecentLimit {
+		postIDs = postIDs[:feedSinceRecentLimit]
+	}
+	return postIDs
+}
 
-		dto := models.PostFeedDTO{
-			PostID:       post.PostID,
-			AuthorID:     post.AuthorID,
-			AuthorName:   authorName,
-			Content:      post.Content,
-			Media:        post.Media,
-			Tags:         post.Tags,
-			Location:     post.Location,
-			LikeCount:    post.LikeCount,
-			CommentCount: post.CommentCount,
-			CreatedAt:    post.CreatedAt,
-			UpdatedAt:    post.UpdatedAt,
-			IsLiked:      likedStatusMap[post.PostID],
-		}
-		frontendPosts = append(frontendPosts, dto)
+// getRankedFeedPosts 回傳依分數排序的 "For You" Feed；debug=true 時一併回傳每篇貼文的分數組成
+func (h *PostHandler) getRankedFeedPosts(c *gin.Context, viewerID, userID string, limit int) {
+	page, err := h.decodeFeedCursor(c.Query("next_key"), feedCursorRanked, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
+		return
+	}
+	rankedAt := time.Now().UTC()
+	if page == nil {
+		page = &feedCursor{Kind: feedCursorRanked, UserID: userID, RankedAt: rankedAt.Format(time.RFC3339Nano)}
+	} else if rankedAt, err = time.Parse(time.RFC3339Nano, page.RankedAt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
+		return
+	}
+
+	ranked, err := h.rankingService.GetRankedFeed(c.Request.Context(), userID, limit, page.Offset, rankedAt, h.muteFilters(c, viewerID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ranked feed"})
+		return
+	}
+
+	nextKey := ""
+	if ranked.HasMore {
+		next := *page
+		next.Offset += len(ranked.Posts)
+		nextKey = h.encodeFeedCursor(next)
 	}
 
-	// 4. 重新排序，以符合原始 Feed 的時間順序
+	response := gin.H{
+		"data":     h.postService.BuildPostFeedDTOs(c.Request.Context(), ranked.Posts, viewerID),
+		"next_key": nextKey,
+	}
+	if c.Query("debug") == "true" {
+		response["scores"] = ranked.Scores
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// fetchPostsInOrder 批量取得貼文並依 postIDs 的順序排列，不存在的貼文會被略過
+func (h *PostHandler) fetchPostsInOrder(c *gin.Context, postIDs []string) ([]models.Post, error) {
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+	posts, err := h.postRepo.GetPostsByIDs(c.Request.Context(), postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新排序，以符合原始 Feed 的時間順序
 	postOrder := make(map[string]int)
 	for i, id := range postIDs {
 		postOrder[id] = i
 	}
-	sort.SliceStable(frontendPosts, func(i, j int) bool {
-		return postOrder[frontendPosts[i].PostID] < postOrder[frontendPosts[j].PostID]
+	sort.SliceStable(posts, func(i, j int) bool {
+		return postOrder[posts[i].PostID] < postOrder[posts[j].PostID]
 	})
-
-	// 5. 準備下一次分頁的 next_key
-	var nextKey string
-	if paginatedFeed.LastEvaluatedKey != nil {
-		keyJSON, _ := json.Marshal(paginatedFeed.LastEvaluatedKey)
-		nextKey = base64.StdEncoding.EncodeToString(keyJSON)
-	}
-
-	c.JSON(http.StatusOK, gin.H{"data": frontendPosts, "next_key": nextKey})
+	return posts, nil
 }
 
 func (h *PostHandler) LikePost(c *gin.Context) {
@@ -298,6 +768,9 @@ func (h *PostHandler) LikePost(c *gin.Context) {
 	}
 
 	if err := h.postService.LikePost(c.Request.Context(), postID, userID); err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -315,12 +788,132 @@ func (h *PostHandler) UnlikePost(c *gin.Context) {
 	}
 
 	if err := h.postService.UnlikePost(c.Request.Context(), postID, userID); err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Post unliked successfully"})
 }
 
+// RepostPost 處理轉發請求
+func (h *PostHandler) RepostPost(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return // 錯誤已由輔助函式發送
+	}
+
+	repost, err := h.postService.RepostPost(c.Request.Context(), c.Param("postID"), userID)
+	if err != nil {
+		respondRepostError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, repost)
+}
+
+// UnrepostPost 處理取消轉發請求
+func (h *PostHandler) UnrepostPost(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return // 錯誤已由輔助函式發送
+	}
+
+	if err := h.postService.UnrepostPost(c.Request.Context(), c.Param("postID"), userID); err != nil {
+		respondRepostError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Repost removed successfully"})
+}
+
+// QuotePost 處理引用貼文請求
+func (h *PostHandler) QuotePost(c *gin.Context) {
+	var payload models.CreateQuotePayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return // 錯誤已由輔助函式發送
+	}
+
+	quote, err := h.postService.QuotePost(c.Request.Context(), c.Param("postID"), userID, payload)
+	if err != nil {
+		respondRepostError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, quote)
+}
+
+// respondNotVisible 在瀏覽者無權查看貼文 (僅限粉絲) 時回應 403，與 GetPost 一致；回傳是否已回應
+func respondNotVisible(c *gin.Context, err error) bool {
+	if !errors.Is(err, service.ErrPostNotVisible) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	return true
+}
+
+// respondRepostError 將轉發 / 引用的錯誤轉換為對應的 HTTP 狀態碼
+func respondRepostError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrAlreadyReposted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrRepostNotAllowed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidVisibility):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// LikeComment 處理評論按讚請求
+func (h *PostHandler) LikeComment(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return // 錯誤已由輔助函式發送
+	}
+
+	if err := h.postService.LikeComment(c.Request.Context(), c.Param("postID"), c.Param("commentSK"), userID); err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comment liked successfully"})
+}
+
+// UnlikeComment 處理取消評論按讚請求
+func (h *PostHandler) UnlikeComment(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return // 錯誤已由輔助函式發送
+	}
+
+	if err := h.postService.UnlikeComment(c.Request.Context(), c.Param("postID"), c.Param("commentSK"), userID); err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comment unliked successfully"})
+}
+
 // CreateComment 處理新增評論請求
 func (h *PostHandler) CreateComment(c *gin.Context) {
 	var payload models.CreateCommentPayload
@@ -339,12 +932,123 @@ func (h *PostHandler) CreateComment(c *gin.Context) {
 
 	comment, err := h.postService.CreateComment(c.Request.Context(), payload)
 	if err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
 	c.JSON(http.StatusCreated, comment)
 }
 
+// RecordImpressions 記錄貼文捲動進入畫面，客戶端應累積後批次回報
+func (h *PostHandler) RecordImpressions(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var payload models.RecordImpressionsPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	recorded, err := h.seenService.RecordImpressions(c.Request.Context(), userID, payload.PostIDs)
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyImpressions) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record impressions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recorded": recorded})
+}
+
+// GetPostViews 回傳貼文的觀看人數，只有作者本人可以查看
+func (h *PostHandler) GetPostViews(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.seenService.GetViewStats(c.Request.Context(), userID, c.Param("postID"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNotPostAuthor):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get post views"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// UpdateComment 處理編輯評論請求，只有評論者本人可以編輯
+func (h *PostHandler) UpdateComment(c *gin.Context) {
+	var payload models.UpdateCommentPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload: " + err.Error()})
+		return
+	}
+
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return // 錯誤已由輔助函式發送
+	}
+
+	comment, err := h.postService.UpdateComment(c.Request.Context(), c.Param("postID"), c.Param("commentSK"), userID, payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPostNotVisible):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case err.Error() == "user not authorized to edit this comment":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, repository.ErrCommentEditConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Comment updated successfully",
+		"comment": comment,
+	})
+}
+
+// GetCommentHistory 回傳評論的編輯紀錄
+func (h *PostHandler) GetCommentHistory(c *gin.Context) {
+	viewerID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.postService.GetCommentHistory(c.Request.Context(), c.Param("id"), c.Param("commentID"), viewerID)
+	if err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get comment history"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
 // DeleteComment 處理刪除評論請求
 func (h *PostHandler) DeleteComment(c *gin.Context) {
 	postID := c.Param("postID")
@@ -357,13 +1061,164 @@ func (h *PostHandler) DeleteComment(c *gin.Context) {
 	}
 
 	if err := h.postService.DeleteComment(c.Request.Context(), postID, commentSK, userID); err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
 		// 更精確地處理權限錯誤
 		if err.Error() == "user not authorized to delete this comment" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
-}
\ No newline at end of file
+}
+// commentCursor 是評論與回覆列表的分頁狀態，簽章後以不透明字串 (next_key / replies_next_key) 交給客戶端
+type commentCursor struct {
+	Kind      string            `json:"k"`           // commentCursorComments 或 commentCursorReplies
+	PostID    string            `json:"p"`           // 簽發時的貼文，不能拿來翻閱其他貼文的評論
+	CommentID string            `json:"c,omitempty"` // replies：所屬的頂層評論
+	Newest    bool              `json:"n,omitempty"` // comments：由新到舊排序
+	Key       map[string]string `json:"x"`           // DynamoDB 的 LastEvaluatedKey (PK/SK)
+}
+
+const (
+	commentCursorComments = "comments"
+	commentCursorReplies  = "replies"
+)
+
+// encodeCommentCursor 將 LastEvaluatedKey 簽發為 cursor，沒有下一頁時回傳空字串
+func (h *PostHandler) encodeCommentCursor(cc commentCursor, lastEvaluatedKey map[string]types.AttributeValue) string {
+	if len(lastEvaluatedKey) == 0 {
+		return ""
+	}
+	cc.Key = make(map[string]string, len(lastEvaluatedKey))
+	for k, v := range lastEvaluatedKey {
+		if sv, ok := v.(*types.AttributeValueMemberS); ok {
+			cc.Key[k] = sv.Value
+		}
+	}
+	token, err := h.cursorSigner.Encode(cc)
+	if err != nil {
+		log.Printf("Failed to encode comment cursor: %v", err)
+		return ""
+	}
+	return token
+}
+
+// decodeCommentCursor 驗證 cursor 的種類與所屬貼文 (及評論)，空字串代表第一頁
+func (h *PostHandler) decodeCommentCursor(token, kind, postID, commentID string) (*commentCursor, map[string]types.AttributeValue, error) {
+	if token == "" {
+		return nil, nil, nil
+	}
+	var cc commentCursor
+	if err := h.cursorSigner.Decode(token, &cc); err != nil {
+		return nil, nil, err
+	}
+	if cc.Kind != kind || cc.PostID != postID || cc.CommentID != commentID || len(cc.Key) == 0 {
+		return nil, nil, cursor.ErrInvalidCursor
+	}
+	lastEvaluatedKey := make(map[string]types.AttributeValue, len(cc.Key))
+	for k, v := range cc.Key {
+		lastEvaluatedKey[k] = &types.AttributeValueMemberS{Value: v}
+	}
+	return &cc, lastEvaluatedKey, nil
+}
+
+// ListComments 分頁列出貼文的頂層評論，每則評論附上最前面幾則回覆。
+// order=newest 由新到舊，預設 (oldest) 由舊到新；帶 next_key 時沿用第一頁的排序
+func (h *PostHandler) ListComments(c *gin.Context) {
+	viewerID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+	postID := c.Param("id")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	var newestFirst bool
+	switch c.DefaultQuery("order", "oldest") {
+	case "oldest":
+	case "newest":
+		newestFirst = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be oldest or newest"})
+		return
+	}
+	page, lastEvaluatedKey, err := h.decodeCommentCursor(c.Query("next_key"), commentCursorComments, postID, "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
+		return
+	}
+	if page != nil {
+		newestFirst = page.Newest
+	}
+
+	threads, nextEvaluatedKey, err := h.postService.ListCommentThreads(c.Request.Context(), postID, viewerID, newestFirst, int32(limit), lastEvaluatedKey)
+	if err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list comments"})
+		return
+	}
+	for i := range threads {
+		threads[i].RepliesNextKey = h.encodeCommentCursor(commentCursor{
+			Kind:      commentCursorReplies,
+			PostID:    postID,
+			CommentID: threads[i].CommentID,
+		}, threads[i].RepliesLastEvaluatedKey)
+	}
+
+	nextKey := h.encodeCommentCursor(commentCursor{Kind: commentCursorComments, PostID: postID, Newest: newestFirst}, nextEvaluatedKey)
+	c.JSON(http.StatusOK, gin.H{"data": threads, "next_key": nextKey})
+}
+
+// ListReplies 依時間由舊到新分頁列出某則評論的回覆；next_key 可以是評論列表中的 replies_next_key
+func (h *PostHandler) ListReplies(c *gin.Context) {
+	viewerID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+	postID := c.Param("id")
+	commentID := c.Param("commentID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	_, lastEvaluatedKey, err := h.decodeCommentCursor(c.Query("next_key"), commentCursorReplies, postID, commentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid next_key"})
+		return
+	}
+
+	replies, nextEvaluatedKey, err := h.postService.ListReplies(c.Request.Context(), postID, commentID, viewerID, int32(limit), lastEvaluatedKey)
+	if err != nil {
+		if respondNotVisible(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list replies"})
+		return
+	}
+
+	nextKey := h.encodeCommentCursor(commentCursor{Kind: commentCursorReplies, PostID: postID, CommentID: commentID}, nextEvaluatedKey)
+	c.JSON(http.StatusOK, gin.H{"data": replies, "next_key": nextKey})
+}
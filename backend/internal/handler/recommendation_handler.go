@@ -2,8 +2,10 @@
 package handler
 
 import (
+	"backend/internal/models"
 	"backend/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,12 +13,14 @@ import (
 // RecommendationHandler 結構
 type RecommendationHandler struct {
 	recoService *service.RecommendationService
+	muteService *service.MuteService
 }
 
 // NewRecommendationHandler 是 RecommendationHandler 的建構子
-func NewRecommendationHandler(recoService *service.RecommendationService) *RecommendationHandler {
+func NewRecommendationHandler(recoService *service.RecommendationService, muteService *service.MuteService) *RecommendationHandler {
 	return &RecommendationHandler{
 		recoService: recoService,
+		muteService: muteService,
 	}
 }
 
@@ -29,4 +33,30 @@ func (h *RecommendationHandler) GenerateTrending(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Trending recommendation generation process started."})
-}
\ No newline at end of file
+}
+
+// GetTrendingTags 處理讀取熱門標籤列表的請求，瀏覽者靜音的標籤不會出現在列表中
+func (h *RecommendationHandler) GetTrendingTags(c *gin.Context) {
+	viewerID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 50"})
+		return
+	}
+
+	var filters []models.MuteFilter
+	if h.muteService != nil {
+		filters = h.muteService.ActiveFilters(c.Request.Context(), viewerID)
+	}
+
+	tags, err := h.recoService.GetTrendingTags(c.Request.Context(), int32(limit), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get trending tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tags})
+}
@@ -0,0 +1,105 @@
+// internal/handler/stream_handler.go
+package handler
+
+import (
+	"backend/internal/realtime"
+	"backend/internal/service"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxStreamPosts 限制單一連線可訂閱計數變化的貼文數量
+	maxStreamPosts = 100
+	// streamHeartbeatInterval 定期送出註解行，避免代理伺服器或瀏覽器因閒置而關閉連線
+	streamHeartbeatInterval = 25 * time.Second
+)
+
+// StreamHandler 以 Server-Sent Events 推送通知、新動態與貼文計數變化
+type StreamHandler struct {
+	hub         *realtime.Hub
+	postService *service.PostService
+}
+
+// NewStreamHandler 是 StreamHandler 的建構子
+func NewStreamHandler(hub *realtime.Hub, postService *service.PostService) *StreamHandler {
+	return &StreamHandler{hub: hub, postService: postService}
+}
+
+// Stream 建立 SSE 連線。
+// 查詢參數 posts 為以逗號分隔的貼文 ID，代表客戶端目前畫面上的貼文；
+// 畫面上的貼文改變時，客戶端重新建立連線即可更新訂閱。
+// 不存在或使用者無權查看的貼文 (例如僅限粉絲的貼文) 會被略過，不回報錯誤，避免透露貼文是否存在。
+func (h *StreamHandler) Stream(c *gin.Context) {
+	userID, ok := getAuthenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var postIDs []string
+	for _, id := range strings.Split(c.Query("posts"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			postIDs = append(postIDs, id)
+		}
+	}
+	if len(postIDs) > maxStreamPosts {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many posts to subscribe to"})
+		return
+	}
+
+	visibleIDs, err := h.postService.VisiblePostIDs(c.Request.Context(), uniqueParams(postIDs), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check posts"})
+		return
+	}
+
+	client := h.hub.Register(userID, visibleIDs)
+	defer h.hub.Unregister(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 關閉 nginx 對此回應的緩衝
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.SSEvent("ready", gin.H{"user_id": userID})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-client.Events():
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event.Data)
+			return true
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return false
+			}
+			return true
+		}
+	})
+}
+
+// uniqueParams 去除重複的查詢參數值並保留順序
+func uniqueParams(values []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
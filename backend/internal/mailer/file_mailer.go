@@ -0,0 +1,43 @@
+// internal/mailer/file_mailer.go
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fileMailer 將郵件寫成 .eml 檔案而不實際寄出，供本機開發或沒有 SMTP 伺服器的環境使用
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer 是 fileMailer 的建構子
+func NewFileMailer(dir, from string) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory %s: %w", dir, err)
+	}
+	return &fileMailer{dir: dir, from: from}, nil
+}
+
+// Send 將郵件寫入 {dir}/{timestamp}-{uuid}.eml
+func (m *fileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405"), uuid.New().String())
+	path := filepath.Join(m.dir, name)
+	_, _, data, err := buildMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write mail to %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+// internal/mailer/mailer.go
+package mailer
+
+import "context"
+
+// Message 是一封純文字電子郵件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 定義了寄送電子郵件的介面，讓服務層不需要知道實際的寄送方式
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,92 @@
+// internal/mailer/smtp_mailer.go
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAddress 表示寄件者或收件者不是合法的電子郵件地址
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// smtpMailer 透過 SMTP 伺服器寄送郵件
+type smtpMailer struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailer 是 smtpMailer 的建構子；username 為空時不進行驗證
+func NewSMTPMailer(host string, port int, username, password, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		host: host,
+		auth: auth,
+		from: from,
+	}
+}
+
+// Send 寄出郵件；net/smtp 不支援 context，因此只在寄送前檢查是否已取消
+func (m *smtpMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	from, to, data, err := buildMessage(m.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(m.addr, m.auth, from, []string{to}, data); err != nil {
+		return fmt.Errorf("failed to send mail to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// parseAddress 以 net/mail 驗證單一電子郵件地址，拒絕含有換行等無法放進標頭的內容
+func parseAddress(address string) (*mail.Address, error) {
+	if strings.ContainsAny(address, "\r\n") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+	return parsed, nil
+}
+
+// buildMessage 組出包含標頭的 RFC 5322 郵件內容，並回傳 SMTP envelope 使用的寄件者與收件者地址。
+// 地址先經過 parseAddress 驗證，主旨移除換行，避免使用者輸入的內容注入額外的標頭
+func buildMessage(from string, msg Message) (string, string, []byte, error) {
+	fromAddr, err := parseAddress(from)
+	if err != nil {
+		return "", "", nil, err
+	}
+	toAddr, err := parseAddress(msg.To)
+	if err != nil {
+		return "", "", nil, err
+	}
+	subject := strings.NewReplacer("\r", "", "\n", " ").Replace(msg.Subject)
+
+	var b strings.Builder
+	b.WriteString("From: " + fromAddr.String() + "\r\n")
+	b.WriteString("To: " + toAddr.String() + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return fromAddr.Address, toAddr.Address, []byte(b.String()), nil
+}
@@ -0,0 +1,42 @@
+// internal/models/bookmark_model.go
+package models
+
+import "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+// Bookmark 是使用者私下收藏的貼文，存放在 Posts 表中使用者的分割區，每筆收藏寫入以下項目：
+//   - USER#{user_id} / BOOKMARK#{created_at}#{post_id}：依收藏時間排序的全部收藏
+//   - USER#{user_id} / BOOKMARKED#{post_id}：以貼文 ID 查詢是否已收藏、取消收藏時找回其他項目
+//   - USER#{user_id} / COLLECTIONITEM#{collection}#{created_at}#{post_id}：放入收藏集時，依收藏集分頁列出
+type Bookmark struct {
+	PK         string `dynamodbav:"PK" json:"-"`
+	SK         string `dynamodbav:"SK" json:"-"`
+	EntityType string `dynamodbav:"entity_type" json:"-"`
+	UserID     string `dynamodbav:"user_id" json:"user_id"`
+	PostID     string `dynamodbav:"post_id" json:"post_id"`
+	Collection string `dynamodbav:"collection,omitempty" json:"collection,omitempty"` // 空字串代表不屬於任何收藏集
+	CreatedAt  string `dynamodbav:"created_at" json:"created_at"`
+}
+
+// BookmarkCollection 是使用者自訂的收藏集
+// PK = USER#{user_id}, SK = COLLECTION#{name}
+type BookmarkCollection struct {
+	PK         string `dynamodbav:"PK" json:"-"`
+	SK         string `dynamodbav:"SK" json:"-"`
+	EntityType string `dynamodbav:"entity_type" json:"-"`
+	UserID     string `dynamodbav:"user_id" json:"-"`
+	Name       string `dynamodbav:"name" json:"name"`
+	ItemCount  int    `dynamodbav:"item_count" json:"item_count"`
+	CreatedAt  string `dynamodbav:"created_at" json:"created_at"`
+	UpdatedAt  string `dynamodbav:"updated_at" json:"updated_at"`
+}
+
+// BookmarkPayload 是收藏貼文的請求內容，collection 為空時只加入全部收藏
+type BookmarkPayload struct {
+	Collection string `json:"collection"`
+}
+
+// PaginatedBookmarks 是收藏列表的分頁結果
+type PaginatedBookmarks struct {
+	Items            []Bookmark
+	LastEvaluatedKey map[string]types.AttributeValue
+}
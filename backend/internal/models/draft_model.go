@@ -0,0 +1,60 @@
+// internal/models/draft_model.go
+package models
+
+// 草稿的狀態
+const (
+	DraftStatusDraft      = "draft"      // 只有作者看得到，不會自動發布
+	DraftStatusScheduled  = "scheduled"  // 等待在 publish_at 由排程發布
+	DraftStatusPublishing = "publishing" // 已被某個排程程序領取，正在發布中
+	DraftStatusFailed     = "failed"     // 排程發布多次失敗，不再自動重試，等待作者修改後重新排程或發布
+)
+
+// ScheduledPostsPK 是排程索引的分割區，所有等待發布的草稿都在這裡依 publish_at 排序
+const ScheduledPostsPK = "SCHEDULED_POSTS"
+
+// Draft 是尚未發布的貼文 (草稿或排程貼文)，只有作者能讀取與編輯；發布時刪除並建立一般的貼文
+// PK = USER#{author_id}, SK = DRAFT#{draft_id}
+type Draft struct {
+	PK         string      `dynamodbav:"PK" json:"-"`
+	SK         string      `dynamodbav:"SK" json:"-"`
+	EntityType string      `dynamodbav:"entity_type" json:"-"`
+	DraftID    string      `dynamodbav:"draft_id" json:"draft_id"`
+	AuthorID   string      `dynamodbav:"author_id" json:"author_id"`
+	Content    string      `dynamodbav:"content" json:"content"`
+	Media      []MediaItem `dynamodbav:"media,omitempty" json:"media,omitempty"`
+	Tags       []string    `dynamodbav:"tags,omitempty" json:"tags,omitempty"`
+	Location   *Location   `dynamodbav:"location,omitempty" json:"location,omitempty"`
+	Visibility string      `dynamodbav:"visibility,omitempty" json:"visibility,omitempty"`
+	Status     string      `dynamodbav:"status" json:"status"`
+	PublishAt  string      `dynamodbav:"publish_at,omitempty" json:"publish_at,omitempty"` // RFC3339 (UTC，秒為單位)；草稿為空
+	LeaseUntil string      `dynamodbav:"lease_until,omitempty" json:"-"`                   // 發布中的領取期限，過期後可由其他程序重新領取
+	CreatedAt  string      `dynamodbav:"created_at" json:"created_at"`
+	UpdatedAt  string      `dynamodbav:"updated_at" json:"updated_at"` // 同時作為編輯時的樂觀鎖
+}
+
+// ScheduledPost 是排程索引中的一筆項目，與排程中的草稿在同一個交易中寫入與刪除
+// PK = SCHEDULED_POSTS, SK = {publish_at}#{draft_id}
+// 發布後會記錄新貼文的主鍵，直到 fan-out 放入佇列後才刪除，讓中途重啟的程序可以補做 fan-out
+type ScheduledPost struct {
+	PK              string `dynamodbav:"PK"`
+	SK              string `dynamodbav:"SK"`
+	EntityType      string `dynamodbav:"entity_type"`
+	DraftID         string `dynamodbav:"draft_id"`
+	AuthorID        string `dynamodbav:"author_id"`
+	PublishAt       string `dynamodbav:"publish_at"`
+	PublishedPostID string `dynamodbav:"published_post_id,omitempty"`
+	PostPK          string `dynamodbav:"post_pk,omitempty"`
+	PostSK          string `dynamodbav:"post_sk,omitempty"`
+	Attempts        int    `dynamodbav:"attempts,omitempty"` // 已失敗的次數
+	RetryAt         string `dynamodbav:"retry_at,omitempty"` // 失敗後延後到此時間 (RFC3339) 才再處理，避免擋住其他到期的項目
+}
+
+// SaveDraftPayload 是建立與編輯草稿的請求內容；publish_at (RFC3339) 不為空時成為排程貼文，為空時為草稿
+type SaveDraftPayload struct {
+	Content    string      `json:"content"`
+	Media      []MediaItem `json:"media,omitempty"`
+	Tags       []string    `json:"tags,omitempty"`
+	Location   *Location   `json:"location,omitempty"`
+	Visibility string      `json:"visibility,omitempty"` // 預設為 public
+	PublishAt  string      `json:"publish_at,omitempty"`
+}
@@ -22,11 +22,46 @@ type PostFeedDTO struct {
 	Media        []MediaItem `json:"media,omitempty"`    // MediaItem 應已在 feed_model.go 中定義
 	Tags         []string    `json:"tags,omitempty"`     // stringset 在 DynamoDB, JSON 為 array of strings
 	Location     *Location   `json:"location,omitempty"` // Location 應已在 feed_model.go 中定義
+	Mentions     []Mention   `json:"mentions,omitempty"` // 被提及的使用者與其在內文中的位置
 	LikeCount    int         `json:"like_count"`
 	CommentCount int         `json:"comment_count"`
 	CreatedAt    string      `json:"created_at"` // ISO 8601 String
 	UpdatedAt    string      `json:"updated_at"` // ISO 8601 String
 	IsLiked      bool        `json:"isLiked"`
+	Bookmarked   bool        `json:"bookmarked"` // 瀏覽者是否收藏過此貼文，只有本人看得到
+	Visibility   string      `json:"visibility"`
+	RepostCount  int         `json:"repost_count"`
+	IsReposted   bool        `json:"is_reposted"`
+	PostType     string      `json:"post_type"` // post、repost 或 quote
+
+	// 轉發與引用附上的原始貼文；原始貼文已刪除或瀏覽者無權查看時為 nil，並將 OriginalUnavailable 設為 true
+	OriginalPost        *PostFeedDTO `json:"original_post,omitempty"`
+	OriginalUnavailable bool         `json:"original_unavailable,omitempty"`
+}
+
+// PostFeedDTO.PostType 的值
+const (
+	PostTypePost   = "post"
+	PostTypeRepost = "repost"
+	PostTypeQuote  = "quote"
+)
+
+// PostAuthorDTO 是單篇貼文頁面上的作者資訊
+type PostAuthorDTO struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+	Bio       string `json:"bio,omitempty"`
+}
+
+// PostDetailDTO 是單篇貼文 (permalink) 的回應：貼文、作者資訊與第一頁評論
+type PostDetailDTO struct {
+	PostFeedDTO
+	Author          PostAuthorDTO      `json:"author"`
+	Comments        []CommentThreadDTO `json:"comments"`
+	CommentsNextKey string             `json:"comments_next_key,omitempty"`
+
+	CommentsLastEvaluatedKey map[string]types.AttributeValue `json:"-"` // 由 handler 編碼為 CommentsNextKey
 }
 
 // UserFeedItem 代表 UserFeed 表中的一個項目
@@ -45,6 +80,15 @@ type PaginatedFeed struct {
 	LastEvaluatedKey map[string]types.AttributeValue
 }
 
+// FeedRebuildReport 是重建單一使用者 Feed 時的比對結果
+type FeedRebuildReport struct {
+	UserID   string
+	Expected int            // 依追蹤關係與作者貼文應該存在的項目數
+	Missing  []UserFeedItem // 應該存在但不在 UserFeed 中
+	Extra    []UserFeedItem // 作者已不在追蹤中 (或改為讀取時拉取) 的項目
+	Orphaned []UserFeedItem // 指向已不存在貼文的項目
+}
+
 type UserRecommendationItem struct {
 	PK               string `dynamodbav:"PK"`
 	SK               string `dynamodbav:"SK"`
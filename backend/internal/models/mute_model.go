@@ -0,0 +1,31 @@
+// internal/models/mute_model.go
+package models
+
+// 靜音條件的類型
+const (
+	MuteKindWord    = "word"    // 完整的單字，不分大小寫
+	MuteKindPhrase  = "phrase"  // 內文包含此字串即符合，不分大小寫
+	MuteKindHashtag = "hashtag" // 貼文帶有此標籤
+)
+
+// MuteFilter 是使用者的靜音條件，存放在 Posts 表 (PK = USER#{user_id}, SK = MUTE#{filter_id})
+type MuteFilter struct {
+	PK         string `dynamodbav:"PK" json:"-"`
+	SK         string `dynamodbav:"SK" json:"-"`
+	EntityType string `dynamodbav:"entity_type" json:"-"` // "MUTE"
+	FilterID   string `dynamodbav:"filter_id" json:"filter_id"`
+	UserID     string `dynamodbav:"user_id" json:"user_id"`
+	Kind       string `dynamodbav:"kind" json:"kind"`
+	Value      string `dynamodbav:"value" json:"value"`
+	CreatedAt  string `dynamodbav:"created_at" json:"created_at"`
+	ExpiresAt  string `dynamodbav:"expires_at,omitempty" json:"expires_at,omitempty"` // 空字串代表永久有效
+	// TTLTimestamp 與 ExpiresAt 相同 (Unix 秒)，讓 DynamoDB TTL 清除過期的條件；TTL 刪除有延遲，讀取時仍以 ExpiresAt 判斷
+	TTLTimestamp int64 `dynamodbav:"TTLTimestamp,omitempty" json:"-"`
+}
+
+// CreateMuteFilterPayload 是新增靜音條件的請求內容
+type CreateMuteFilterPayload struct {
+	Kind          string `json:"kind" binding:"required"`
+	Value         string `json:"value" binding:"required"`
+	ExpiresInDays int    `json:"expires_in_days"` // 0 代表永久有效
+}
@@ -0,0 +1,147 @@
+// internal/models/notification_model.go
+package models
+
+import "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+// 通知類型，沿用匯出資料 (Posts.csv) 中的命名
+const (
+	NotificationTypeNewComment  = "NEW_COMMENT_ON_YOUR_POST"
+	NotificationTypeNewLike     = "NEW_LIKE_ON_YOUR_POST"
+	NotificationTypeCommentLike = "NEW_LIKE_ON_YOUR_COMMENT"
+	NotificationTypeNewFollower = "NEW_FOLLOWER"
+	NotificationTypeMention     = "MENTIONED_YOU"
+	NotificationTypeRepost      = "REPOSTED_YOUR_POST" // 轉發與引用共用
+)
+
+// Notification 是存放在 Posts 表中的通知項目
+// PK = USER#{recipient_user_id}, SK = NOTIFICATION#{timestamp}#{notification_id}
+type Notification struct {
+	PK               string   `dynamodbav:"PK" json:"-"`
+	SK               string   `dynamodbav:"SK" json:"notification_sk"`
+	EntityType       string   `dynamodbav:"entity_type" json:"-"`
+	NotificationID   string   `dynamodbav:"notification_id" json:"notification_id"`
+	RecipientUserID  string   `dynamodbav:"recipient_user_id" json:"recipient_user_id"`
+	NotificationType string   `dynamodbav:"notification_type" json:"notification_type"`
+	ActorID          string   `dynamodbav:"actor_id" json:"actor_id"`                                         // 最近一次觸發通知的使用者
+	ActorIDs         []string `dynamodbav:"actor_ids,omitempty" json:"actor_ids,omitempty"`                   // 群組通知中最近的幾位使用者
+	ActorCount       int      `dynamodbav:"actor_count,omitempty" json:"actor_count,omitempty"`               // 群組通知涉及的使用者總數
+	GroupKey         string   `dynamodbav:"group_key,omitempty" json:"-"`                                     // {notification_type}#{target_entity_id}
+	TargetEntityType string   `dynamodbav:"target_entity_type,omitempty" json:"target_entity_type,omitempty"` // 例如 POST
+	TargetEntityID   string   `dynamodbav:"target_entity_id,omitempty" json:"target_entity_id,omitempty"`
+	RelatedEntityID  string   `dynamodbav:"related_entity_id,omitempty" json:"related_entity_id,omitempty"` // 例如評論 ID
+	Message          string   `dynamodbav:"message" json:"message"`
+	ReadStatus       bool     `dynamodbav:"read_status" json:"read_status"`
+	ReadAt           string   `dynamodbav:"read_at,omitempty" json:"read_at,omitempty"`
+	CreatedAt        string   `dynamodbav:"created_at" json:"created_at"`
+}
+
+// NotificationGroupPointer 指向某個群組目前仍在累加的通知
+// PK = USER#{recipient_user_id}, SK = NOTIFGROUP#{group_key}
+type NotificationGroupPointer struct {
+	PK             string `dynamodbav:"PK"`
+	SK             string `dynamodbav:"SK"`
+	EntityType     string `dynamodbav:"entity_type"`
+	NotificationSK string `dynamodbav:"notification_sk"`
+	TTLTimestamp   int64  `dynamodbav:"TTLTimestamp"` // 每次累加時延長，群組閒置後由 TTL 清除
+}
+
+// PaginatedNotifications 是通知列表的分頁結果
+type PaginatedNotifications struct {
+	Items            []Notification
+	LastEvaluatedKey map[string]types.AttributeValue
+}
+
+// 通知偏好的類別，每個類別對應一或多種通知類型
+const (
+	NotificationCategoryLikes    = "likes"
+	NotificationCategoryComments = "comments"
+	NotificationCategoryFollows  = "follows"
+	NotificationCategoryMentions = "mentions"
+	NotificationCategoryReposts  = "reposts"
+)
+
+// 通知的傳遞管道：in_app 只寫入站內通知；email 同時寫入站內通知並列入電子郵件摘要；off 則完全不建立通知
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelEmail = "email"
+	NotificationChannelOff   = "off"
+)
+
+// 電子郵件摘要的寄送頻率
+const (
+	DigestFrequencyOff    = "off"
+	DigestFrequencyDaily  = "daily"
+	DigestFrequencyWeekly = "weekly"
+)
+
+// NotificationCategories 列出所有可設定偏好的類別
+var NotificationCategories = []string{
+	NotificationCategoryLikes,
+	NotificationCategoryComments,
+	NotificationCategoryFollows,
+	NotificationCategoryMentions,
+	NotificationCategoryReposts,
+}
+
+// NotificationCategoryOf 回傳通知類型所屬的偏好類別
+func NotificationCategoryOf(notificationType string) string {
+	switch notificationType {
+	case NotificationTypeNewLike, NotificationTypeCommentLike:
+		return NotificationCategoryLikes
+	case NotificationTypeNewComment:
+		return NotificationCategoryComments
+	case NotificationTypeNewFollower:
+		return NotificationCategoryFollows
+	case NotificationTypeMention:
+		return NotificationCategoryMentions
+	case NotificationTypeRepost:
+		return NotificationCategoryReposts
+	}
+	return ""
+}
+
+// DigestDuePK 是摘要排程索引 (GSI1) 的分割區，開啟摘要的使用者依下一次寄送時間排序
+const DigestDuePK = "DIGEST_DUE"
+
+// NotificationPreferences 是使用者的通知偏好，存放在 Posts 表
+// PK = USER#{user_id}, SK = NOTIFPREFS
+// 開啟摘要時 GSI1PK = DIGEST_DUE, GSI1SK = {下一次寄送時間 (RFC3339)}#{user_id}，關閉時移除，讓排程只讀取到期的使用者
+type NotificationPreferences struct {
+	PK              string            `dynamodbav:"PK" json:"-"`
+	SK              string            `dynamodbav:"SK" json:"-"`
+	GSI1PK          string            `dynamodbav:"GSI1PK,omitempty" json:"-"`
+	GSI1SK          string            `dynamodbav:"GSI1SK,omitempty" json:"-"`
+	EntityType      string            `dynamodbav:"entity_type" json:"-"`
+	UserID          string            `dynamodbav:"user_id" json:"user_id"`
+	Channels        map[string]string `dynamodbav:"channels" json:"channels"` // 類別 -> 傳遞管道
+	DigestFrequency string            `dynamodbav:"digest_frequency" json:"digest_frequency"`
+	LastDigestAt    string            `dynamodbav:"last_digest_at,omitempty" json:"last_digest_at,omitempty"`
+	UpdatedAt       string            `dynamodbav:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// DefaultNotificationPreferences 回傳尚未設定偏好的使用者所使用的預設值：全部只在站內通知，不寄送摘要
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	channels := make(map[string]string, len(NotificationCategories))
+	for _, category := range NotificationCategories {
+		channels[category] = NotificationChannelInApp
+	}
+	return &NotificationPreferences{
+		UserID:          userID,
+		Channels:        channels,
+		DigestFrequency: DigestFrequencyOff,
+	}
+}
+
+// ChannelFor 回傳指定通知類型的傳遞管道，未設定的類別視為 in_app
+func (p *NotificationPreferences) ChannelFor(notificationType string) string {
+	if channel, ok := p.Channels[NotificationCategoryOf(notificationType)]; ok {
+		return channel
+	}
+	return NotificationChannelInApp
+}
+
+// UpdateNotificationPreferencesPayload 是更新通知偏好的請求內容，未提供的欄位維持不變
+type UpdateNotificationPreferencesPayload struct {
+	Channels        map[string]string `json:"channels"`
+	DigestFrequency string            `json:"digest_frequency"`
+}
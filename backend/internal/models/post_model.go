@@ -1,5 +1,7 @@
 package models
 
+import "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
 type Post struct {
 	PK           string      `dynamodbav:"PK"`      // 例如 USER#{author_id}
 	SK           string      `dynamodbav:"SK"`      // 例如 POST#{timestamp}#{post_id}
@@ -12,10 +14,67 @@ type Post struct {
 	Media        []MediaItem `dynamodbav:"media,omitempty"` // omitempty 如果為空則不儲存
 	Tags         []string    `dynamodbav:"tags,stringset,omitempty"` // DynamoDB String Set
 	Location     *Location   `dynamodbav:"location,omitempty"`
+	Mentions     []string    `dynamodbav:"mentions,omitempty"`      // 被提及使用者的 ID
+	MentionSpans []Mention   `dynamodbav:"mention_spans,omitempty"` // 提及在內文中的位置
 	LikeCount    int         `dynamodbav:"like_count"`
 	CommentCount int         `dynamodbav:"comment_count"`
 	CreatedAt    string      `dynamodbav:"created_at"` // ISO 8601 String
 	UpdatedAt    string      `dynamodbav:"updated_at"` // ISO 8601 String
+	DeletedAt    string      `dynamodbav:"deleted_at,omitempty"` // 軟刪除標記，非空代表貼文已刪除、等待清理相依資料
+	Version      int         `dynamodbav:"version,omitempty"`    // 已編輯次數，同時作為編輯時的樂觀鎖版本
+	Visibility   string      `dynamodbav:"visibility,omitempty"` // PostVisibilityPublic 或 PostVisibilityFollowers，空字串視為公開
+	RepostCount  int         `dynamodbav:"repost_count"`          // 被轉發與引用的次數
+
+	// 轉發 (PostEntityRepost) 與引用 (PostEntityQuote) 才有的欄位，指向被分享的原始貼文
+	OriginalPostID string `dynamodbav:"original_post_id,omitempty"`
+	OriginalPK     string `dynamodbav:"original_pk,omitempty"` // 原始貼文的主鍵，刪除時用來遞減 repost_count
+	OriginalSK     string `dynamodbav:"original_sk,omitempty"`
+}
+
+// Posts 表中貼文類項目的 entity_type；三者共用 USER#{author_id} / POST#{timestamp}#{post_id} 的鍵結構
+const (
+	PostEntityPost   = "POST"
+	PostEntityRepost = "REPOST" // 單純轉發，沒有自己的內容
+	PostEntityQuote  = "QUOTE"  // 引用，附上自己的內容
+)
+
+// IsRepost 判斷是否為單純轉發
+func (p *Post) IsRepost() bool {
+	return p.EntityType == PostEntityRepost
+}
+
+// 貼文的可見範圍
+const (
+	PostVisibilityPublic    = "public"    // 任何人 (包含未登入的訪客) 都能看到
+	PostVisibilityFollowers = "followers" // 只有作者與其粉絲能看到，不會出現在標籤與熱門列表中
+)
+
+// IsPublic 判斷貼文是否公開，早期沒有 visibility 欄位的貼文皆為公開
+func (p *Post) IsPublic() bool {
+	return p.Visibility == "" || p.Visibility == PostVisibilityPublic
+}
+
+// ValidPostVisibility 判斷 visibility 是否為支援的值
+func ValidPostVisibility(visibility string) bool {
+	return visibility == PostVisibilityPublic || visibility == PostVisibilityFollowers
+}
+
+// PostVersion 保存貼文被編輯前的一個版本
+// PK = POST#{post_id}, SK = VERSION#{version，補零到 6 位}
+type PostVersion struct {
+	PK           string      `dynamodbav:"PK" json:"-"`
+	SK           string      `dynamodbav:"SK" json:"-"`
+	EntityType   string      `dynamodbav:"entity_type" json:"-"`
+	PostID       string      `dynamodbav:"post_id" json:"post_id"`
+	Version      int         `dynamodbav:"version" json:"version"` // 1 為原始內容
+	Content      string      `dynamodbav:"content" json:"content"`
+	Media        []MediaItem `dynamodbav:"media,omitempty" json:"media,omitempty"`
+	Tags         []string    `dynamodbav:"tags,omitempty" json:"tags,omitempty"`
+	Location     *Location   `dynamodbav:"location,omitempty" json:"location,omitempty"`
+	MentionSpans []Mention   `dynamodbav:"mention_spans,omitempty" json:"mentions,omitempty"`
+	Visibility   string      `dynamodbav:"visibility,omitempty" json:"visibility,omitempty"`
+	WrittenAt    string      `dynamodbav:"written_at" json:"written_at"`                       // 此版本成為目前內容的時間
+	ReplacedAt   string      `dynamodbav:"replaced_at,omitempty" json:"replaced_at,omitempty"` // 被下一個版本取代的時間；目前版本為空
 }
 
 // MediaItem 和 Location 結構也需要定義 (如果 Post 結構中使用它們)
@@ -29,19 +88,42 @@ type Location struct {
 	Longitude float64 `dynamodbav:"longitude"`
 }
 
+// Mention 記錄內文中被提及的使用者，Start/End 為 UTF-16 code unit 偏移量 (包含 @，與 JavaScript 字串索引一致)，讓前端可以建立連結
+type Mention struct {
+	UserID   string `dynamodbav:"user_id" json:"user_id"`
+	Username string `dynamodbav:"username" json:"username"`
+	Start    int    `dynamodbav:"start" json:"start"`
+	End      int    `dynamodbav:"end" json:"end"`
+}
+
 type CreatePostPayload struct {
-	AuthorID string      `json:"author_id" binding:"required"`
-	Content  string      `json:"content" binding:"required"`
-	Media    []MediaItem `json:"media,omitempty"`
-	Tags     []string    `json:"tags,omitempty"`
-	Location *Location   `json:"location,omitempty"`
+	AuthorID   string      `json:"author_id" binding:"required"`
+	Content    string      `json:"content" binding:"required"`
+	Media      []MediaItem `json:"media,omitempty"`
+	Tags       []string    `json:"tags,omitempty"`
+	Location   *Location   `json:"location,omitempty"`
+	Visibility string      `json:"visibility,omitempty"` // 預設為 public
+}
+
+// CreateQuotePayload 定義了引用貼文請求的 JSON 結構，被引用的貼文由路徑參數指定
+type CreateQuotePayload struct {
+	Content    string      `json:"content" binding:"required"`
+	Media      []MediaItem `json:"media,omitempty"`
+	Tags       []string    `json:"tags,omitempty"`
+	Visibility string      `json:"visibility,omitempty"` // 預設為 public
 }
 
-// UpdatePostPayload 定義了編輯貼文請求的 JSON 結構
+// UpdatePostPayload 定義了編輯貼文請求的 JSON 結構。
+// Media、Tags 未提供 (null) 時維持原值，提供空陣列則清空；Location 需以 remove_location 移除
 type UpdatePostPayload struct {
-	PostID  string `json:"post_id" binding:"required"`
-	Content string `json:"content" binding:"required"`
-	// 其他允許更新的欄位...
+	PostID         string       `json:"post_id" binding:"required"`
+	AuthorID       string       `json:"-"` // 由 handler 填入已驗證的使用者，交由 service 層進行權限驗證
+	Content        string       `json:"content" binding:"required"`
+	Media          *[]MediaItem `json:"media,omitempty"`
+	Tags           *[]string    `json:"tags,omitempty"`
+	Location       *Location    `json:"location,omitempty"`
+	RemoveLocation bool         `json:"remove_location,omitempty"`
+	Visibility     string       `json:"visibility,omitempty"` // 空字串代表維持原值
 }
 
 // DeletePostPayload 定義了刪除貼文請求的 JSON 結構
@@ -66,22 +148,124 @@ type Like struct {
 	CreatedAt  string `dynamodbav:"created_at"`
 }
 
-// Comment 包含了評論的詳細資訊
-type Comment struct {
-	PK           string `dynamodbav:"PK"`         // POST#{post_id}
-	SK           string `dynamodbav:"SK"`         // COMMENT#{timestamp}#{comment_id}
+// RepostLink 記錄了誰轉發了哪篇貼文，用來防止重複轉發、取消轉發與原始貼文刪除時找出轉發
+// PK = POST#{original_post_id}, SK = REPOST#{user_id}
+type RepostLink struct {
+	PK           string `dynamodbav:"PK"`
+	SK           string `dynamodbav:"SK"`
 	EntityType   string `dynamodbav:"entity_type"`
-	CommentID    string `dynamodbav:"comment_id"`
-	PostID       string `dynamodbav:"post_id"`
-	AuthorID     string `dynamodbav:"author_id"`
-	AuthorName   string `dynamodbav:"author_name"` // 冗餘儲存，方便查詢
-	Content      string `dynamodbav:"content"`
+	PostID       string `dynamodbav:"post_id"`        // 原始貼文
+	UserID       string `dynamodbav:"user_id"`        // 轉發者
+	RepostPostID string `dynamodbav:"repost_post_id"` // 轉發者分割區中的 REPOST 項目
 	CreatedAt    string `dynamodbav:"created_at"`
 }
 
+// CommentLike 記錄了誰對哪則評論按讚
+// PK = USER#{user_id}, SK = LIKEDCOMMENT#{comment_id}；GSI2 (COMMENT#{comment_id} / USER#{user_id}) 用於列出評論的按讚者
+type CommentLike struct {
+	PK            string `dynamodbav:"PK"`
+	SK            string `dynamodbav:"SK"`
+	GSI2PK        string `dynamodbav:"GSI2PK"`
+	GSI2SK        string `dynamodbav:"GSI2SK"`
+	EntityType    string `dynamodbav:"entity_type"` // LIKED_COMMENT
+	LikedEntityID string `dynamodbav:"liked_entity_id"`
+	LikerUserID   string `dynamodbav:"liker_user_id"`
+	PostID        string `dynamodbav:"post_id"`
+	CreatedAt     string `dynamodbav:"created_at"`
+}
+
+// Comment 包含了評論的詳細資訊
+// 頂層評論 SK = COMMENT#{timestamp}#{comment_id}；
+// 回覆 SK = REPLY#{parent_comment_id}#{timestamp}#{comment_id}，讓同一則評論的回覆在分割區中相鄰並依時間排序
+type Comment struct {
+	PK              string    `dynamodbav:"PK"` // POST#{post_id}
+	SK              string    `dynamodbav:"SK"`
+	GSI1PK          string    `dynamodbav:"GSI1PK,omitempty"` // COMMENT#{comment_id}，用於以 ID 查詢評論
+	GSI1SK          string    `dynamodbav:"GSI1SK,omitempty"` // METADATA
+	EntityType      string    `dynamodbav:"entity_type"`
+	CommentID       string    `dynamodbav:"comment_id"`
+	PostID          string    `dynamodbav:"post_id"`
+	ParentCommentID string    `dynamodbav:"parent_comment_id,omitempty"` // 空字串代表頂層評論
+	AuthorID        string    `dynamodbav:"author_id"`
+	AuthorName      string    `dynamodbav:"author_name"` // 冗餘儲存，方便查詢
+	Content         string    `dynamodbav:"content"`
+	Mentions        []string  `dynamodbav:"mentions,omitempty"`      // 被提及使用者的 ID
+	MentionSpans    []Mention `dynamodbav:"mention_spans,omitempty"` // 提及在內文中的位置
+	ReplyCount      int       `dynamodbav:"reply_count"`
+	LikeCount       int       `dynamodbav:"like_count"`
+	Deleted         bool      `dynamodbav:"deleted,omitempty"` // 仍有回覆的評論被刪除時只清空內容並保留此標記
+	EditCount       int       `dynamodbav:"edit_count,omitempty"` // 已編輯次數，同時作為編輯時的樂觀鎖版本
+	EditedAt        string    `dynamodbav:"edited_at,omitempty"`
+	CreatedAt       string    `dynamodbav:"created_at"`
+}
+
+// CommentEdit 保存評論被編輯前的一個版本
+// PK = POST#{post_id}, SK = COMMENTEDIT#{comment_id}#{version，補零到 6 位}
+type CommentEdit struct {
+	PK           string    `dynamodbav:"PK" json:"-"`
+	SK           string    `dynamodbav:"SK" json:"-"`
+	EntityType   string    `dynamodbav:"entity_type" json:"-"`
+	CommentID    string    `dynamodbav:"comment_id" json:"comment_id"`
+	PostID       string    `dynamodbav:"post_id" json:"post_id"`
+	Version      int       `dynamodbav:"version" json:"version"` // 1 為原始內容
+	Content      string    `dynamodbav:"content" json:"content"`
+	MentionSpans []Mention `dynamodbav:"mention_spans,omitempty" json:"mentions,omitempty"`
+	WrittenAt    string    `dynamodbav:"written_at" json:"written_at"`   // 此版本成為目前內容的時間
+	ReplacedAt   string    `dynamodbav:"replaced_at" json:"replaced_at"` // 被下一個版本取代的時間；目前版本為空
+}
+
+// UpdateCommentPayload 定義了編輯評論請求的 JSON 結構
+type UpdateCommentPayload struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// DeletedCommentPlaceholder 取代已刪除、但仍有回覆的評論內容
+const DeletedCommentPlaceholder = "[deleted]"
+
 // CreateCommentPayload 定義了新增評論請求的 JSON 結構
 type CreateCommentPayload struct {
-	PostID   string `json:"post_id" binding:"required"`
-	AuthorID string `json:"author_id" binding:"required"`
-	Content  string `json:"content" binding:"required"`
+	PostID          string `json:"post_id" binding:"required"`
+	AuthorID        string `json:"author_id" binding:"required"`
+	Content         string `json:"content" binding:"required"`
+	ParentCommentID string `json:"parent_comment_id"` // 回覆某則評論時填入；回覆一則回覆時會歸到同一個頂層評論之下
+}
+
+// CommentDTO 是回傳給前端的評論
+type CommentDTO struct {
+	CommentID       string    `json:"comment_id"`
+	CommentSK       string    `json:"comment_sk"` // 刪除評論時使用
+	PostID          string    `json:"post_id"`
+	ParentCommentID string    `json:"parent_comment_id,omitempty"`
+	AuthorID        string    `json:"author_id,omitempty"`
+	AuthorName      string    `json:"author_name,omitempty"`
+	AuthorAvatarURL string    `json:"author_avatar_url,omitempty"` // 來自 user_profiles
+	Content         string    `json:"content"`
+	Mentions        []Mention `json:"mentions,omitempty"`
+	ReplyCount      int       `json:"reply_count"`
+	LikeCount       int       `json:"like_count"`
+	Deleted         bool      `json:"deleted"`
+	IsLiked         bool      `json:"is_liked"` // 目前使用者是否對此評論按讚
+	EditedAt        string    `json:"edited_at,omitempty"` // 有值代表評論被編輯過
+	CreatedAt       string    `json:"created_at"`
+}
+
+// CommentThreadDTO 是一則頂層評論與其最前面的幾則回覆
+type CommentThreadDTO struct {
+	CommentDTO
+	Replies        []CommentDTO `json:"replies"`
+	RepliesNextKey string       `json:"replies_next_key,omitempty"` // 還有更多回覆時，可用於回覆列表端點
+
+	RepliesLastEvaluatedKey map[string]types.AttributeValue `json:"-"` // 由 handler 編碼為 RepliesNextKey
+}
+
+// PaginatedPosts 是作者貼文列表的分頁結果
+type PaginatedPosts struct {
+	Items            []Post
+	LastEvaluatedKey map[string]types.AttributeValue
+}
+
+// PaginatedComments 是評論或回覆列表的分頁結果
+type PaginatedComments struct {
+	Items            []Comment
+	LastEvaluatedKey map[string]types.AttributeValue
 }
\ No newline at end of file
@@ -0,0 +1,24 @@
+// internal/models/ranking_model.go
+package models
+
+// Affinity 記錄使用者與某位作者的互動次數，存放在 Posts 表 (PK = USER#{user_id}, SK = AFFINITY#{author_id})
+type Affinity struct {
+	PK           string `dynamodbav:"PK"`
+	SK           string `dynamodbav:"SK"`
+	EntityType   string `dynamodbav:"entity_type"` // "AFFINITY"
+	AuthorID     string `dynamodbav:"author_id"`
+	LikeCount    int    `dynamodbav:"like_count"`
+	CommentCount int    `dynamodbav:"comment_count"`
+	UpdatedAt    string `dynamodbav:"updated_at"`
+}
+
+// ScoreBreakdown 是排序模式下單篇貼文的分數組成，只在 debug 模式回傳
+type ScoreBreakdown struct {
+	PostID      string  `json:"post_id"`
+	Source      string  `json:"source"` // "feed" 或 "trending"
+	Recency     float64 `json:"recency"`
+	Affinity    float64 `json:"affinity"`
+	Velocity    float64 `json:"velocity"`
+	SeenPenalty float64 `json:"seen_penalty"`
+	Total       float64 `json:"total"`
+}
@@ -0,0 +1,26 @@
+// internal/models/seen_model.go
+package models
+
+// SeenItem 代表 SeenHistory 表中使用者看過某篇貼文的紀錄。
+// 主表 (PK = USER#{user_id}, SK = POST#{post_id}) 用於過濾看過的貼文，
+// GSI1 (GSI1PK = POST#{post_id}, GSI1SK = USER#{user_id}) 用於計算貼文的觀看人數。
+type SeenItem struct {
+	PK           string `dynamodbav:"PK"`
+	SK           string `dynamodbav:"SK"`
+	GSI1PK       string `dynamodbav:"GSI1PK"`
+	GSI1SK       string `dynamodbav:"GSI1SK"`
+	SeenAt       string `dynamodbav:"SeenAt"`
+	AuthorID     string `dynamodbav:"AuthorID"`
+	TTLTimestamp int64  `dynamodbav:"TTLTimestamp"` // 用於 TTL 的時間戳記
+}
+
+// RecordImpressionsPayload 是客戶端在貼文捲動進入畫面時批次回報的內容
+type RecordImpressionsPayload struct {
+	PostIDs []string `json:"post_ids" binding:"required"`
+}
+
+// PostViewStats 是貼文作者可以看到的觀看統計
+type PostViewStats struct {
+	PostID      string `json:"post_id"`
+	ViewerCount int    `json:"viewer_count"` // 看過此貼文的不重複使用者數 (不含作者本人，紀錄過期後不再計入)
+}
@@ -0,0 +1,37 @@
+// internal/models/tag_model.go
+package models
+
+import "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+// TagIndexItem 是 hashtag 索引項目，與貼文存放在同一張 Posts 表
+// PK = TAG#{tag}, SK = {created_at}#{post_id}，因此同一標籤下的貼文依時間排序
+type TagIndexItem struct {
+	PK         string `dynamodbav:"PK"` // TAG#{normalized_tag}
+	SK         string `dynamodbav:"SK"` // {created_at}#{post_id}
+	EntityType string `dynamodbav:"entity_type"`
+	Tag        string `dynamodbav:"tag"`
+	PostID     string `dynamodbav:"post_id"`
+	AuthorID   string `dynamodbav:"author_id"`
+	CreatedAt  string `dynamodbav:"created_at"`
+}
+
+// PaginatedTagPosts 是標籤時間軸的分頁結果
+type PaginatedTagPosts struct {
+	Items            []TagIndexItem
+	LastEvaluatedKey map[string]types.AttributeValue
+}
+
+// TrendingTagItem 是熱門標籤列表中的一筆資料，存放在 UserRecommendations 表
+// PK = TRENDING_TAGS#{algorithm_version}, SK = {score}#{tag}，查詢時依分數降序排列
+type TrendingTagItem struct {
+	PK               string  `dynamodbav:"PK" json:"-"`
+	SK               string  `dynamodbav:"SK" json:"-"`
+	Tag              string  `dynamodbav:"Tag" json:"tag"`
+	Count1h          int     `dynamodbav:"Count1h" json:"count_1h"`
+	Count24h         int     `dynamodbav:"Count24h" json:"count_24h"`
+	Count7d          int     `dynamodbav:"Count7d" json:"count_7d"`
+	Acceleration     float64 `dynamodbav:"Acceleration" json:"acceleration"` // 大於 1 表示使用量正在加速
+	Score            float64 `dynamodbav:"Score" json:"score"`
+	AlgorithmVersion string  `dynamodbav:"AlgorithmVersion" json:"algorithm_version"`
+	GeneratedAt      string  `dynamodbav:"GeneratedAt" json:"generated_at"`
+}
@@ -0,0 +1,109 @@
+// internal/queue/memory_queue.go
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryQueue 是行程內的佇列實作，行為與 redisQueue 相同但不具持久性，
+// 適用於測試或未設定 Redis 的單機開發環境
+type memoryQueue struct {
+	mu       sync.Mutex
+	ready    []*Job
+	inflight map[string]memoryEntry // job ID -> 工作與可見性期限
+	delayed  map[string]memoryEntry // job ID -> 工作與到期時間
+	dead     []*Job
+}
+
+type memoryEntry struct {
+	job *Job
+	at  time.Time
+}
+
+// NewMemoryQueue 是 memoryQueue 的建構子
+func NewMemoryQueue() Queue {
+	return &memoryQueue{
+		inflight: make(map[string]memoryEntry),
+		delayed:  make(map[string]memoryEntry),
+	}
+}
+
+func (q *memoryQueue) Enqueue(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ready = append(q.ready, copyJob(job))
+	return nil
+}
+
+func (q *memoryQueue) Dequeue(ctx context.Context, visibility time.Duration) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for id, entry := range q.delayed {
+		if !entry.at.After(now) {
+			delete(q.delayed, id)
+			q.ready = append(q.ready, entry.job)
+		}
+	}
+	for id, entry := range q.inflight {
+		if !entry.at.After(now) {
+			delete(q.inflight, id)
+			q.ready = append([]*Job{entry.job}, q.ready...)
+		}
+	}
+
+	if len(q.ready) == 0 {
+		return nil, nil
+	}
+	job := q.ready[0]
+	q.ready = q.ready[1:]
+	q.inflight[job.ID] = memoryEntry{job: job, at: now.Add(visibility)}
+	return copyJob(job), nil
+}
+
+func (q *memoryQueue) Ack(ctx context.Context, job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inflight, job.ID)
+	return nil
+}
+
+func (q *memoryQueue) Retry(ctx context.Context, job *Job, delay time.Duration, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.Attempts++
+	job.LastError = cause.Error()
+	delete(q.inflight, job.ID)
+	q.delayed[job.ID] = memoryEntry{job: copyJob(job), at: time.Now().Add(delay)}
+	return nil
+}
+
+func (q *memoryQueue) DeadLetter(ctx context.Context, job *Job, cause error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job.Attempts++
+	job.LastError = cause.Error()
+	delete(q.inflight, job.ID)
+	q.dead = append(q.dead, copyJob(job))
+	return nil
+}
+
+func (q *memoryQueue) Stats(ctx context.Context) (Stats, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return Stats{
+		Ready:    int64(len(q.ready)),
+		InFlight: int64(len(q.inflight)),
+		Delayed:  int64(len(q.delayed)),
+		Dead:     int64(len(q.dead)),
+	}, nil
+}
+
+// copyJob 避免呼叫端修改佇列內部保存的工作
+func copyJob(job *Job) *Job {
+	c := *job
+	return &c
+}
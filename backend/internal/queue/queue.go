@@ -0,0 +1,82 @@
+// internal/queue/queue.go
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Job 是佇列中的一個工作。
+// 佇列提供 at-least-once 的傳遞保證：工作被取出後必須在可見性逾時內 Ack，
+// 否則會被重新放回佇列，因此 Handler 必須是冪等的。
+type Job struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"attempts"` // 已失敗的次數
+	LastError  string          `json:"last_error,omitempty"`
+	EnqueuedAt string          `json:"enqueued_at"`
+
+	// raw 是取出時的原始編碼，用於在 Redis 中精準地移除此工作
+	raw string
+}
+
+// NewJob 以 payload 建立一個新的工作
+func NewJob(jobType string, payload interface{}) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &Job{
+		ID:         uuid.New().String(),
+		Type:       jobType,
+		Payload:    data,
+		EnqueuedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}, nil
+}
+
+// Stats 是佇列各狀態的工作數量
+type Stats struct {
+	Ready    int64 `json:"ready"`
+	InFlight int64 `json:"in_flight"`
+	Delayed  int64 `json:"delayed"`
+	Dead     int64 `json:"dead"`
+}
+
+// Queue 定義了持久化工作佇列的操作
+type Queue interface {
+	// Enqueue 將工作放入佇列尾端
+	Enqueue(ctx context.Context, job *Job) error
+	// Dequeue 取出下一個可執行的工作，並在 visibility 時間內對其他取用者隱藏；佇列為空時回傳 nil
+	Dequeue(ctx context.Context, visibility time.Duration) (*Job, error)
+	// Ack 表示工作已完成，將其永久移除
+	Ack(ctx context.Context, job *Job) error
+	// Retry 記錄失敗並在 delay 之後重新放回佇列
+	Retry(ctx context.Context, job *Job, delay time.Duration, cause error) error
+	// DeadLetter 將多次失敗的工作移到 dead-letter 列表，保留以供人工檢查
+	DeadLetter(ctx context.Context, job *Job, cause error) error
+	// Stats 回傳各狀態的工作數量
+	Stats(ctx context.Context) (Stats, error)
+}
+
+// encode 將工作編碼為 JSON 字串
+func encode(job *Job) (string, error) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// decode 解析工作並記住原始編碼
+func decode(raw string) (*Job, error) {
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, err
+	}
+	job.raw = raw
+	return &job, nil
+}
@@ -0,0 +1,161 @@
+// internal/queue/redis_queue.go
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// dequeueScript 以單一原子操作完成：
+// 1. 將到期的延遲工作與可見性逾時的執行中工作移回 ready
+// 2. 從 ready 取出最舊的工作並放入 inflight，分數為可見性期限
+var dequeueScript = redis.NewScript(`
+local ready, inflight, delayed = KEYS[1], KEYS[2], KEYS[3]
+local now, deadline = tonumber(ARGV[1]), tonumber(ARGV[2])
+
+local due = redis.call('ZRANGEBYSCORE', delayed, '-inf', now, 'LIMIT', 0, 100)
+for _, member in ipairs(due) do
+	redis.call('ZREM', delayed, member)
+	redis.call('LPUSH', ready, member)
+end
+
+local expired = redis.call('ZRANGEBYSCORE', inflight, '-inf', now, 'LIMIT', 0, 100)
+for _, member in ipairs(expired) do
+	redis.call('ZREM', inflight, member)
+	redis.call('RPUSH', ready, member)
+end
+
+local job = redis.call('RPOP', ready)
+if job then
+	redis.call('ZADD', inflight, deadline, job)
+end
+return job
+`)
+
+// redisQueue 是以 Redis 實作的持久化佇列。
+// ready 為 list (LPUSH 進、RPOP 出)，inflight 與 delayed 為以時間為分數的 sorted set，dead 為 list。
+type redisQueue struct {
+	client   *redis.Client
+	ready    string
+	inflight string
+	delayed  string
+	dead     string
+}
+
+// NewRedisQueue 是 redisQueue 的建構子，name 用於區分不同用途的佇列
+func NewRedisQueue(client *redis.Client, name string) Queue {
+	prefix := "queue:" + name + ":"
+	return &redisQueue{
+		client:   client,
+		ready:    prefix + "ready",
+		inflight: prefix + "inflight",
+		delayed:  prefix + "delayed",
+		dead:     prefix + "dead",
+	}
+}
+
+func (q *redisQueue) Enqueue(ctx context.Context, job *Job) error {
+	raw, err := encode(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+	if err := q.client.LPush(ctx, q.ready, raw).Err(); err != nil {
+		return fmt.Errorf("failed to enqueue job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (q *redisQueue) Dequeue(ctx context.Context, visibility time.Duration) (*Job, error) {
+	now := time.Now()
+	keys := []string{q.ready, q.inflight, q.delayed}
+	raw, err := dequeueScript.Run(ctx, q.client, keys, millis(now), millis(now.Add(visibility))).Text()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dequeue job: %w", err)
+	}
+
+	job, err := decode(raw)
+	if err != nil {
+		// 無法解析的工作直接移到 dead-letter，避免一直被重新取出
+		pipe := q.client.TxPipeline()
+		pipe.ZRem(ctx, q.inflight, raw)
+		pipe.LPush(ctx, q.dead, raw)
+		if _, pipeErr := pipe.Exec(ctx); pipeErr != nil {
+			return nil, fmt.Errorf("failed to dead-letter malformed job: %w", pipeErr)
+		}
+		return nil, fmt.Errorf("malformed job moved to dead-letter: %w", err)
+	}
+	return job, nil
+}
+
+func (q *redisQueue) Ack(ctx context.Context, job *Job) error {
+	if err := q.client.ZRem(ctx, q.inflight, job.raw).Err(); err != nil {
+		return fmt.Errorf("failed to ack job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (q *redisQueue) Retry(ctx context.Context, job *Job, delay time.Duration, cause error) error {
+	previous := job.raw
+	job.Attempts++
+	job.LastError = cause.Error()
+	raw, err := encode(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.ZRem(ctx, q.inflight, previous)
+	pipe.ZAdd(ctx, q.delayed, redis.Z{Score: float64(time.Now().Add(delay).UnixMilli()), Member: raw})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to schedule retry for job %s: %w", job.ID, err)
+	}
+	job.raw = raw
+	return nil
+}
+
+func (q *redisQueue) DeadLetter(ctx context.Context, job *Job, cause error) error {
+	previous := job.raw
+	job.Attempts++
+	job.LastError = cause.Error()
+	raw, err := encode(job)
+	if err != nil {
+		return fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	pipe := q.client.TxPipeline()
+	pipe.ZRem(ctx, q.inflight, previous)
+	pipe.LPush(ctx, q.dead, raw)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to dead-letter job %s: %w", job.ID, err)
+	}
+	job.raw = raw
+	return nil
+}
+
+func (q *redisQueue) Stats(ctx context.Context) (Stats, error) {
+	pipe := q.client.Pipeline()
+	ready := pipe.LLen(ctx, q.ready)
+	inflight := pipe.ZCard(ctx, q.inflight)
+	delayed := pipe.ZCard(ctx, q.delayed)
+	dead := pipe.LLen(ctx, q.dead)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Stats{}, fmt.Errorf("failed to read queue stats: %w", err)
+	}
+	return Stats{
+		Ready:    ready.Val(),
+		InFlight: inflight.Val(),
+		Delayed:  delayed.Val(),
+		Dead:     dead.Val(),
+	}, nil
+}
+
+func millis(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
@@ -0,0 +1,180 @@
+// internal/queue/worker.go
+package queue
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Handler 處理單一工作；回傳錯誤時工作會以指數退避重試，超過上限後移到 dead-letter
+type Handler func(ctx context.Context, job *Job) error
+
+// WorkerOptions 是 WorkerPool 的設定，零值欄位會使用預設值
+type WorkerOptions struct {
+	Concurrency  int           // 同時執行的 worker 數量
+	MaxAttempts  int           // 包含第一次在內的最大嘗試次數
+	BaseBackoff  time.Duration // 第一次重試的等待時間，之後每次加倍
+	MaxBackoff   time.Duration // 重試等待時間的上限
+	Visibility   time.Duration // 取出的工作在此時間內未 Ack 會被重新傳遞
+	PollInterval time.Duration // 佇列為空時的輪詢間隔
+}
+
+func (o *WorkerOptions) applyDefaults() {
+	if o.Concurrency <= 0 {
+		o.Concurrency = 4
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 2 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+	if o.Visibility <= 0 {
+		o.Visibility = 2 * time.Minute
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 500 * time.Millisecond
+	}
+}
+
+// WorkerPool 以固定數量的 worker 從佇列取出工作並分派給對應的 Handler。
+// 處理結果會記錄在 expvar 的 queue.{name} 底下，可從內部監控 listener (admin.addr) 的 /debug/vars 查看。
+type WorkerPool struct {
+	name     string
+	queue    Queue
+	opts     WorkerOptions
+	handlers map[string]Handler
+	metrics  *expvar.Map
+}
+
+// NewWorkerPool 是 WorkerPool 的建構子，同一個 name 只能建立一次 (expvar 名稱必須唯一)
+func NewWorkerPool(name string, q Queue, opts WorkerOptions) *WorkerPool {
+	opts.applyDefaults()
+	p := &WorkerPool{
+		name:     name,
+		queue:    q,
+		opts:     opts,
+		handlers: make(map[string]Handler),
+		metrics:  expvar.NewMap("queue." + name),
+	}
+	p.metrics.Set("depth", expvar.Func(func() interface{} {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		stats, err := q.Stats(ctx)
+		if err != nil {
+			return err.Error()
+		}
+		return stats
+	}))
+	return p
+}
+
+// Handle 註冊工作類型的 Handler，必須在 Run 之前呼叫
+func (p *WorkerPool) Handle(jobType string, handler Handler) {
+	p.handlers[jobType] = handler
+}
+
+// Run 啟動 worker 並阻塞直到 ctx 結束且所有 worker 完成目前的工作
+func (p *WorkerPool) Run(ctx context.Context) {
+	log.Printf("Starting worker pool %s with %d workers", p.name, p.opts.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < p.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *WorkerPool) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := p.queue.Dequeue(ctx, p.opts.Visibility)
+		if err != nil {
+			log.Printf("Worker pool %s: dequeue failed: %v", p.name, err)
+			p.metrics.Add("dequeue_errors", 1)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(p.opts.PollInterval):
+			}
+			continue
+		}
+		p.process(ctx, job)
+	}
+}
+
+func (p *WorkerPool) process(ctx context.Context, job *Job) {
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		p.fail(ctx, job, fmt.Errorf("no handler registered for job type %q", job.Type), false)
+		return
+	}
+
+	start := time.Now()
+	err := p.safeHandle(ctx, handler, job)
+	p.metrics.AddFloat("processing_seconds", time.Since(start).Seconds())
+	if err != nil {
+		p.fail(ctx, job, err, true)
+		return
+	}
+
+	if err := p.queue.Ack(ctx, job); err != nil {
+		// Ack 失敗時工作會在可見性逾時後再次執行，Handler 必須能承受重複執行
+		log.Printf("Worker pool %s: failed to ack job %s: %v", p.name, job.ID, err)
+	}
+	p.metrics.Add("processed", 1)
+}
+
+// safeHandle 執行 Handler 並將 panic 轉為錯誤，避免單一工作讓 worker 停止
+func (p *WorkerPool) safeHandle(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while handling job: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// fail 依嘗試次數決定重試或移到 dead-letter
+func (p *WorkerPool) fail(ctx context.Context, job *Job, cause error, retryable bool) {
+	p.metrics.Add("failed", 1)
+
+	if !retryable || job.Attempts+1 >= p.opts.MaxAttempts {
+		log.Printf("Worker pool %s: job %s (%s) moved to dead-letter after %d attempts: %v", p.name, job.ID, job.Type, job.Attempts+1, cause)
+		if err := p.queue.DeadLetter(ctx, job, cause); err != nil {
+			log.Printf("Worker pool %s: failed to dead-letter job %s: %v", p.name, job.ID, err)
+			return
+		}
+		p.metrics.Add("dead_lettered", 1)
+		return
+	}
+
+	delay := p.backoff(job.Attempts)
+	log.Printf("Worker pool %s: job %s (%s) failed, retrying in %v: %v", p.name, job.ID, job.Type, delay, cause)
+	if err := p.queue.Retry(ctx, job, delay, cause); err != nil {
+		log.Printf("Worker pool %s: failed to schedule retry for job %s: %v", p.name, job.ID, err)
+		return
+	}
+	p.metrics.Add("retried", 1)
+}
+
+// backoff 計算第 attempts 次重試前的等待時間 (指數成長並加上最多 20% 的隨機抖動)
+func (p *WorkerPool) backoff(attempts int) time.Duration {
+	delay := p.opts.BaseBackoff << uint(attempts)
+	if delay <= 0 || delay > p.opts.MaxBackoff {
+		delay = p.opts.MaxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
@@ -0,0 +1,208 @@
+// internal/realtime/hub.go
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 推播事件類型
+const (
+	EventNotification = "notification" // 新的站內通知
+	EventFeedItem     = "feed_item"    // fan-out 產生的新動態
+	EventPostCounts   = "post_counts"  // 貼文的按讚 / 評論數變化
+)
+
+const (
+	// redisChannel 是所有 replica 共用的 pub/sub 頻道
+	redisChannel = "realtime:events"
+	// clientBufferSize 是每個連線的事件緩衝區大小，緩衝區滿時事件會被丟棄，避免慢速連線拖住整個 hub
+	clientBufferSize = 64
+)
+
+const (
+	targetUser = "user"
+	targetPost = "post"
+)
+
+// Event 是推送給客戶端的單一事件
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// envelope 是在 replica 之間傳遞的訊息，標明事件要送給哪個使用者或哪篇貼文的訂閱者
+type envelope struct {
+	Target string `json:"target"`
+	ID     string `json:"id"`
+	Event  Event  `json:"event"`
+}
+
+// Client 代表一條已驗證的推播連線
+type Client struct {
+	UserID  string
+	postIDs []string
+	send    chan Event
+}
+
+// Events 回傳此連線的事件 channel，連線取消註冊後會被關閉
+func (c *Client) Events() <-chan Event {
+	return c.send
+}
+
+// Hub 管理本機的推播連線。
+// 設定了 Redis 時，所有事件都先發佈到 Redis，再由每個 replica 的訂閱迴圈轉送給本機連線，
+// 因此不論使用者連到哪一台 backend 都能收到事件；未設定 Redis 時只在本機轉送。
+type Hub struct {
+	mu     sync.RWMutex
+	users  map[string]map[*Client]struct{}
+	posts  map[string]map[*Client]struct{}
+	client *redis.Client
+}
+
+// NewHub 是 Hub 的建構子，redisClient 可以為 nil (單機模式)
+func NewHub(redisClient *redis.Client) *Hub {
+	return &Hub{
+		users:  make(map[string]map[*Client]struct{}),
+		posts:  make(map[string]map[*Client]struct{}),
+		client: redisClient,
+	}
+}
+
+// Run 訂閱 Redis 頻道並將事件轉送給本機連線，直到 ctx 結束；單機模式下直接返回
+func (h *Hub) Run(ctx context.Context) {
+	if h == nil || h.client == nil {
+		return
+	}
+
+	sub := h.client.Subscribe(ctx, redisChannel)
+	defer sub.Close()
+	log.Printf("Realtime hub subscribed to Redis channel %s", redisChannel)
+
+	// go-redis 會在連線中斷時自動重新訂閱
+	for msg := range sub.Channel() {
+		var env envelope
+		if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+			log.Printf("Realtime hub: dropping malformed message: %v", err)
+			continue
+		}
+		h.deliver(env)
+	}
+}
+
+// Register 註冊一條連線，同時訂閱使用者本人的事件與指定貼文的計數變化
+func (h *Hub) Register(userID string, postIDs []string) *Client {
+	c := &Client{
+		UserID:  userID,
+		postIDs: postIDs,
+		send:    make(chan Event, clientBufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	addClient(h.users, userID, c)
+	for _, postID := range postIDs {
+		addClient(h.posts, postID, c)
+	}
+	return c
+}
+
+// Unregister 移除連線並關閉其事件 channel
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.users[c.UserID][c]; !ok {
+		return
+	}
+	removeClient(h.users, c.UserID, c)
+	for _, postID := range c.postIDs {
+		removeClient(h.posts, postID, c)
+	}
+	close(c.send)
+}
+
+// PublishToUser 推送事件給指定使用者的所有連線
+func (h *Hub) PublishToUser(ctx context.Context, userID, eventType string, data interface{}) {
+	h.publish(ctx, targetUser, userID, eventType, data)
+}
+
+// PublishToPost 推送事件給正在瀏覽指定貼文的所有連線
+func (h *Hub) PublishToPost(ctx context.Context, postID, eventType string, data interface{}) {
+	h.publish(ctx, targetPost, postID, eventType, data)
+}
+
+func (h *Hub) publish(ctx context.Context, target, id, eventType string, data interface{}) {
+	// 允許未啟用推播時傳入 nil hub
+	if h == nil || id == "" {
+		return
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Realtime hub: failed to marshal %s event: %v", eventType, err)
+		return
+	}
+	env := envelope{Target: target, ID: id, Event: Event{Type: eventType, Data: raw}}
+
+	if h.client == nil {
+		h.deliver(env)
+		return
+	}
+
+	payload, err := json.Marshal(env)
+	if err != nil {
+		log.Printf("Realtime hub: failed to marshal envelope: %v", err)
+		return
+	}
+	if err := h.client.Publish(ctx, redisChannel, payload).Err(); err != nil {
+		// Redis 暫時無法使用時至少送達本機的連線
+		log.Printf("Realtime hub: failed to publish to Redis, delivering locally only: %v", err)
+		h.deliver(env)
+	}
+}
+
+// deliver 將事件送給本機符合條件的連線
+func (h *Hub) deliver(env envelope) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var clients map[*Client]struct{}
+	switch env.Target {
+	case targetUser:
+		clients = h.users[env.ID]
+	case targetPost:
+		clients = h.posts[env.ID]
+	}
+
+	for c := range clients {
+		select {
+		case c.send <- env.Event:
+		default:
+			log.Printf("Realtime hub: buffer full for user %s, dropping %s event", c.UserID, env.Event.Type)
+		}
+	}
+}
+
+func addClient(index map[string]map[*Client]struct{}, key string, c *Client) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[*Client]struct{})
+		index[key] = set
+	}
+	set[c] = struct{}{}
+}
+
+func removeClient(index map[string]map[*Client]struct{}, key string, c *Client) {
+	set, ok := index[key]
+	if !ok {
+		return
+	}
+	delete(set, c)
+	if len(set) == 0 {
+		delete(index, key)
+	}
+}
@@ -15,7 +15,7 @@ const (
 	likeWeight           = 1.0
 	commentWeight        = 0
 	lookbackDays         = 7
-	trendingAlgorithmKey = "trending-v1.0" // 定義一個常數作為演算法金鑰
+	TrendingAlgorithmKey = "trending-v1.0" // 定義一個常數作為演算法金鑰
 	maxTrendingPosts     = 100             // 儲存前 100 篇熱門貼文
 )
 
@@ -58,6 +58,10 @@ func (r *TrendingRecommender) GenerateRecommendations(ctx context.Context) error
 
 	var trendingList []TrendingPost
 	for _, post := range allRecentPosts {
+		// 僅限粉絲的貼文不進入全域熱門列表
+		if !post.IsPublic() {
+			continue
+		}
 		score := float64(post.LikeCount)*likeWeight + float64(post.CommentCount)*commentWeight
 		trendingList = append(trendingList, TrendingPost{PostID: post.PostID, Score: score})
 	}
@@ -79,6 +83,8 @@ func (r *TrendingRecommender) GenerateRecommendations(ctx context.Context) error
 		numToSave = maxTrendingPosts
 	}
 
+	// 同一次產生的項目使用相同的時間，Feed 的 cursor 以此判斷熱門列表是否已重新產生
+	generatedAt := time.Now().UTC().Format(time.RFC3339)
 	for i := 0; i < numToSave; i++ {
 		trendingPost := trendingList[i]
 
@@ -86,11 +92,11 @@ func (r *TrendingRecommender) GenerateRecommendations(ctx context.Context) error
 		uniqueSortKey := fmt.Sprintf("%010.2f#%s", trendingPost.Score, trendingPost.PostID)
 
 		recItem := models.UserRecommendationItem{
-			PK:               fmt.Sprintf("TRENDING#%s", trendingAlgorithmKey), // 使用一個常數 PK 代表全域列表
+			PK:               fmt.Sprintf("TRENDING#%s", TrendingAlgorithmKey), // 使用一個常數 PK 代表全域列表
 			SK:               uniqueSortKey,                                   // SK 用於按分數排序
 			PostID:           trendingPost.PostID,
-			AlgorithmVersion: trendingAlgorithmKey,
-			GeneratedAt:      time.Now().UTC().Format(time.RFC3339),
+			AlgorithmVersion: TrendingAlgorithmKey,
+			GeneratedAt:      generatedAt,
 			// GSI 相關鍵在此查詢模式下不再需要。
 		}
 		globalTrendingItems = append(globalTrendingItems, recItem)
@@ -0,0 +1,141 @@
+// internal/recommendation/trending_tags.go
+package recommendation
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/textparse"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// TrendingTagsAlgorithmKey 是熱門標籤列表在 UserRecommendations 表中的演算法金鑰
+	TrendingTagsAlgorithmKey = "trending-tags-v1.0"
+	maxTrendingTags          = 50
+	// tagRateSmoothing 是每小時使用率的平滑常數，避免只出現一兩次的標籤因比例懸殊而衝上排行
+	tagRateSmoothing = 1.0
+	// 短期 (1h 對 24h) 與中期 (24h 對 7d) 加速度的權重
+	shortTermAccelWeight = 0.6
+	midTermAccelWeight   = 0.4
+)
+
+// TrendingTagsRecommender 計算滑動時間窗內的標籤使用量，找出正在加速的標籤
+type TrendingTagsRecommender struct {
+	postRepo           repository.PostRepository
+	recommendationRepo repository.RecommendationRepository
+}
+
+// NewTrendingTagsRecommender 是 TrendingTagsRecommender 的建構子
+func NewTrendingTagsRecommender(postRepo repository.PostRepository, recoRepo repository.RecommendationRepository) *TrendingTagsRecommender {
+	return &TrendingTagsRecommender{
+		postRepo:           postRepo,
+		recommendationRepo: recoRepo,
+	}
+}
+
+// tagWindowCounts 是單一標籤在三個時間窗內的使用次數
+type tagWindowCounts struct {
+	hour int
+	day  int
+	week int
+}
+
+// GenerateTrendingTags 計算熱門標籤並取代 UserRecommendations 中的舊列表
+func (r *TrendingTagsRecommender) GenerateTrendingTags(ctx context.Context) error {
+	log.Println("Fetching recent posts for trending tags calculation...")
+	posts, err := r.postRepo.GetRecentPosts(ctx, lookbackDays)
+	if err != nil {
+		log.Printf("Error getting recent posts for trending tags: %v", err)
+		return fmt.Errorf("could not get recent posts: %w", err)
+	}
+
+	now := time.Now().UTC()
+	counts := make(map[string]*tagWindowCounts)
+	for _, post := range posts {
+		if !post.IsPublic() {
+			continue
+		}
+		createdAt, err := time.Parse(time.RFC3339Nano, post.CreatedAt)
+		if err != nil {
+			continue
+		}
+		age := now.Sub(createdAt)
+		// 同一篇貼文中重複的標籤只計算一次
+		seen := make(map[string]bool)
+		for _, tag := range post.Tags {
+			key := textparse.NormalizeTag(tag)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			c, ok := counts[key]
+			if !ok {
+				c = &tagWindowCounts{}
+				counts[key] = c
+			}
+			c.week++
+			if age <= 24*time.Hour {
+				c.day++
+			}
+			if age <= time.Hour {
+				c.hour++
+			}
+		}
+	}
+
+	generatedAt := now.Format(time.RFC3339)
+	var items []models.TrendingTagItem
+	for tag, c := range counts {
+		// 最近 24 小時沒有使用的標籤不算熱門
+		if c.day == 0 {
+			continue
+		}
+		acceleration, score := scoreTag(c)
+		items = append(items, models.TrendingTagItem{
+			SK:               fmt.Sprintf("%012.4f#%s", score, tag),
+			Tag:              tag,
+			Count1h:          c.hour,
+			Count24h:         c.day,
+			Count7d:          c.week,
+			Acceleration:     acceleration,
+			Score:            score,
+			AlgorithmVersion: TrendingTagsAlgorithmKey,
+			GeneratedAt:      generatedAt,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Score > items[j].Score
+	})
+	if len(items) > maxTrendingTags {
+		items = items[:maxTrendingTags]
+	}
+
+	log.Printf("Saving %d trending tags to DynamoDB...", len(items))
+	if err := r.recommendationRepo.ReplaceTrendingTags(ctx, TrendingTagsAlgorithmKey, items); err != nil {
+		return fmt.Errorf("failed to save trending tags: %w", err)
+	}
+	return nil
+}
+
+// scoreTag 比較各時間窗的每小時使用率來計算加速度。
+// 使用率穩定的大型標籤加速度約為 1，只靠總量無法衝上排行；
+// 近期使用率明顯高於長期平均的標籤則會被放大。
+func scoreTag(c *tagWindowCounts) (acceleration, score float64) {
+	rateHour := float64(c.hour)
+	rateDay := float64(c.day) / 24
+	rateWeek := float64(c.week) / (24 * lookbackDays)
+
+	shortTerm := (rateHour + tagRateSmoothing) / (rateDay + tagRateSmoothing)
+	midTerm := (rateDay + tagRateSmoothing) / (rateWeek + tagRateSmoothing)
+	acceleration = shortTermAccelWeight*shortTerm + midTermAccelWeight*midTerm
+
+	// 以對數壓縮使用量，讓量體只作為次要因素
+	score = acceleration * math.Log1p(float64(c.day))
+	return acceleration, score
+}
@@ -0,0 +1,94 @@
+// internal/repository/affinity_repository_dynamodb.go
+package repository
+
+import (
+	"backend/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// AffinityRepository 維護使用者對作者的互動計數，供排序 Feed 計算作者親密度
+type AffinityRepository interface {
+	// IncrementAffinity 以原子操作累加按讚與評論次數 (可為負數)
+	IncrementAffinity(ctx context.Context, userID, authorID string, likes, comments int) error
+	// GetAffinities 取得使用者對所有作者的互動計數，以作者 ID 為鍵
+	GetAffinities(ctx context.Context, userID string) (map[string]models.Affinity, error)
+}
+
+// dynamoDBAffinityRepository 將互動計數存放在 Posts 表中使用者的分割區
+type dynamoDBAffinityRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBAffinityRepository 是 dynamoDBAffinityRepository 的建構子
+func NewDynamoDBAffinityRepository(client *dynamodb.Client) AffinityRepository {
+	return &dynamoDBAffinityRepository{
+		client:    client,
+		tableName: FeedTableName,
+	}
+}
+
+// IncrementAffinity 使用 UpdateItem ADD，項目不存在時會自動建立
+func (r *dynamoDBAffinityRepository) IncrementAffinity(ctx context.Context, userID, authorID string, likes, comments int) error {
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "AFFINITY#" + authorID},
+		},
+		UpdateExpression: aws.String("ADD like_count :likes, comment_count :comments SET entity_type = :type, author_id = :author, updated_at = :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":likes":    &types.AttributeValueMemberN{Value: fmt.Sprint(likes)},
+			":comments": &types.AttributeValueMemberN{Value: fmt.Sprint(comments)},
+			":type":     &types.AttributeValueMemberS{Value: "AFFINITY"},
+			":author":   &types.AttributeValueMemberS{Value: authorID},
+			":now":      &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to update affinity %s -> %s: %v", userID, authorID, err)
+		return fmt.Errorf("failed to update affinity: %w", err)
+	}
+	return nil
+}
+
+// GetAffinities 分頁查詢 AFFINITY# 前綴的項目
+func (r *dynamoDBAffinityRepository) GetAffinities(ctx context.Context, userID string) (map[string]models.Affinity, error) {
+	affinities := make(map[string]models.Affinity)
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":prefix": &types.AttributeValueMemberS{Value: "AFFINITY#"},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			log.Printf("Failed to query affinities of user %s: %v", userID, err)
+			return nil, fmt.Errorf("failed to query affinities: %w", err)
+		}
+
+		var page []models.Affinity
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		for _, a := range page {
+			affinities[a.AuthorID] = a
+		}
+		if result.LastEvaluatedKey == nil {
+			return affinities, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
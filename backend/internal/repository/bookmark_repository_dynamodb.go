@@ -0,0 +1,415 @@
+// internal/repository/bookmark_repository_dynamodb.go
+package repository
+
+import (
+	"backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrBookmarkNotFound 表示使用者沒有收藏這篇貼文
+	ErrBookmarkNotFound = errors.New("bookmark not found")
+	// ErrAlreadyBookmarked 表示使用者已經收藏過這篇貼文
+	ErrAlreadyBookmarked = errors.New("post already bookmarked")
+	// ErrBookmarkConflict 表示收藏在讀取後已被其他請求移動或取消
+	ErrBookmarkConflict = errors.New("bookmark was modified concurrently")
+)
+
+// BookmarkRepository 定義了收藏與收藏集的操作
+type BookmarkRepository interface {
+	// AddBookmark 以交易寫入收藏的所有項目並更新收藏集的數量，已收藏時回傳 ErrAlreadyBookmarked
+	AddBookmark(ctx context.Context, bookmark *models.Bookmark) error
+	// RemoveBookmark 刪除收藏的所有項目，不存在時回傳 ErrBookmarkNotFound
+	RemoveBookmark(ctx context.Context, bookmark *models.Bookmark) error
+	// MoveBookmark 以單一交易將 GetBookmark 取得的收藏移到另一個收藏集 (空字串代表移出收藏集)，保留原本的收藏時間；
+	// 收藏在期間被移動或取消時回傳 ErrBookmarkConflict
+	MoveBookmark(ctx context.Context, bookmark *models.Bookmark, collection string) error
+	// GetBookmark 以貼文 ID 取得收藏，不存在時回傳 ErrBookmarkNotFound
+	GetBookmark(ctx context.Context, userID, postID string) (*models.Bookmark, error)
+	// ListBookmarks 依收藏時間由新到舊分頁列出收藏；collection 不為空時只列出該收藏集
+	ListBookmarks(ctx context.Context, userID, collection string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedBookmarks, error)
+	// ListCollections 列出使用者仍有收藏的收藏集
+	ListCollections(ctx context.Context, userID string) ([]models.BookmarkCollection, error)
+	// CheckIfPostsBookmarkedBy 批次檢查使用者是否收藏過貼文 (USER#{user_id} / BOOKMARKED#{post_id})
+	CheckIfPostsBookmarkedBy(ctx context.Context, postIDs []string, userID string) (map[string]bool, error)
+}
+
+// dynamoDBBookmarkRepository 將收藏存放在 Posts 表中使用者的分割區
+type dynamoDBBookmarkRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBBookmarkRepository 是 dynamoDBBookmarkRepository 的建構子
+func NewDynamoDBBookmarkRepository(client *dynamodb.Client) BookmarkRepository {
+	return &dynamoDBBookmarkRepository{
+		client:    client,
+		tableName: FeedTableName,
+	}
+}
+
+// bookmarkKey 回傳收藏在使用者分割區中某個項目的主鍵
+func bookmarkKey(userID, sk string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+		"SK": &types.AttributeValueMemberS{Value: sk},
+	}
+}
+
+func bookmarkPointerSK(postID string) string {
+	return "BOOKMARKED#" + postID
+}
+
+func bookmarkTimelineSK(bookmark *models.Bookmark) string {
+	return "BOOKMARK#" + bookmark.CreatedAt + "#" + bookmark.PostID
+}
+
+func collectionItemPrefix(collection string) string {
+	return "COLLECTIONITEM#" + collection + "#"
+}
+
+func collectionSK(collection string) string {
+	return "COLLECTION#" + collection
+}
+
+// AddBookmark 寫入索引項目 (條件：尚未收藏)、時間軸項目，以及收藏集項目與數量
+func (r *dynamoDBBookmarkRepository) AddBookmark(ctx context.Context, bookmark *models.Bookmark) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	bookmark.CreatedAt = now
+	bookmark.PK = "USER#" + bookmark.UserID
+	bookmark.EntityType = "BOOKMARK"
+
+	// 第一個項目是索引項目，以條件寫入防止重複收藏
+	sks := []string{bookmarkPointerSK(bookmark.PostID), bookmarkTimelineSK(bookmark)}
+	if bookmark.Collection != "" {
+		sks = append(sks, collectionItemPrefix(bookmark.Collection)+now+"#"+bookmark.PostID)
+	}
+
+	var transactItems []types.TransactWriteItem
+	for i, sk := range sks {
+		item := *bookmark
+		item.SK = sk
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal bookmark: %w", err)
+		}
+		put := &types.Put{
+			TableName: aws.String(r.tableName),
+			Item:      av,
+		}
+		if i == 0 {
+			put.ConditionExpression = aws.String("attribute_not_exists(PK)")
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{Put: put})
+	}
+	if bookmark.Collection != "" {
+		transactItems = append(transactItems, r.collectionCountIncrement(bookmark.UserID, bookmark.Collection, now))
+	}
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && conditionFailedAt(canceled, 0) {
+			return ErrAlreadyBookmarked
+		}
+		log.Printf("Failed to add bookmark of post %s for user %s: %v", bookmark.PostID, bookmark.UserID, err)
+		return fmt.Errorf("failed to add bookmark: %w", err)
+	}
+	bookmark.SK = bookmarkPointerSK(bookmark.PostID)
+	return nil
+}
+
+// RemoveBookmark 依 GetBookmark 取得的內容刪除所有項目；索引項目以條件刪除，避免重複遞減收藏集數量
+func (r *dynamoDBBookmarkRepository) RemoveBookmark(ctx context.Context, bookmark *models.Bookmark) error {
+	transactItems := []types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				TableName:           aws.String(r.tableName),
+				Key:                 bookmarkKey(bookmark.UserID, bookmarkPointerSK(bookmark.PostID)),
+				ConditionExpression: aws.String("attribute_exists(PK)"),
+			},
+		},
+		{
+			Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key:       bookmarkKey(bookmark.UserID, bookmarkTimelineSK(bookmark)),
+			},
+		},
+	}
+	if bookmark.Collection != "" {
+		transactItems = append(transactItems,
+			types.TransactWriteItem{
+				Delete: &types.Delete{
+					TableName: aws.String(r.tableName),
+					Key:       bookmarkKey(bookmark.UserID, collectionItemPrefix(bookmark.Collection)+bookmark.CreatedAt+"#"+bookmark.PostID),
+				},
+			},
+			r.collectionCountDecrement(bookmark.UserID, bookmark.Collection, time.Now().UTC().Format(time.RFC3339Nano)),
+		)
+	}
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && conditionFailedAt(canceled, 0) {
+			return ErrBookmarkNotFound
+		}
+		log.Printf("Failed to remove bookmark of post %s for user %s: %v", bookmark.PostID, bookmark.UserID, err)
+		return fmt.Errorf("failed to remove bookmark: %w", err)
+	}
+	return nil
+}
+
+// MoveBookmark 更新索引與時間軸項目上的收藏集、搬移收藏集項目並調整兩邊的數量。
+// 索引項目以讀取當下的收藏集作為條件，避免與同時進行的移動或取消收藏重複計數
+func (r *dynamoDBBookmarkRepository) MoveBookmark(ctx context.Context, bookmark *models.Bookmark, collection string) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	pointer := &types.Update{
+		TableName: aws.String(r.tableName),
+		Key:       bookmarkKey(bookmark.UserID, bookmarkPointerSK(bookmark.PostID)),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":created": &types.AttributeValueMemberS{Value: bookmark.CreatedAt},
+		},
+	}
+	if bookmark.Collection == "" {
+		pointer.ConditionExpression = aws.String("created_at = :created AND attribute_not_exists(collection)")
+	} else {
+		pointer.ConditionExpression = aws.String("created_at = :created AND collection = :previous")
+		pointer.ExpressionAttributeValues[":previous"] = &types.AttributeValueMemberS{Value: bookmark.Collection}
+	}
+	timeline := &types.Update{
+		TableName: aws.String(r.tableName),
+		Key:       bookmarkKey(bookmark.UserID, bookmarkTimelineSK(bookmark)),
+	}
+	if collection == "" {
+		pointer.UpdateExpression = aws.String("REMOVE collection")
+		timeline.UpdateExpression = aws.String("REMOVE collection")
+	} else {
+		pointer.UpdateExpression = aws.String("SET collection = :collection")
+		pointer.ExpressionAttributeValues[":collection"] = &types.AttributeValueMemberS{Value: collection}
+		timeline.UpdateExpression = aws.String("SET collection = :collection")
+		timeline.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":collection": &types.AttributeValueMemberS{Value: collection},
+		}
+	}
+
+	// 第一個項目是索引項目，條件失敗代表收藏已被修改
+	transactItems := []types.TransactWriteItem{{Update: pointer}, {Update: timeline}}
+	if bookmark.Collection != "" {
+		transactItems = append(transactItems,
+			types.TransactWriteItem{
+				Delete: &types.Delete{
+					TableName: aws.String(r.tableName),
+					Key:       bookmarkKey(bookmark.UserID, collectionItemPrefix(bookmark.Collection)+bookmark.CreatedAt+"#"+bookmark.PostID),
+				},
+			},
+			r.collectionCountDecrement(bookmark.UserID, bookmark.Collection, now),
+		)
+	}
+	if collection != "" {
+		item := *bookmark
+		item.Collection = collection
+		item.SK = collectionItemPrefix(collection) + bookmark.CreatedAt + "#" + bookmark.PostID
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal bookmark: %w", err)
+		}
+		transactItems = append(transactItems,
+			types.TransactWriteItem{
+				Put: &types.Put{
+					TableName: aws.String(r.tableName),
+					Item:      av,
+				},
+			},
+			r.collectionCountIncrement(bookmark.UserID, collection, now),
+		)
+	}
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && conditionFailedAt(canceled, 0) {
+			return ErrBookmarkConflict
+		}
+		log.Printf("Failed to move bookmark of post %s for user %s: %v", bookmark.PostID, bookmark.UserID, err)
+		return fmt.Errorf("failed to move bookmark: %w", err)
+	}
+	bookmark.Collection = collection
+	return nil
+}
+
+// collectionCountIncrement 遞增收藏集的數量，收藏集不存在時一併建立
+func (r *dynamoDBBookmarkRepository) collectionCountIncrement(userID, collection, now string) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:        aws.String(r.tableName),
+			Key:              bookmarkKey(userID, collectionSK(collection)),
+			UpdateExpression: aws.String("ADD item_count :inc SET entity_type = :type, user_id = :uid, #name = :name, created_at = if_not_exists(created_at, :now), updated_at = :now"),
+			ExpressionAttributeNames: map[string]string{
+				"#name": "name",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":inc":  &types.AttributeValueMemberN{Value: "1"},
+				":type": &types.AttributeValueMemberS{Value: "BOOKMARK_COLLECTION"},
+				":uid":  &types.AttributeValueMemberS{Value: userID},
+				":name": &types.AttributeValueMemberS{Value: collection},
+				":now":  &types.AttributeValueMemberS{Value: now},
+			},
+		},
+	}
+}
+
+// collectionCountDecrement 遞減收藏集的數量，收藏集必須存在
+func (r *dynamoDBBookmarkRepository) collectionCountDecrement(userID, collection, now string) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:           aws.String(r.tableName),
+			Key:                 bookmarkKey(userID, collectionSK(collection)),
+			UpdateExpression:    aws.String("ADD item_count :dec SET updated_at = :now"),
+			ConditionExpression: aws.String("attribute_exists(PK)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":dec": &types.AttributeValueMemberN{Value: "-1"},
+				":now": &types.AttributeValueMemberS{Value: now},
+			},
+		},
+	}
+}
+
+// GetBookmark 讀取 BOOKMARKED#{post_id} 索引項目
+func (r *dynamoDBBookmarkRepository) GetBookmark(ctx context.Context, userID, postID string) (*models.Bookmark, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       bookmarkKey(userID, bookmarkPointerSK(postID)),
+	})
+	if err != nil {
+		log.Printf("Failed to get bookmark of post %s for user %s: %v", postID, userID, err)
+		return nil, fmt.Errorf("failed to get bookmark: %w", err)
+	}
+	if result.Item == nil {
+		return nil, ErrBookmarkNotFound
+	}
+
+	var bookmark models.Bookmark
+	if err := attributevalue.UnmarshalMap(result.Item, &bookmark); err != nil {
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// ListBookmarks 查詢 BOOKMARK# (全部) 或 COLLECTIONITEM#{collection}# (收藏集) 前綴的項目
+func (r *dynamoDBBookmarkRepository) ListBookmarks(ctx context.Context, userID, collection string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedBookmarks, error) {
+	prefix := "BOOKMARK#"
+	if collection != "" {
+		prefix = collectionItemPrefix(collection)
+	}
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: "USER#" + userID},
+			":prefix": &types.AttributeValueMemberS{Value: prefix},
+		},
+		ScanIndexForward:  aws.Bool(false), // 最新收藏在前
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: lastEvaluatedKey,
+	})
+	if err != nil {
+		log.Printf("Failed to query bookmarks of user %s: %v", userID, err)
+		return nil, fmt.Errorf("failed to query bookmarks: %w", err)
+	}
+
+	var bookmarks []models.Bookmark
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &bookmarks); err != nil {
+		return nil, err
+	}
+	return &models.PaginatedBookmarks{
+		Items:            bookmarks,
+		LastEvaluatedKey: result.LastEvaluatedKey,
+	}, nil
+}
+
+// ListCollections 查詢 COLLECTION# 前綴的項目，略過已經沒有收藏的收藏集
+func (r *dynamoDBBookmarkRepository) ListCollections(ctx context.Context, userID string) ([]models.BookmarkCollection, error) {
+	var collections []models.BookmarkCollection
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+			FilterExpression:       aws.String("item_count > :zero"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":prefix": &types.AttributeValueMemberS{Value: "COLLECTION#"},
+				":zero":   &types.AttributeValueMemberN{Value: "0"},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			log.Printf("Failed to query bookmark collections of user %s: %v", userID, err)
+			return nil, fmt.Errorf("failed to query bookmark collections: %w", err)
+		}
+
+		var page []models.BookmarkCollection
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		collections = append(collections, page...)
+		if result.LastEvaluatedKey == nil {
+			return collections, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// CheckIfPostsBookmarkedBy 以 BatchGetItem 檢查 BOOKMARKED#{post_id} 索引項目是否存在
+func (r *dynamoDBBookmarkRepository) CheckIfPostsBookmarkedBy(ctx context.Context, postIDs []string, userID string) (map[string]bool, error) {
+	bookmarked := make(map[string]bool, len(postIDs))
+	// BatchGetItem 每次最多查詢 100 個項目
+	const chunkSize = 100
+	for i := 0; i < len(postIDs); i += chunkSize {
+		end := i + chunkSize
+		if end > len(postIDs) {
+			end = len(postIDs)
+		}
+		keys := make([]map[string]types.AttributeValue, 0, end-i)
+		for _, postID := range postIDs[i:end] {
+			keys = append(keys, bookmarkKey(userID, bookmarkPointerSK(postID)))
+		}
+
+		result, err := r.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				r.tableName: {
+					Keys:                 keys,
+					ProjectionExpression: aws.String("SK"),
+				},
+			},
+		})
+		if err != nil {
+			log.Printf("BatchGetItem failed for checking bookmarks of user %s: %v", userID, err)
+			return nil, err
+		}
+		for _, item := range result.Responses[r.tableName] {
+			if sk, ok := item["SK"].(*types.AttributeValueMemberS); ok {
+				bookmarked[strings.TrimPrefix(sk.Value, "BOOKMARKED#")] = true
+			}
+		}
+	}
+	return bookmarked, nil
+}
@@ -0,0 +1,504 @@
+// internal/repository/draft_repository_dynamodb.go
+package repository
+
+import (
+	"backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrDraftNotFound 表示草稿不存在 (或已經發布)
+	ErrDraftNotFound = errors.New("draft not found")
+	// ErrDraftConflict 表示草稿在讀取後已被其他請求修改，或已被排程領取發布
+	ErrDraftConflict = errors.New("draft was modified concurrently or is being published")
+	// ErrDraftAlreadyClaimed 表示草稿尚未到期、已被其他程序領取或已不存在
+	ErrDraftAlreadyClaimed = errors.New("draft is not due or already claimed")
+	// ErrDraftLeaseLost 表示發布前領取期限已過並被其他程序重新領取
+	ErrDraftLeaseLost = errors.New("draft lease lost")
+)
+
+// DraftRepository 定義了草稿、排程貼文與排程索引的操作
+type DraftRepository interface {
+	// CreateDraft 寫入新草稿；排程中的草稿同時寫入排程索引
+	CreateDraft(ctx context.Context, draft *models.Draft) error
+	// GetDraft 取得作者的草稿，不存在時回傳 ErrDraftNotFound
+	GetDraft(ctx context.Context, authorID, draftID string) (*models.Draft, error)
+	// ListDrafts 依最後修改時間由新到舊列出作者的草稿與排程貼文
+	ListDrafts(ctx context.Context, authorID string) ([]models.Draft, error)
+	// UpdateDraft 以 previous.UpdatedAt 做樂觀鎖覆寫草稿並同步排程索引；
+	// 草稿已被修改或已被領取發布時回傳 ErrDraftConflict
+	UpdateDraft(ctx context.Context, previous, draft *models.Draft) error
+	// DeleteDraft 刪除草稿與其排程索引，條件與 UpdateDraft 相同
+	DeleteDraft(ctx context.Context, draft *models.Draft) error
+	// ListDueScheduledPosts 依 publish_at 由舊到新列出 now (含) 之前到期的排程索引，最多 limit 筆，
+	// 包含已發布但尚未完成 fan-out 的項目；略過 retry_at 尚未到的項目
+	ListDueScheduledPosts(ctx context.Context, now string, limit int32) ([]models.ScheduledPost, error)
+	// ClaimDraft 以條件更新將到期的排程貼文 (或領取期限已過的發布中草稿) 改為發布中並設定領取期限，
+	// 讓多個 replica 同時執行排程時同一篇只會由一個程序發布；無法領取時回傳 ErrDraftAlreadyClaimed
+	ClaimDraft(ctx context.Context, authorID, draftID, now, leaseUntil string) (*models.Draft, error)
+	// PublishDraft 在同一個交易中建立貼文、刪除草稿並在排程索引記錄新貼文；領取期限已被他人取代時回傳 ErrDraftLeaseLost
+	PublishDraft(ctx context.Context, draft *models.Draft, post *models.Post) error
+	// CompleteScheduledPost 在 fan-out 放入佇列後 (或草稿已不存在時) 刪除排程索引；項目已被其他程序處理時不做任何事
+	CompleteScheduledPost(ctx context.Context, entry *models.ScheduledPost) error
+	// DeferScheduledPost 記錄排程索引的失敗次數，並延後到 retryAt 才再處理；項目已被其他程序處理時不做任何事
+	DeferScheduledPost(ctx context.Context, entry *models.ScheduledPost, attempts int, retryAt string) error
+	// FailScheduledPost 在同一個交易中將多次發布失敗的草稿改為 failed 並刪除排程索引；
+	// 草稿已被修改或正由其他程序發布時回傳 ErrDraftConflict。leaseUntil 是呼叫端自己持有的領取期限，沒有時為空字串
+	FailScheduledPost(ctx context.Context, entry *models.ScheduledPost, now, leaseUntil string) error
+}
+
+// dynamoDBDraftRepository 將草稿存放在 Posts 表中作者的分割區，排程索引存放在 SCHEDULED_POSTS 分割區
+type dynamoDBDraftRepository struct {
+	client    *dynamodb.Client
+	tableName string
+	posts     *DynamoDBPostRepository // 發布時沿用建立貼文的交易項目
+}
+
+// NewDynamoDBDraftRepository 是 dynamoDBDraftRepository 的建構子
+func NewDynamoDBDraftRepository(client *dynamodb.Client) DraftRepository {
+	return &dynamoDBDraftRepository{
+		client:    client,
+		tableName: FeedTableName,
+		posts: &DynamoDBPostRepository{
+			client:    client,
+			tableName: FeedTableName,
+		},
+	}
+}
+
+// draftKey 回傳草稿的主鍵
+func draftKey(authorID, draftID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + authorID},
+		"SK": &types.AttributeValueMemberS{Value: "DRAFT#" + draftID},
+	}
+}
+
+// scheduledPostKey 回傳排程索引項目的主鍵
+func scheduledPostKey(publishAt, draftID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: models.ScheduledPostsPK},
+		"SK": &types.AttributeValueMemberS{Value: publishAt + "#" + draftID},
+	}
+}
+
+// scheduledPostPut 建立寫入排程索引的交易項目
+func (r *dynamoDBDraftRepository) scheduledPostPut(entry models.ScheduledPost) (types.TransactWriteItem, error) {
+	entry.PK = models.ScheduledPostsPK
+	entry.SK = entry.PublishAt + "#" + entry.DraftID
+	entry.EntityType = "SCHEDULED_POST"
+	item, err := attributevalue.MarshalMap(entry)
+	if err != nil {
+		return types.TransactWriteItem{}, fmt.Errorf("failed to marshal scheduled post: %w", err)
+	}
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName: aws.String(r.tableName),
+			Item:      item,
+		},
+	}, nil
+}
+
+// scheduledPostDelete 建立刪除排程索引的交易項目；草稿不是排程中時回傳 false
+func (r *dynamoDBDraftRepository) scheduledPostDelete(draft *models.Draft) (types.TransactWriteItem, bool) {
+	if draft.Status != models.DraftStatusScheduled || draft.PublishAt == "" {
+		return types.TransactWriteItem{}, false
+	}
+	return types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName: aws.String(r.tableName),
+			Key:       scheduledPostKey(draft.PublishAt, draft.DraftID),
+		},
+	}, true
+}
+
+// draftPuts 建立寫入草稿 (以及排程中時的排程索引) 的交易項目，condition 套用在草稿上
+func (r *dynamoDBDraftRepository) draftPuts(draft *models.Draft, condition string, values map[string]types.AttributeValue) ([]types.TransactWriteItem, error) {
+	draft.PK = "USER#" + draft.AuthorID
+	draft.SK = "DRAFT#" + draft.DraftID
+	draft.EntityType = "DRAFT"
+	item, err := attributevalue.MarshalMap(draft)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal draft: %w", err)
+	}
+
+	put := &types.Put{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String(condition),
+	}
+	if len(values) > 0 {
+		put.ExpressionAttributeNames = map[string]string{"#status": "status"}
+		put.ExpressionAttributeValues = values
+	}
+	transactItems := []types.TransactWriteItem{{Put: put}}
+
+	if draft.Status == models.DraftStatusScheduled {
+		entryPut, err := r.scheduledPostPut(models.ScheduledPost{
+			DraftID:   draft.DraftID,
+			AuthorID:  draft.AuthorID,
+			PublishAt: draft.PublishAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		transactItems = append(transactItems, entryPut)
+	}
+	return transactItems, nil
+}
+
+// CreateDraft 在同一個交易中寫入草稿與排程索引
+func (r *dynamoDBDraftRepository) CreateDraft(ctx context.Context, draft *models.Draft) error {
+	transactItems, err := r.draftPuts(draft, "attribute_not_exists(PK)", nil)
+	if err != nil {
+		return err
+	}
+	if _, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: transactItems}); err != nil {
+		log.Printf("Error creating draft %s for user %s: %v", draft.DraftID, draft.AuthorID, err)
+		return err
+	}
+	return nil
+}
+
+// GetDraft 讀取 USER#{author_id} / DRAFT#{draft_id}
+func (r *dynamoDBDraftRepository) GetDraft(ctx context.Context, authorID, draftID string) (*models.Draft, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            draftKey(authorID, draftID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		log.Printf("Error getting draft %s for user %s: %v", draftID, authorID, err)
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, ErrDraftNotFound
+	}
+
+	var draft models.Draft
+	if err := attributevalue.UnmarshalMap(result.Item, &draft); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal draft: %w", err)
+	}
+	return &draft, nil
+}
+
+// ListDrafts 查詢作者分割區中 DRAFT# 開頭的項目；草稿數量不多，讀取全部後依 updated_at 排序
+func (r *dynamoDBDraftRepository) ListDrafts(ctx context.Context, authorID string) ([]models.Draft, error) {
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: "USER#" + authorID},
+			":prefix": &types.AttributeValueMemberS{Value: "DRAFT#"},
+		},
+	})
+
+	var drafts []models.Draft
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("Error listing drafts for user %s: %v", authorID, err)
+			return nil, err
+		}
+		var items []models.Draft
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal drafts: %w", err)
+		}
+		drafts = append(drafts, items...)
+	}
+
+	sort.Slice(drafts, func(i, j int) bool {
+		return drafts[i].UpdatedAt > drafts[j].UpdatedAt
+	})
+	return drafts, nil
+}
+
+// editableDraftCondition 是編輯與刪除草稿的條件：內容未被修改且尚未被領取發布
+const editableDraftCondition = "updated_at = :previous AND #status IN (:draft, :scheduled, :failed)"
+
+// editableDraftValues 回傳 editableDraftCondition 使用的值
+func editableDraftValues(previous *models.Draft) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		":previous":  &types.AttributeValueMemberS{Value: previous.UpdatedAt},
+		":draft":     &types.AttributeValueMemberS{Value: models.DraftStatusDraft},
+		":scheduled": &types.AttributeValueMemberS{Value: models.DraftStatusScheduled},
+		":failed":    &types.AttributeValueMemberS{Value: models.DraftStatusFailed},
+	}
+}
+
+// UpdateDraft 覆寫草稿；publish_at 或狀態改變時刪除舊的排程索引並寫入新的
+func (r *dynamoDBDraftRepository) UpdateDraft(ctx context.Context, previous, draft *models.Draft) error {
+	transactItems, err := r.draftPuts(draft, editableDraftCondition, editableDraftValues(previous))
+	if err != nil {
+		return err
+	}
+	if entryDelete, ok := r.scheduledPostDelete(previous); ok && !(draft.Status == models.DraftStatusScheduled && draft.PublishAt == previous.PublishAt) {
+		transactItems = append(transactItems, entryDelete)
+	}
+
+	if _, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: transactItems}); err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && conditionFailedAt(canceled, 0) {
+			return ErrDraftConflict
+		}
+		log.Printf("Error updating draft %s for user %s: %v", draft.DraftID, draft.AuthorID, err)
+		return err
+	}
+	return nil
+}
+
+// DeleteDraft 在同一個交易中刪除草稿與排程索引
+func (r *dynamoDBDraftRepository) DeleteDraft(ctx context.Context, draft *models.Draft) error {
+	transactItems := []types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				TableName:                 aws.String(r.tableName),
+				Key:                       draftKey(draft.AuthorID, draft.DraftID),
+				ConditionExpression:       aws.String(editableDraftCondition),
+				ExpressionAttributeNames:  map[string]string{"#status": "status"},
+				ExpressionAttributeValues: editableDraftValues(draft),
+			},
+		},
+	}
+	if entryDelete, ok := r.scheduledPostDelete(draft); ok {
+		transactItems = append(transactItems, entryDelete)
+	}
+
+	if _, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: transactItems}); err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && conditionFailedAt(canceled, 0) {
+			return ErrDraftConflict
+		}
+		log.Printf("Error deleting draft %s for user %s: %v", draft.DraftID, draft.AuthorID, err)
+		return err
+	}
+	return nil
+}
+
+// ListDueScheduledPosts 查詢 SCHEDULED_POSTS 分割區中 SK <= {now}#~ 的項目；draft_id 為 UUID，'~' 排在所有字元之後。
+// 延後重試的項目以 FilterExpression 略過，因此分頁讀取直到湊滿 limit 筆或讀完到期的範圍
+func (r *dynamoDBDraftRepository) ListDueScheduledPosts(ctx context.Context, now string, limit int32) ([]models.ScheduledPost, error) {
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND SK <= :upper"),
+		FilterExpression:       aws.String("attribute_not_exists(retry_at) OR retry_at <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: models.ScheduledPostsPK},
+			":upper": &types.AttributeValueMemberS{Value: now + "#~"},
+			":now":   &types.AttributeValueMemberS{Value: now},
+		},
+		ConsistentRead: aws.Bool(true),
+		Limit:          aws.Int32(limit),
+	})
+
+	var entries []models.ScheduledPost
+	for paginator.HasMorePages() && int32(len(entries)) < limit {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("Error listing due scheduled posts: %v", err)
+			return nil, err
+		}
+		var items []models.ScheduledPost
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal scheduled posts: %w", err)
+		}
+		entries = append(entries, items...)
+	}
+	if int32(len(entries)) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+// ClaimDraft 以條件更新領取草稿，成功時回傳領取後的草稿
+func (r *dynamoDBDraftRepository) ClaimDraft(ctx context.Context, authorID, draftID, now, leaseUntil string) (*models.Draft, error) {
+	result, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 draftKey(authorID, draftID),
+		UpdateExpression:    aws.String("SET #status = :publishing, lease_until = :until"),
+		ConditionExpression: aws.String("(#status = :scheduled AND publish_at <= :now) OR (#status = :publishing AND lease_until < :now)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":publishing": &types.AttributeValueMemberS{Value: models.DraftStatusPublishing},
+			":scheduled":  &types.AttributeValueMemberS{Value: models.DraftStatusScheduled},
+			":until":      &types.AttributeValueMemberS{Value: leaseUntil},
+			":now":        &types.AttributeValueMemberS{Value: now},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil, ErrDraftAlreadyClaimed
+		}
+		log.Printf("Error claiming draft %s for user %s: %v", draftID, authorID, err)
+		return nil, err
+	}
+
+	var draft models.Draft
+	if err := attributevalue.UnmarshalMap(result.Attributes, &draft); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal claimed draft: %w", err)
+	}
+	return &draft, nil
+}
+
+// PublishDraft 建立貼文、以領取期限為條件刪除草稿，並覆寫排程索引記錄新貼文的主鍵
+func (r *dynamoDBDraftRepository) PublishDraft(ctx context.Context, draft *models.Draft, post *models.Post) error {
+	post.EntityType = models.PostEntityPost
+	transactItems, err := r.posts.newPostPuts(post)
+	if err != nil {
+		return err
+	}
+
+	draftIndex := len(transactItems)
+	transactItems = append(transactItems, types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName:           aws.String(r.tableName),
+			Key:                 draftKey(draft.AuthorID, draft.DraftID),
+			ConditionExpression: aws.String("#status = :publishing AND lease_until = :until"),
+			ExpressionAttributeNames: map[string]string{
+				"#status": "status",
+			},
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":publishing": &types.AttributeValueMemberS{Value: models.DraftStatusPublishing},
+				":until":      &types.AttributeValueMemberS{Value: draft.LeaseUntil},
+			},
+		},
+	})
+	entryPut, err := r.scheduledPostPut(models.ScheduledPost{
+		DraftID:         draft.DraftID,
+		AuthorID:        draft.AuthorID,
+		PublishAt:       draft.PublishAt,
+		PublishedPostID: post.PostID,
+		PostPK:          post.PK,
+		PostSK:          post.SK,
+	})
+	if err != nil {
+		return err
+	}
+	transactItems = append(transactItems, entryPut)
+
+	if _, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: transactItems}); err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && conditionFailedAt(canceled, draftIndex) {
+			return ErrDraftLeaseLost
+		}
+		log.Printf("Error publishing draft %s for user %s: %v", draft.DraftID, draft.AuthorID, err)
+		return err
+	}
+	return nil
+}
+
+// scheduledPostCondition 回傳以 published_post_id 確認排程索引仍是呼叫端讀到的狀態的條件，
+// 避免在其他程序剛發布、尚未完成 fan-out 時改動它的項目
+func scheduledPostCondition(entry *models.ScheduledPost) (string, map[string]types.AttributeValue) {
+	if entry.PublishedPostID == "" {
+		return "attribute_not_exists(published_post_id)", nil
+	}
+	return "published_post_id = :pid", map[string]types.AttributeValue{
+		":pid": &types.AttributeValueMemberS{Value: entry.PublishedPostID},
+	}
+}
+
+// CompleteScheduledPost 刪除排程索引項目；條件不符代表已由其他程序處理，視為成功
+func (r *dynamoDBDraftRepository) CompleteScheduledPost(ctx context.Context, entry *models.ScheduledPost) error {
+	condition, values := scheduledPostCondition(entry)
+	input := &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(r.tableName),
+		Key:                       scheduledPostKey(entry.PublishAt, entry.DraftID),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil
+		}
+		log.Printf("Error deleting scheduled post entry for draft %s: %v", entry.DraftID, err)
+		return err
+	}
+	return nil
+}
+
+// DeferScheduledPost 更新排程索引的 attempts 與 retry_at；項目已被刪除或已被其他程序改寫時視為成功
+func (r *dynamoDBDraftRepository) DeferScheduledPost(ctx context.Context, entry *models.ScheduledPost, attempts int, retryAt string) error {
+	condition, values := scheduledPostCondition(entry)
+	if values == nil {
+		values = make(map[string]types.AttributeValue, 2)
+	}
+	values[":attempts"] = &types.AttributeValueMemberN{Value: strconv.Itoa(attempts)}
+	values[":retry"] = &types.AttributeValueMemberS{Value: retryAt}
+
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tableName),
+		Key:                       scheduledPostKey(entry.PublishAt, entry.DraftID),
+		UpdateExpression:          aws.String("SET attempts = :attempts, retry_at = :retry"),
+		ConditionExpression:       aws.String("attribute_exists(PK) AND " + condition),
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil
+		}
+		log.Printf("Error deferring scheduled post entry for draft %s: %v", entry.DraftID, err)
+		return err
+	}
+	return nil
+}
+
+// FailScheduledPost 將草稿改為 failed 並移除排程索引。草稿必須仍在同一個時間排程，
+// 且沒有被其他程序在領取期限內發布中 (呼叫端自己持有的領取除外)
+func (r *dynamoDBDraftRepository) FailScheduledPost(ctx context.Context, entry *models.ScheduledPost, now, leaseUntil string) error {
+	transactItems := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName:           aws.String(r.tableName),
+				Key:                 draftKey(entry.AuthorID, entry.DraftID),
+				UpdateExpression:    aws.String("SET #status = :failed REMOVE lease_until"),
+				ConditionExpression: aws.String("publish_at = :publishAt AND (#status = :scheduled OR (#status = :publishing AND (lease_until < :now OR lease_until = :until)))"),
+				ExpressionAttributeNames: map[string]string{
+					"#status": "status",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":failed":     &types.AttributeValueMemberS{Value: models.DraftStatusFailed},
+					":scheduled":  &types.AttributeValueMemberS{Value: models.DraftStatusScheduled},
+					":publishing": &types.AttributeValueMemberS{Value: models.DraftStatusPublishing},
+					":publishAt":  &types.AttributeValueMemberS{Value: entry.PublishAt},
+					":now":        &types.AttributeValueMemberS{Value: now},
+					":until":      &types.AttributeValueMemberS{Value: leaseUntil},
+				},
+			},
+		},
+		{
+			Delete: &types.Delete{
+				TableName:           aws.String(r.tableName),
+				Key:                 scheduledPostKey(entry.PublishAt, entry.DraftID),
+				ConditionExpression: aws.String("attribute_not_exists(published_post_id)"),
+			},
+		},
+	}
+
+	if _, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: transactItems}); err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			return ErrDraftConflict
+		}
+		log.Printf("Error failing scheduled draft %s for user %s: %v", entry.DraftID, entry.AuthorID, err)
+		return err
+	}
+	return nil
+}
@@ -4,9 +4,11 @@ package repository
 import (
 	"backend/internal/models" // 假設有 models.UserFeedItem
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -16,9 +18,28 @@ import (
 
 const UserFeedTableName = "UserFeed" // UserFeed 表名
 
+const (
+	// batchWriteMaxAttempts 是 BatchWriteItem 遇到 UnprocessedItems (BatchGetItem 遇到 UnprocessedKeys) 時的最大嘗試次數
+	batchWriteMaxAttempts = 5
+	// batchWriteBaseBackoff 是第一次重試未處理項目前的等待時間，之後每次加倍
+	batchWriteBaseBackoff = 50 * time.Millisecond
+)
+
 type FeedRepository interface {
+	// GetUserFeed 依時間由新到舊分頁取得使用者的 Feed
 	GetUserFeed(ctx context.Context, userID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedFeed, error)
+	// GetUserFeedSince 依時間由舊到新取得 after (不含) 之後新增的 Feed 項目，供客戶端輪詢新內容
+	GetUserFeedSince(ctx context.Context, userID, after string, limit int32) (*models.PaginatedFeed, error)
 	BatchAddToFeed(ctx context.Context, items []models.UserFeedItem) error // <--- 新增此方法
+	// RemoveAuthorFromFeed 刪除使用者 Feed 中某位作者的所有項目，回傳刪除的筆數
+	RemoveAuthorFromFeed(ctx context.Context, userID, authorID string) (int, error)
+	// RemovePostFromFeeds 從多位使用者的 Feed 刪除同一篇貼文，回傳實際刪除的筆數
+	RemovePostFromFeeds(ctx context.Context, userIDs []string, createdAt, postID string) (int, error)
+	// ListFeedItems 取得使用者 Feed 的所有項目 (只含 PK、SK、PostID、AuthorID)，供重建指令比對
+	ListFeedItems(ctx context.Context, userID string) ([]models.UserFeedItem, error)
+	// ScanFeedItems 分頁掃描整張 UserFeed 表 (只含 PK、SK、PostID、AuthorID)，供對帳指令使用
+	ScanFeedItems(ctx context.Context, fn func(items []models.UserFeedItem) error) error
+	DeleteFeedItems(ctx context.Context, items []models.UserFeedItem) error
 }
 
 type dynamoDBFeedRepository struct {
@@ -61,23 +82,253 @@ func (r *dynamoDBFeedRepository) BatchAddToFeed(ctx context.Context, items []mod
 		}
 		chunk := writeRequests[i:end]
 
-		input := &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				r.tableName: chunk,
+		if err := r.batchWriteWithRetry(ctx, chunk); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Successfully fanned out to %d feeds.", len(items))
+	return nil
+}
+
+// RemoveAuthorFromFeed 查詢使用者 Feed 中屬於 authorID 的項目並批次刪除
+func (r *dynamoDBFeedRepository) RemoveAuthorFromFeed(ctx context.Context, userID, authorID string) (int, error) {
+	removed := 0
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk"),
+			FilterExpression:       aws.String("AuthorID = :author"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":author": &types.AttributeValueMemberS{Value: authorID},
+			},
+			ProjectionExpression: aws.String("PK, SK"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			log.Printf("DynamoDB Query failed for feed items of author %s in feed %s: %v", authorID, userID, err)
+			return removed, fmt.Errorf("failed to query feed items: %w", err)
+		}
+
+		var requests []types.WriteRequest
+		for _, item := range result.Items {
+			requests = append(requests, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{
+					Key: map[string]types.AttributeValue{"PK": item["PK"], "SK": item["SK"]},
+				},
+			})
+		}
+		for i := 0; i < len(requests); i += 25 {
+			end := i + 25
+			if end > len(requests) {
+				end = len(requests)
+			}
+			if err := r.batchWriteWithRetry(ctx, requests[i:end]); err != nil {
+				return removed, err
+			}
+			removed += end - i
+		}
+
+		if result.LastEvaluatedKey == nil {
+			return removed, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// RemovePostFromFeeds 逐一刪除使用者 Feed 中的貼文項目。
+// UserFeed 的 SK 只有 created_at，因此以 PostID 作為刪除條件，避免誤刪同一時間的其他貼文。
+func (r *dynamoDBFeedRepository) RemovePostFromFeeds(ctx context.Context, userIDs []string, createdAt, postID string) (int, error) {
+	removed := 0
+	for _, userID := range userIDs {
+		_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(r.tableName),
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+				"SK": &types.AttributeValueMemberS{Value: createdAt},
+			},
+			ConditionExpression: aws.String("PostID = :pid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pid": &types.AttributeValueMemberS{Value: postID},
 			},
+		})
+		if err != nil {
+			var conditionFailed *types.ConditionalCheckFailedException
+			if errors.As(err, &conditionFailed) {
+				continue // 項目不存在或屬於其他貼文
+			}
+			log.Printf("failed to remove post %s from feed of user %s: %v", postID, userID, err)
+			return removed, fmt.Errorf("failed to remove feed item: %w", err)
 		}
+		removed++
+	}
+	return removed, nil
+}
 
-		_, err := r.client.BatchWriteItem(ctx, input)
+// ListFeedItems 分頁查詢使用者的整個 Feed 分割區
+func (r *dynamoDBFeedRepository) ListFeedItems(ctx context.Context, userID string) ([]models.UserFeedItem, error) {
+	var items []models.UserFeedItem
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			},
+			// 不投影 TTLTimestamp，其型別在舊資料中不一致
+			ProjectionExpression: aws.String("PK, SK, PostID, AuthorID"),
+			ExclusiveStartKey:    startKey,
+		})
 		if err != nil {
-			log.Printf("failed to batch write feed items: %v", err)
-			return fmt.Errorf("failed to batch write feed items: %w", err)
+			log.Printf("DynamoDB Query failed for feed items of user %s: %v", userID, err)
+			return nil, fmt.Errorf("failed to query feed items: %w", err)
+		}
+
+		var page []models.UserFeedItem
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal feed items: %w", err)
 		}
+		items = append(items, page...)
+		if result.LastEvaluatedKey == nil {
+			return items, nil
+		}
+		startKey = result.LastEvaluatedKey
 	}
+}
 
-	log.Printf("Successfully fanned out to %d feeds.", len(items))
+// ScanFeedItems 分頁掃描 UserFeed 表並對每一頁呼叫 fn
+func (r *dynamoDBFeedRepository) ScanFeedItems(ctx context.Context, fn func(items []models.UserFeedItem) error) error {
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName: aws.String(r.tableName),
+			// 不投影 TTLTimestamp，其型別在舊資料中不一致
+			ProjectionExpression: aws.String("PK, SK, PostID, AuthorID"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			log.Printf("failed to scan user feed table: %v", err)
+			return fmt.Errorf("failed to scan user feed: %w", err)
+		}
+
+		var items []models.UserFeedItem
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &items); err != nil {
+			return fmt.Errorf("failed to unmarshal feed items: %w", err)
+		}
+		if err := fn(items); err != nil {
+			return err
+		}
+
+		if result.LastEvaluatedKey == nil {
+			return nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// DeleteFeedItems 批次刪除 Feed 項目
+func (r *dynamoDBFeedRepository) DeleteFeedItems(ctx context.Context, items []models.UserFeedItem) error {
+	for i := 0; i < len(items); i += 25 {
+		end := i + 25
+		if end > len(items) {
+			end = len(items)
+		}
+		var requests []types.WriteRequest
+		for _, item := range items[i:end] {
+			requests = append(requests, types.WriteRequest{
+				DeleteRequest: &types.DeleteRequest{
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: item.PK},
+						"SK": &types.AttributeValueMemberS{Value: item.SK},
+					},
+				},
+			})
+		}
+		if err := r.batchWriteWithRetry(ctx, requests); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// batchWriteWithRetry 寫入一批項目，並以指數退避重試 DynamoDB 回傳的 UnprocessedItems；
+// 重試次數用完仍有未寫入的項目時回傳錯誤，讓呼叫端 (fan-out 工作) 可以整批重試
+func (r *dynamoDBFeedRepository) batchWriteWithRetry(ctx context.Context, requests []types.WriteRequest) error {
+	return batchWriteWithRetry(ctx, r.client, r.tableName, requests)
+}
+
+// batchWriteWithRetry 是各 repository 共用的 BatchWriteItem 重試邏輯，requests 不可超過 25 筆
+func batchWriteWithRetry(ctx context.Context, client *dynamodb.Client, tableName string, requests []types.WriteRequest) error {
+	pending := map[string][]types.WriteRequest{tableName: requests}
+	delay := batchWriteBaseBackoff
+
+	for attempt := 0; ; attempt++ {
+		output, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			log.Printf("failed to batch write %s items: %v", tableName, err)
+			return fmt.Errorf("failed to batch write %s items: %w", tableName, err)
+		}
+
+		pending = output.UnprocessedItems
+		remaining := len(pending[tableName])
+		if remaining == 0 {
+			return nil
+		}
+		if attempt+1 >= batchWriteMaxAttempts {
+			return fmt.Errorf("%d %s items still unprocessed after %d attempts", remaining, tableName, batchWriteMaxAttempts)
+		}
+
+		log.Printf("%d %s items unprocessed, retrying in %v", remaining, tableName, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// batchGetWithRetry 是各 repository 共用的 BatchGetItem 重試邏輯，keys 不可超過 100 筆。
+// 以與 batchWriteWithRetry 相同的指數退避重試 UnprocessedKeys，重試次數用完仍有未讀取的鍵時回傳錯誤
+func batchGetWithRetry(ctx context.Context, client *dynamodb.Client, tableName string, keys []map[string]types.AttributeValue, projection string) ([]map[string]types.AttributeValue, error) {
+	pending := map[string]types.KeysAndAttributes{tableName: {Keys: keys, ProjectionExpression: aws.String(projection)}}
+	delay := batchWriteBaseBackoff
+	var items []map[string]types.AttributeValue
+
+	for attempt := 0; ; attempt++ {
+		output, err := client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			log.Printf("failed to batch get %s items: %v", tableName, err)
+			return nil, fmt.Errorf("failed to batch get %s items: %w", tableName, err)
+		}
+		items = append(items, output.Responses[tableName]...)
+
+		pending = output.UnprocessedKeys
+		remaining := len(pending[tableName].Keys)
+		if remaining == 0 {
+			return items, nil
+		}
+		if attempt+1 >= batchWriteMaxAttempts {
+			return nil, fmt.Errorf("%d %s keys still unprocessed after %d attempts", remaining, tableName, batchWriteMaxAttempts)
+		}
+
+		log.Printf("%d %s keys unprocessed, retrying in %v", remaining, tableName, delay)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
 // GetUserFeed 從 UserFeed 表獲取 Feed
 func (r *dynamoDBFeedRepository) GetUserFeed(ctx context.Context, userID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedFeed, error) {
 	pkValue := "USER#" + userID
@@ -88,11 +339,32 @@ func (r *dynamoDBFeedRepository) GetUserFeed(ctx context.Context, userID string,
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":pk": &types.AttributeValueMemberS{Value: pkValue},
 		},
-		ScanIndexForward: aws.Bool(true),
+		ScanIndexForward: aws.Bool(false), // 最新的在前
 		Limit:            aws.Int32(limit),
 		ExclusiveStartKey: lastEvaluatedKey,
 	}
 
+	return r.queryFeed(ctx, userID, queryInput)
+}
+
+// GetUserFeedSince 查詢 created_at 大於 after 的 Feed 項目
+func (r *dynamoDBFeedRepository) GetUserFeedSince(ctx context.Context, userID, after string, limit int32) (*models.PaginatedFeed, error) {
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND SK > :after"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: "USER#" + userID},
+			":after": &types.AttributeValueMemberS{Value: after},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(limit),
+	}
+
+	return r.queryFeed(ctx, userID, queryInput)
+}
+
+// queryFeed 執行 Feed 查詢並解析結果
+func (r *dynamoDBFeedRepository) queryFeed(ctx context.Context, userID string, queryInput *dynamodb.QueryInput) (*models.PaginatedFeed, error) {
 	result, err := r.client.Query(ctx, queryInput)
 	if err != nil {
 		log.Printf("DynamoDB Query failed for user feed %s: %v", userID, err)
@@ -0,0 +1,116 @@
+// internal/repository/mute_repository_dynamodb.go
+package repository
+
+import (
+	"backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// ErrMuteFilterNotFound 表示找不到指定的靜音條件
+var ErrMuteFilterNotFound = errors.New("mute filter not found")
+
+// MuteRepository 定義了靜音條件的操作
+type MuteRepository interface {
+	CreateMuteFilter(ctx context.Context, filter *models.MuteFilter) error
+	// ListMuteFilters 列出使用者所有的靜音條件 (包含已過期、尚未刪除的項目)
+	ListMuteFilters(ctx context.Context, userID string) ([]models.MuteFilter, error)
+	DeleteMuteFilter(ctx context.Context, userID, filterID string) error
+}
+
+// dynamoDBMuteRepository 將靜音條件存放在 Posts 表中使用者的分割區
+type dynamoDBMuteRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBMuteRepository 是 dynamoDBMuteRepository 的建構子
+func NewDynamoDBMuteRepository(client *dynamodb.Client) MuteRepository {
+	return &dynamoDBMuteRepository{
+		client:    client,
+		tableName: FeedTableName,
+	}
+}
+
+// CreateMuteFilter 產生 ID 與鍵後寫入
+func (r *dynamoDBMuteRepository) CreateMuteFilter(ctx context.Context, filter *models.MuteFilter) error {
+	filter.FilterID = uuid.New().String()
+	filter.PK = "USER#" + filter.UserID
+	filter.SK = "MUTE#" + filter.FilterID
+	filter.EntityType = "MUTE"
+	filter.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+
+	item, err := attributevalue.MarshalMap(filter)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mute filter: %w", err)
+	}
+	if _, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	}); err != nil {
+		log.Printf("Failed to create mute filter for user %s: %v", filter.UserID, err)
+		return fmt.Errorf("failed to create mute filter: %w", err)
+	}
+	return nil
+}
+
+// ListMuteFilters 查詢 MUTE# 前綴的項目
+func (r *dynamoDBMuteRepository) ListMuteFilters(ctx context.Context, userID string) ([]models.MuteFilter, error) {
+	var filters []models.MuteFilter
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":prefix": &types.AttributeValueMemberS{Value: "MUTE#"},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			log.Printf("Failed to query mute filters of user %s: %v", userID, err)
+			return nil, fmt.Errorf("failed to query mute filters: %w", err)
+		}
+
+		var page []models.MuteFilter
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		filters = append(filters, page...)
+		if result.LastEvaluatedKey == nil {
+			return filters, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// DeleteMuteFilter 刪除靜音條件，不存在時回傳 ErrMuteFilterNotFound
+func (r *dynamoDBMuteRepository) DeleteMuteFilter(ctx context.Context, userID, filterID string) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "MUTE#" + filterID},
+		},
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrMuteFilterNotFound
+		}
+		log.Printf("Failed to delete mute filter %s of user %s: %v", filterID, userID, err)
+		return fmt.Errorf("failed to delete mute filter: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,646 @@
+// internal/repository/notification_repository_dynamodb.go
+package repository
+
+import (
+	"backend/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// ErrNotificationGroupConflict 表示群組通知在讀取後已被其他請求修改，呼叫端應重新讀取後再試
+var ErrNotificationGroupConflict = errors.New("notification group was modified concurrently")
+
+// ErrNotificationNotFound 表示找不到指定的通知
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// ErrDigestAlreadyClaimed 表示摘要已被其他排程執行個體領取
+var ErrDigestAlreadyClaimed = errors.New("digest already claimed")
+
+// notificationPrefsSK 是通知偏好項目的 SK
+const notificationPrefsSK = "NOTIFPREFS"
+
+// notificationCounterSK 是未讀通知計數項目的 SK，與通知在同一個交易中增減
+const notificationCounterSK = "NOTIFCOUNT"
+
+// notificationGroupPointerTTL 是群組指標在最後一次累加後的存活時間，過期後由 DynamoDB TTL 清除，
+// 之後同一群組的新事件會開新的群組通知
+const notificationGroupPointerTTL = 30 * 24 * time.Hour
+
+// NotificationRepository 定義了通知項目的操作
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, notification *models.Notification) error
+	// GetOpenGroupNotification 回傳群組中仍未讀、可以繼續累加的通知 (沒有則為 nil)，
+	// 以及讀取當下群組指標所指向的 SK (指標不存在時為空字串)
+	GetOpenGroupNotification(ctx context.Context, userID, groupKey string) (*models.Notification, string, error)
+	// SaveGroupNotification 寫入新的群組通知並取代 previous (可為 nil)，
+	// 若群組指標已不是 observedPointerSK 則回傳 ErrNotificationGroupConflict
+	SaveGroupNotification(ctx context.Context, observedPointerSK string, previous, next *models.Notification) error
+	ListNotifications(ctx context.Context, userID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedNotifications, error)
+	MarkAsRead(ctx context.Context, userID, notificationSK string) error
+	MarkAllAsRead(ctx context.Context, userID string) (int, error)
+	CountUnread(ctx context.Context, userID string) (int, error)
+	// ListUnreadSince 由新到舊列出 since (RFC3339Nano，空字串代表不限) 之後建立的未讀通知，最多 limit 筆
+	ListUnreadSince(ctx context.Context, userID, since string, limit int) ([]models.Notification, error)
+
+	// --- 通知偏好 ---
+	// GetPreferences 取得使用者的通知偏好，尚未設定時回傳 nil
+	GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+	// SavePreferences 寫入通知管道與摘要頻率，不會覆寫 last_digest_at；開啟摘要時將使用者排入摘要排程索引並立即到期
+	SavePreferences(ctx context.Context, prefs *models.NotificationPreferences) error
+	// ListDueDigests 分頁列出摘要排程索引中 now (RFC3339，含) 之前到期的使用者 ID，每一頁呼叫一次 fn
+	ListDueDigests(ctx context.Context, now string, fn func(userIDs []string) error) error
+	// ClaimDigest 在 last_digest_at 仍為 previous 時將其更新為 now，並把下一次寄送時間排到 nextDue，
+	// 讓多個 replica 同時執行排程時同一份摘要只會寄出一次；已被領取時回傳 ErrDigestAlreadyClaimed
+	ClaimDigest(ctx context.Context, userID, previous, now, nextDue string) error
+	// RescheduleDigest 將尚未到期 (或暫時無法寄送) 的使用者在摘要排程索引中延後到 nextDue
+	RescheduleDigest(ctx context.Context, userID, nextDue string) error
+}
+
+// dynamoDBNotificationRepository 將通知存放在 Posts 表中收件者的分割區
+type dynamoDBNotificationRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBNotificationRepository 是 dynamoDBNotificationRepository 的建構子
+func NewDynamoDBNotificationRepository(client *dynamodb.Client) NotificationRepository {
+	return &dynamoDBNotificationRepository{
+		client:    client,
+		tableName: FeedTableName,
+	}
+}
+
+// fillNotificationKeys 補全新通知的鍵值、ID 與時間
+func fillNotificationKeys(notification *models.Notification) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	notificationID := uuid.New().String()
+
+	notification.PK = "USER#" + notification.RecipientUserID
+	notification.SK = fmt.Sprintf("NOTIFICATION#%s#%s", now, notificationID)
+	notification.EntityType = "NOTIFICATION"
+	notification.NotificationID = notificationID
+	notification.ReadStatus = false
+	notification.ReadAt = ""
+	notification.CreatedAt = now
+}
+
+// CreateNotification 補全鍵值與時間後寫入一筆新通知
+func (r *dynamoDBNotificationRepository) CreateNotification(ctx context.Context, notification *models.Notification) error {
+	fillNotificationKeys(notification)
+
+	item, err := attributevalue.MarshalMap(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: aws.String(r.tableName),
+					Item:      item,
+				},
+			},
+			r.unreadCounterUpdate(notification.RecipientUserID, 1),
+		},
+	})
+	if err != nil {
+		log.Printf("Error putting notification for user %s: %v", notification.RecipientUserID, err)
+		return err
+	}
+	return nil
+}
+
+// GetOpenGroupNotification 透過群組指標找到目前的群組通知，已讀的通知不再累加
+func (r *dynamoDBNotificationRepository) GetOpenGroupNotification(ctx context.Context, userID, groupKey string) (*models.Notification, string, error) {
+	pk := "USER#" + userID
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: pk},
+			"SK": &types.AttributeValueMemberS{Value: "NOTIFGROUP#" + groupKey},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		log.Printf("Error reading notification group %s for user %s: %v", groupKey, userID, err)
+		return nil, "", err
+	}
+	if result.Item == nil {
+		return nil, "", nil
+	}
+
+	var pointer models.NotificationGroupPointer
+	if err := attributevalue.UnmarshalMap(result.Item, &pointer); err != nil {
+		return nil, "", err
+	}
+
+	result, err = r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: pk},
+			"SK": &types.AttributeValueMemberS{Value: pointer.NotificationSK},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		log.Printf("Error reading grouped notification %s for user %s: %v", pointer.NotificationSK, userID, err)
+		return nil, "", err
+	}
+	if result.Item == nil {
+		return nil, pointer.NotificationSK, nil
+	}
+
+	var notification models.Notification
+	if err := attributevalue.UnmarshalMap(result.Item, &notification); err != nil {
+		return nil, "", err
+	}
+	if notification.ReadStatus {
+		return nil, pointer.NotificationSK, nil
+	}
+	return &notification, pointer.NotificationSK, nil
+}
+
+// SaveGroupNotification 在同一個交易中寫入新的群組通知、刪除被取代的舊通知並移動群組指標。
+// 新通知使用新的 SK (時間戳)，因此合併後的通知會回到收件匣的最上方。
+func (r *dynamoDBNotificationRepository) SaveGroupNotification(ctx context.Context, observedPointerSK string, previous, next *models.Notification) error {
+	fillNotificationKeys(next)
+
+	item, err := attributevalue.MarshalMap(next)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	pointerItem, err := attributevalue.MarshalMap(models.NotificationGroupPointer{
+		PK:             next.PK,
+		SK:             "NOTIFGROUP#" + next.GroupKey,
+		EntityType:     "NOTIFICATION_GROUP",
+		NotificationSK: next.SK,
+		TTLTimestamp:   time.Now().Add(notificationGroupPointerTTL).Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification group pointer: %w", err)
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      item,
+			},
+		},
+	}
+
+	pointerPut := &types.Put{
+		TableName: aws.String(r.tableName),
+		Item:      pointerItem,
+	}
+	// 以讀取當下的指標作為樂觀鎖，避免兩個同時發生的事件各自開一個新群組
+	if observedPointerSK == "" {
+		pointerPut.ConditionExpression = aws.String("attribute_not_exists(PK)")
+	} else {
+		pointerPut.ConditionExpression = aws.String("notification_sk = :observed")
+		pointerPut.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":observed": &types.AttributeValueMemberS{Value: observedPointerSK},
+		}
+	}
+	if previous != nil {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: previous.PK},
+					"SK": &types.AttributeValueMemberS{Value: previous.SK},
+				},
+				// 若舊通知在期間被標記為已讀，就不能再合併進去
+				ConditionExpression: aws.String("read_status = :unread"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":unread": &types.AttributeValueMemberBOOL{Value: false},
+				},
+			},
+		})
+	} else {
+		// 沒有被取代的未讀通知時才會多一則未讀
+		transactItems = append(transactItems, r.unreadCounterUpdate(next.RecipientUserID, 1))
+	}
+	transactItems = append(transactItems, types.TransactWriteItem{Put: pointerPut})
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			return ErrNotificationGroupConflict
+		}
+		log.Printf("Error saving grouped notification for user %s: %v", next.RecipientUserID, err)
+		return err
+	}
+	return nil
+}
+
+// ListNotifications 依時間由新到舊列出使用者的通知
+func (r *dynamoDBNotificationRepository) ListNotifications(ctx context.Context, userID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedNotifications, error) {
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: "USER#" + userID},
+			":prefix": &types.AttributeValueMemberS{Value: "NOTIFICATION#"},
+		},
+		ScanIndexForward:  aws.Bool(false),
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: lastEvaluatedKey,
+	})
+	if err != nil {
+		log.Printf("DynamoDB Query failed for notifications of user %s: %v", userID, err)
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+
+	var notifications []models.Notification
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &notifications); err != nil {
+		log.Printf("Failed to unmarshal notifications for user %s: %v", userID, err)
+		return nil, err
+	}
+
+	return &models.PaginatedNotifications{
+		Items:            notifications,
+		LastEvaluatedKey: result.LastEvaluatedKey,
+	}, nil
+}
+
+// MarkAsRead 將單一通知標記為已讀，並在同一個交易中遞減未讀計數；已讀的通知不會重複遞減
+func (r *dynamoDBNotificationRepository) MarkAsRead(ctx context.Context, userID, notificationSK string) error {
+	if !strings.HasPrefix(notificationSK, "NOTIFICATION#") {
+		return ErrNotificationNotFound
+	}
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName: aws.String(r.tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+						"SK": &types.AttributeValueMemberS{Value: notificationSK},
+					},
+					UpdateExpression:    aws.String("SET read_status = :read, read_at = :now"),
+					ConditionExpression: aws.String("attribute_exists(PK) AND read_status = :unread"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":read":   &types.AttributeValueMemberBOOL{Value: true},
+						":unread": &types.AttributeValueMemberBOOL{Value: false},
+						":now":    &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+					},
+					// 條件失敗時帶回舊項目，用來分辨「不存在」與「已經是已讀」
+					ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+				},
+			},
+			r.unreadCounterUpdate(userID, -1),
+		},
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && conditionFailedAt(canceled, 0) {
+			if len(canceled.CancellationReasons[0].Item) == 0 {
+				return ErrNotificationNotFound
+			}
+			return nil
+		}
+		log.Printf("Error marking notification %s as read for user %s: %v", notificationSK, userID, err)
+		return err
+	}
+	return nil
+}
+
+// MarkAllAsRead 將使用者所有未讀通知標記為已讀，回傳更新的筆數
+func (r *dynamoDBNotificationRepository) MarkAllAsRead(ctx context.Context, userID string) (int, error) {
+	updated := 0
+	err := r.forEachUnread(ctx, userID, func(items []map[string]types.AttributeValue) error {
+		for _, item := range items {
+			sk, ok := item["SK"].(*types.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+			if err := r.MarkAsRead(ctx, userID, sk.Value); err != nil {
+				if errors.Is(err, ErrNotificationNotFound) {
+					continue
+				}
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	return updated, err
+}
+
+// CountUnread 讀取使用者的未讀計數項目；尚未有計數項目的舊帳號會先完整計算一次並寫入
+func (r *dynamoDBNotificationRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	key := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+		"SK": &types.AttributeValueMemberS{Value: notificationCounterSK},
+	}
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String(r.tableName),
+		Key:                  key,
+		ProjectionExpression: aws.String("unread_count"),
+	})
+	if err != nil {
+		log.Printf("Error reading unread notification count for user %s: %v", userID, err)
+		return 0, fmt.Errorf("failed to read unread notification count: %w", err)
+	}
+	if result.Item != nil {
+		var counter struct {
+			UnreadCount int `dynamodbav:"unread_count"`
+		}
+		if err := attributevalue.UnmarshalMap(result.Item, &counter); err != nil {
+			return 0, err
+		}
+		return max(counter.UnreadCount, 0), nil
+	}
+
+	count := 0
+	err = r.forEachUnread(ctx, userID, func(items []map[string]types.AttributeValue) error {
+		count += len(items)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	// 只在計數項目仍不存在時寫入，避免覆蓋期間由新通知建立的計數
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item: map[string]types.AttributeValue{
+			"PK":           key["PK"],
+			"SK":           key["SK"],
+			"entity_type":  &types.AttributeValueMemberS{Value: "NOTIFICATION_COUNTER"},
+			"unread_count": &types.AttributeValueMemberN{Value: strconv.Itoa(count)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if !errors.As(err, &conditionFailed) {
+			log.Printf("Error seeding unread notification count for user %s: %v", userID, err)
+		}
+	}
+	return count, nil
+}
+
+// unreadCounterUpdate 產生增減未讀計數的交易項目
+func (r *dynamoDBNotificationRepository) unreadCounterUpdate(userID string, delta int) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: aws.String(r.tableName),
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+				"SK": &types.AttributeValueMemberS{Value: notificationCounterSK},
+			},
+			UpdateExpression: aws.String("ADD unread_count :delta SET entity_type = :type"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":delta": &types.AttributeValueMemberN{Value: strconv.Itoa(delta)},
+				":type":  &types.AttributeValueMemberS{Value: "NOTIFICATION_COUNTER"},
+			},
+		},
+	}
+}
+
+// forEachUnread 分頁查詢使用者的未讀通知 (只投影 SK)，並對每一頁呼叫 fn；會掃過整個通知歷史，只用於全部已讀與補建計數
+func (r *dynamoDBNotificationRepository) forEachUnread(ctx context.Context, userID string, fn func(items []map[string]types.AttributeValue) error) error {
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+			FilterExpression:       aws.String("read_status = :unread"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":prefix": &types.AttributeValueMemberS{Value: "NOTIFICATION#"},
+				":unread": &types.AttributeValueMemberBOOL{Value: false},
+			},
+			ProjectionExpression: aws.String("SK"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			log.Printf("DynamoDB Query failed for unread notifications of user %s: %v", userID, err)
+			return fmt.Errorf("failed to query unread notifications: %w", err)
+		}
+		if err := fn(result.Items); err != nil {
+			return err
+		}
+		if result.LastEvaluatedKey == nil {
+			return nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// ListUnreadSince 由新到舊列出指定時間之後建立的未讀通知
+func (r *dynamoDBNotificationRepository) ListUnreadSince(ctx context.Context, userID, since string, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	var startKey map[string]types.AttributeValue
+	for len(notifications) < limit {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :from AND :to"),
+			FilterExpression:       aws.String("read_status = :unread"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     &types.AttributeValueMemberS{Value: "USER#" + userID},
+				":from":   &types.AttributeValueMemberS{Value: "NOTIFICATION#" + since},
+				":to":     &types.AttributeValueMemberS{Value: "NOTIFICATION#~"}, // '~' 排在所有時間字元之後
+				":unread": &types.AttributeValueMemberBOOL{Value: false},
+			},
+			ScanIndexForward:  aws.Bool(false),
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			log.Printf("DynamoDB Query failed for unread notifications of user %s since %s: %v", userID, since, err)
+			return nil, fmt.Errorf("failed to query unread notifications: %w", err)
+		}
+
+		var page []models.Notification
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, page...)
+
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		startKey = result.LastEvaluatedKey
+	}
+
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+	}
+	return notifications, nil
+}
+
+// GetPreferences 取得使用者的通知偏好，尚未設定時回傳 nil
+func (r *dynamoDBNotificationRepository) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: notificationPrefsSK},
+		},
+	})
+	if err != nil {
+		log.Printf("Error getting notification preferences for user %s: %v", userID, err)
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+
+	var prefs models.NotificationPreferences
+	if err := attributevalue.UnmarshalMap(result.Item, &prefs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification preferences: %w", err)
+	}
+	return &prefs, nil
+}
+
+// SavePreferences 寫入通知管道與摘要頻率；使用 UpdateItem 以保留摘要排程寫入的 last_digest_at。
+// 開啟摘要時設定 GSI1 讓使用者出現在摘要排程索引，關閉時移除
+func (r *dynamoDBNotificationRepository) SavePreferences(ctx context.Context, prefs *models.NotificationPreferences) error {
+	channels, err := attributevalue.Marshal(prefs.Channels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification channels: %w", err)
+	}
+	prefs.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+
+	values := map[string]types.AttributeValue{
+		":type":     &types.AttributeValueMemberS{Value: "NOTIFICATION_PREFERENCES"},
+		":uid":      &types.AttributeValueMemberS{Value: prefs.UserID},
+		":channels": channels,
+		":freq":     &types.AttributeValueMemberS{Value: prefs.DigestFrequency},
+		":now":      &types.AttributeValueMemberS{Value: prefs.UpdatedAt},
+	}
+	update := "SET entity_type = :type, user_id = :uid, channels = :channels, digest_frequency = :freq, updated_at = :now"
+	if prefs.DigestFrequency != "" && prefs.DigestFrequency != models.DigestFrequencyOff {
+		// 先排在現在，由摘要排程依 last_digest_at 判斷是否真的到期，未到期時再延後
+		update += ", GSI1PK = :duePK, GSI1SK = :dueSK"
+		values[":duePK"] = &types.AttributeValueMemberS{Value: models.DigestDuePK}
+		values[":dueSK"] = &types.AttributeValueMemberS{Value: digestDueSK(time.Now().UTC().Format(time.RFC3339), prefs.UserID)}
+	} else {
+		update += " REMOVE GSI1PK, GSI1SK"
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + prefs.UserID},
+			"SK": &types.AttributeValueMemberS{Value: notificationPrefsSK},
+		},
+		UpdateExpression:          aws.String(update),
+		ExpressionAttributeValues: values,
+	}
+	_, err = r.client.UpdateItem(ctx, input)
+	if err != nil {
+		log.Printf("Error saving notification preferences for user %s: %v", prefs.UserID, err)
+		return err
+	}
+	return nil
+}
+
+// digestDueSK 回傳摘要排程索引的排序鍵
+func digestDueSK(due, userID string) string {
+	return due + "#" + userID
+}
+
+// ListDueDigests 以 GSI1 查詢 DIGEST_DUE 分割區中 GSI1SK <= {now}#~ 的項目，從 PK 取出使用者 ID
+func (r *dynamoDBNotificationRepository) ListDueDigests(ctx context.Context, now string, fn func(userIDs []string) error) error {
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :pk AND GSI1SK <= :upper"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: models.DigestDuePK},
+			":upper": &types.AttributeValueMemberS{Value: digestDueSK(now, "~")},
+		},
+		ProjectionExpression: aws.String("PK"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("Error listing due digests: %v", err)
+			return fmt.Errorf("failed to list due digests: %w", err)
+		}
+		userIDs := make([]string, 0, len(page.Items))
+		for _, item := range page.Items {
+			if pk, ok := item["PK"].(*types.AttributeValueMemberS); ok {
+				userIDs = append(userIDs, strings.TrimPrefix(pk.Value, "USER#"))
+			}
+		}
+		if err := fn(userIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimDigest 以條件更新領取下一份摘要
+func (r *dynamoDBNotificationRepository) ClaimDigest(ctx context.Context, userID, previous, now, nextDue string) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: notificationPrefsSK},
+		},
+		UpdateExpression: aws.String("SET last_digest_at = :now, GSI1SK = :dueSK"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now":   &types.AttributeValueMemberS{Value: now},
+			":dueSK": &types.AttributeValueMemberS{Value: digestDueSK(nextDue, userID)},
+		},
+	}
+	if previous == "" {
+		input.ConditionExpression = aws.String("attribute_exists(PK) AND attribute_not_exists(last_digest_at)")
+	} else {
+		input.ConditionExpression = aws.String("last_digest_at = :previous")
+		input.ExpressionAttributeValues[":previous"] = &types.AttributeValueMemberS{Value: previous}
+	}
+
+	if _, err := r.client.UpdateItem(ctx, input); err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return ErrDigestAlreadyClaimed
+		}
+		log.Printf("Error claiming digest for user %s: %v", userID, err)
+		return err
+	}
+	return nil
+}
+
+// RescheduleDigest 更新摘要排程索引的 GSI1SK；使用者已關閉摘要 (沒有 GSI1PK) 時不做任何事
+func (r *dynamoDBNotificationRepository) RescheduleDigest(ctx context.Context, userID, nextDue string) error {
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: notificationPrefsSK},
+		},
+		UpdateExpression:    aws.String("SET GSI1SK = :dueSK"),
+		ConditionExpression: aws.String("attribute_exists(GSI1PK)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":dueSK": &types.AttributeValueMemberS{Value: digestDueSK(nextDue, userID)},
+		},
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return nil
+		}
+		log.Printf("Error rescheduling digest for user %s: %v", userID, err)
+		return err
+	}
+	return nil
+}
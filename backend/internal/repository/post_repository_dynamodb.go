@@ -4,6 +4,7 @@ package repository
 
 import (
 	"backend/internal/models" // 假設您有 models.FeedItem 和 models.Post 結構
+	"backend/internal/textparse"
 	"context"
 	"errors"
 	"fmt"
@@ -22,25 +23,87 @@ import (
 type PostRepository interface {
 	GetFeedItemsByUserID(ctx context.Context, userPK string) ([]models.FeedItem, error)
 	GetPostsByIDs(ctx context.Context, postIDs []string) ([]models.Post, error)
+	// GetPostsByUserID 依時間由新到舊讀取作者的所有貼文 (重建 Feed 等需要完整列表的內部流程使用)
 	GetPostsByUserID(ctx context.Context, userID string) ([]models.Post, error)
+	// GetPostsPageByUserID 依時間由新到舊分頁讀取作者的貼文；已刪除的貼文會被過濾，因此一頁可能少於 limit 筆
+	GetPostsPageByUserID(ctx context.Context, userID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedPosts, error)
+	// GetPostsByAuthorAfter 依時間由舊到新取得作者在 after (RFC3339Nano，不含) 之後發布的貼文
+	GetPostsByAuthorAfter(ctx context.Context, authorID, after string, limit int32) ([]models.Post, error)
+	// GetPostsByAuthorBefore 依時間由新到舊取得作者在 after 與 before 之間 (皆不含) 發布的貼文；before 為空代表不設上限
+	GetPostsByAuthorBefore(ctx context.Context, authorID, before, after string, limit int32) ([]models.Post, error)
 	CreatePost(ctx context.Context, post *models.Post) error
+	// CreateRepost 建立轉發或引用 (repost.EntityType 為 PostEntityRepost 或 PostEntityQuote) 並遞增原始貼文的 repost_count；
+	// 單純轉發同時寫入 RepostLink，重複轉發時回傳 ErrAlreadyReposted
+	CreateRepost(ctx context.Context, original *models.Post, repost *models.Post) error
+	// GetRepostLink 取得使用者對某篇貼文的轉發紀錄
+	GetRepostLink(ctx context.Context, originalPostID, userID string) (*models.RepostLink, error)
+	// ListRepostLinks 列出某篇貼文的所有轉發紀錄
+	ListRepostLinks(ctx context.Context, originalPostID string) ([]models.RepostLink, error)
+	// CheckIfPostsRepostedBy 批次檢查使用者是否轉發過貼文 (POST#{post_id} / REPOST#{user_id})
+	CheckIfPostsRepostedBy(ctx context.Context, postIDs []string, userID string) (map[string]bool, error)
+	// UpdatePost 更新貼文並寫入編輯前的版本；post.Version 為讀取時的版本，不符時回傳 ErrPostEditConflict
 	UpdatePost(ctx context.Context, post *models.Post) error
+	// ListPostVersions 依版本由舊到新列出貼文的編輯紀錄
+	ListPostVersions(ctx context.Context, postID string) ([]models.PostVersion, error)
 	DeletePost(ctx context.Context, authorID, postID, createdAt string) error
+	// MarkPostDeleted 寫入軟刪除標記並立即移除標籤索引，相依資料由清理工作稍後刪除；
+	// 轉發與引用會一併遞減原始貼文的 repost_count 並移除 RepostLink
+	MarkPostDeleted(ctx context.Context, post *models.Post) error
+	// DeletePostChildren 刪除 POST#{post_id} 分割區中的所有項目 (按讚、評論等) 以及評論的 LIKEDCOMMENT 項目，回傳刪除的筆數
+	DeletePostChildren(ctx context.Context, postID string) (int, error)
+	// ScanDeletedPosts 找出所有帶有軟刪除標記、尚未清理完成的貼文
+	ScanDeletedPosts(ctx context.Context) ([]models.Post, error)
+	// ScanChildPostIDs 找出所有擁有子項目 (POST#{post_id} 分割區) 的貼文 ID
+	ScanChildPostIDs(ctx context.Context) ([]string, error)
+	// GetPostByID 以貼文 ID 讀取貼文，不存在或已軟刪除時回傳包裝 ErrPostNotFound 的錯誤
 	GetPostByID(ctx context.Context, postID string) (*models.Post, error)
+	// CheckPostsExist 確認貼文是否存在 (已軟刪除的視為不存在)；與 GetPostsByIDs 不同，任何讀取失敗都會回傳錯誤
+	CheckPostsExist(ctx context.Context, postIDs []string) (map[string]bool, error)
+	ReloadPost(ctx context.Context, post *models.Post) (*models.Post, error)
 	GetRecentPosts(ctx context.Context, lookbackDays int) ([]models.Post, error)
 
 	// --- FIX: Signatures changed to accept *models.Post ---
 	AddLike(ctx context.Context, post *models.Post, userID string) error
 	RemoveLike(ctx context.Context, post *models.Post, userID string) error
-	CreateComment(ctx context.Context, post *models.Post, comment *models.Comment) error
-	DeleteComment(ctx context.Context, post *models.Post, commentSK string) error
+	// CreateComment 新增評論；parent 不為 nil 時新增的是該評論的回覆
+	CreateComment(ctx context.Context, post *models.Post, parent *models.Comment, comment *models.Comment) error
+	DeleteComment(ctx context.Context, post *models.Post, comment *models.Comment) error
 	GetCommentBySK(ctx context.Context, postID, commentSK string) (*models.Comment, error)
+	GetCommentByID(ctx context.Context, postID, commentID string) (*models.Comment, error)
+	// UpdateComment 以 previous 的內容寫入一筆編輯紀錄，並將評論更新為 comment 的內容；
+	// 期間評論已被其他請求編輯時回傳 ErrCommentEditConflict
+	UpdateComment(ctx context.Context, previous *models.Comment, comment *models.Comment) error
+	// ListCommentEdits 依版本由舊到新列出評論的編輯紀錄
+	ListCommentEdits(ctx context.Context, postID, commentID string) ([]models.CommentEdit, error)
+	// ListComments 分頁列出頂層評論，newestFirst 為 false 時由舊到新
+	ListComments(ctx context.Context, postID string, newestFirst bool, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedComments, error)
+	// ListReplies 依時間由舊到新分頁列出某則評論的回覆
+	ListReplies(ctx context.Context, postID, parentCommentID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedComments, error)
 	CheckIfPostsLikedBy(ctx context.Context, postIDs []string, userID string) (map[string]bool, error) // <--- 新增此方法
-
+	// AddCommentLike / RemoveCommentLike 以交易同時寫入按讚紀錄與評論的 like_count
+	AddCommentLike(ctx context.Context, comment *models.Comment, userID string) error
+	RemoveCommentLike(ctx context.Context, comment *models.Comment, userID string) error
+	// CheckIfCommentsLikedBy 批次檢查使用者是否對評論按過讚 (USER#{user_id} / LIKEDCOMMENT#{comment_id})
+	CheckIfCommentsLikedBy(ctx context.Context, commentIDs []string, userID string) (map[string]bool, error)
+
+	// --- Hashtag 索引 ---
+	GetTagTimeline(ctx context.Context, tag string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedTagPosts, error)
 }
 
 const FeedTableName = "Posts" // 假設您的表名
 
+// ErrPostNotFound 表示貼文不存在或已軟刪除；只有這個錯誤代表貼文確定不存在，其他錯誤 (例如節流) 不能當作不存在處理
+var ErrPostNotFound = errors.New("post not found")
+
+// ErrPostEditConflict 表示貼文在讀取後已被其他請求編輯或刪除
+var ErrPostEditConflict = errors.New("post was modified concurrently")
+
+// ErrCommentEditConflict 表示評論在讀取後已被其他請求編輯或刪除
+var ErrCommentEditConflict = errors.New("comment was modified concurrently")
+
+// ErrAlreadyReposted 表示使用者已經轉發過這篇貼文
+var ErrAlreadyReposted = errors.New("post already reposted")
+
 // DynamoDBPostRepository 結構
 type DynamoDBPostRepository struct {
 	client    *dynamodb.Client
@@ -59,30 +122,46 @@ func (r *DynamoDBPostRepository) GetRecentPosts(ctx context.Context, lookbackDay
 
 	// 使用 Scan 操作篩選近期貼文。這在大型表上效率低下。
 	// 生產環境應建立 GSI (例如 PK: EntityType, SK: CreatedAt) 來高效查詢。
-	input := &dynamodb.ScanInput{
+	// 引用有自己的內容，與一般貼文一樣參與熱門排行；單純轉發沒有內容，不列入。
+	// 每次 Scan 最多讀取 1 MB，因此必須翻完所有頁面，否則只會看到表的一部分
+	paginator := dynamodb.NewScanPaginator(r.client, &dynamodb.ScanInput{
 		TableName:        aws.String(r.tableName),
-		FilterExpression: aws.String("entity_type = :type AND created_at >= :date"),
+		FilterExpression: aws.String("entity_type IN (:post, :quote) AND created_at >= :date AND attribute_not_exists(deleted_at)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":type": &types.AttributeValueMemberS{Value: "POST"},
-			":date": &types.AttributeValueMemberS{Value: cutOffDate},
+			":post":  &types.AttributeValueMemberS{Value: models.PostEntityPost},
+			":quote": &types.AttributeValueMemberS{Value: models.PostEntityQuote},
+			":date":  &types.AttributeValueMemberS{Value: cutOffDate},
 		},
-	}
-
-	result, err := r.client.Scan(ctx, input)
-	if err != nil {
-		log.Printf("Failed to scan for recent posts: %v", err)
-		return nil, err
-	}
+	})
 
 	var posts []models.Post
-	if err := attributevalue.UnmarshalListOfMaps(result.Items, &posts); err != nil {
-		log.Printf("Failed to unmarshal recent posts: %v", err)
-		return nil, err
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("Failed to scan for recent posts: %v", err)
+			return nil, err
+		}
+		var items []models.Post
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			log.Printf("Failed to unmarshal recent posts: %v", err)
+			return nil, err
+		}
+		posts = append(posts, items...)
 	}
 	return posts, nil
 }
 
 func (r *DynamoDBPostRepository) CheckIfPostsLikedBy(ctx context.Context, postIDs []string, userID string) (map[string]bool, error) {
+	return r.checkPostMarkers(ctx, postIDs, "USER#"+userID)
+}
+
+// CheckIfPostsRepostedBy 以 BatchGetItem 檢查 POST#{post_id} / REPOST#{user_id} 是否存在
+func (r *DynamoDBPostRepository) CheckIfPostsRepostedBy(ctx context.Context, postIDs []string, userID string) (map[string]bool, error) {
+	return r.checkPostMarkers(ctx, postIDs, "REPOST#"+userID)
+}
+
+// checkPostMarkers 批次檢查每篇貼文的 POST#{post_id} 分割區中是否存在 SK 為 sk 的項目 (按讚或轉發紀錄)
+func (r *DynamoDBPostRepository) checkPostMarkers(ctx context.Context, postIDs []string, sk string) (map[string]bool, error) {
 	if len(postIDs) == 0 {
 		return make(map[string]bool), nil
 	}
@@ -98,7 +177,7 @@ func (r *DynamoDBPostRepository) CheckIfPostsLikedBy(ctx context.Context, postID
 	for i, postID := range postIDs {
 		keys[i] = map[string]types.AttributeValue{
 			"PK": &types.AttributeValueMemberS{Value: "POST#" + postID},
-			"SK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: sk},
 		}
 	}
 
@@ -123,7 +202,7 @@ func (r *DynamoDBPostRepository) CheckIfPostsLikedBy(ctx context.Context, postID
 
 		result, err := r.client.BatchGetItem(ctx, input)
 		if err != nil {
-			log.Printf("BatchGetItem failed for checking likes: %v", err)
+			log.Printf("BatchGetItem failed for checking %s markers: %v", sk, err)
 			return nil, err
 		}
 
@@ -145,6 +224,59 @@ func (r *DynamoDBPostRepository) CheckIfPostsLikedBy(ctx context.Context, postID
 	return likedStatus, nil
 }
 
+// CheckIfCommentsLikedBy 以 BatchGetItem 檢查 USER#{user_id} / LIKEDCOMMENT#{comment_id} 是否存在
+func (r *DynamoDBPostRepository) CheckIfCommentsLikedBy(ctx context.Context, commentIDs []string, userID string) (map[string]bool, error) {
+	likedStatus := make(map[string]bool, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return likedStatus, nil
+	}
+
+	keys := make([]map[string]types.AttributeValue, 0, len(commentIDs))
+	for _, commentID := range uniqueCommentIDs(commentIDs) {
+		likedStatus[commentID] = false
+		keys = append(keys, map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "LIKEDCOMMENT#" + commentID},
+		})
+	}
+
+	// BatchGetItem 每次最多查詢 100 個項目
+	chunkSize := 100
+	for i := 0; i < len(keys); i += chunkSize {
+		end := i + chunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		items, err := batchGetWithRetry(ctx, r.client, r.tableName, keys[i:end], "SK")
+		if err != nil {
+			log.Printf("BatchGetItem failed for checking comment likes: %v", err)
+			return nil, err
+		}
+		for _, itemMap := range items {
+			var like struct {
+				SK string `dynamodbav:"SK"`
+			}
+			if err := attributevalue.UnmarshalMap(itemMap, &like); err == nil {
+				likedStatus[strings.TrimPrefix(like.SK, "LIKEDCOMMENT#")] = true
+			}
+		}
+	}
+	return likedStatus, nil
+}
+
+// uniqueCommentIDs 去除重複的評論 ID (BatchGetItem 不允許重複的鍵)
+func uniqueCommentIDs(commentIDs []string) []string {
+	seen := make(map[string]bool, len(commentIDs))
+	unique := make([]string, 0, len(commentIDs))
+	for _, id := range commentIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
 func (r *DynamoDBPostRepository) AddLike(ctx context.Context, post *models.Post, userID string) error {
 	like := models.Like{
 		PK:         "POST#" + post.PostID,
@@ -244,41 +376,42 @@ func (r *DynamoDBPostRepository) RemoveLike(ctx context.Context, post *models.Po
 	return nil
 }
 
-// --- FIX: CreateComment now uses the passed-in post's PK and SK ---
-func (r *DynamoDBPostRepository) CreateComment(ctx context.Context, post *models.Post, comment *models.Comment) error {
-	now := time.Now().UTC()
-	commentID := uuid.New().String()
-	timestamp := now.Format(time.RFC3339Nano)
-
-	comment.PK = "POST#" + post.PostID
-	comment.SK = fmt.Sprintf("COMMENT#%s#%s", timestamp, commentID)
-	comment.CommentID = commentID
-	comment.EntityType = "COMMENT"
-	comment.CreatedAt = timestamp
-
-	commentItem, err := attributevalue.MarshalMap(comment)
-	if err != nil {
-		return err
+// AddCommentLike 寫入 LIKEDCOMMENT 項目並增加評論的 like_count，已刪除的評論不能按讚
+func (r *DynamoDBPostRepository) AddCommentLike(ctx context.Context, comment *models.Comment, userID string) error {
+	like := models.CommentLike{
+		PK:            "USER#" + userID,
+		SK:            "LIKEDCOMMENT#" + comment.CommentID,
+		GSI2PK:        "COMMENT#" + comment.CommentID,
+		GSI2SK:        "USER#" + userID,
+		EntityType:    "LIKED_COMMENT",
+		LikedEntityID: comment.CommentID,
+		LikerUserID:   userID,
+		PostID:        comment.PostID,
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339Nano),
 	}
-
-	postKey, err := attributevalue.MarshalMap(map[string]string{"PK": post.PK, "SK": post.SK})
+	likeItem, err := attributevalue.MarshalMap(like)
 	if err != nil {
-		return fmt.Errorf("failed to marshal post key for comment: %w", err)
+		return fmt.Errorf("failed to marshal comment like: %w", err)
 	}
 
 	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
 		TransactItems: []types.TransactWriteItem{
 			{
 				Put: &types.Put{
-					TableName: aws.String(r.tableName),
-					Item:      commentItem,
+					TableName:           aws.String(r.tableName),
+					Item:                likeItem,
+					ConditionExpression: aws.String("attribute_not_exists(PK)"), // Prevents duplicate likes
 				},
 			},
 			{
 				Update: &types.Update{
-					TableName:        aws.String(r.tableName),
-					Key:              postKey,
-					UpdateExpression: aws.String("ADD comment_count :inc"),
+					TableName: aws.String(r.tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: comment.PK},
+						"SK": &types.AttributeValueMemberS{Value: comment.SK},
+					},
+					UpdateExpression:    aws.String("ADD like_count :inc"),
+					ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(deleted)"),
 					ExpressionAttributeValues: map[string]types.AttributeValue{
 						":inc": &types.AttributeValueMemberN{Value: "1"},
 					},
@@ -288,38 +421,42 @@ func (r *DynamoDBPostRepository) CreateComment(ctx context.Context, post *models
 	})
 
 	if err != nil {
-		log.Printf("Error in CreateComment transaction: %v", err)
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			if conditionFailedAt(canceled, 1) {
+				return errors.New("comment not found")
+			}
+			return errors.New("transaction failed, possibly already liked")
+		}
+		log.Printf("Error in AddCommentLike transaction: %v", err)
 		return err
 	}
 	return nil
 }
 
-// --- FIX: DeleteComment now uses the passed-in post's PK and SK ---
-func (r *DynamoDBPostRepository) DeleteComment(ctx context.Context, post *models.Post, commentSK string) error {
-	commentPK := "POST#" + post.PostID
-
-	postKey, err := attributevalue.MarshalMap(map[string]string{"PK": post.PK, "SK": post.SK})
-	if err != nil {
-		return fmt.Errorf("failed to marshal post key for delete comment: %w", err)
-	}
-
-	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+// RemoveCommentLike 刪除 LIKEDCOMMENT 項目並減少評論的 like_count
+func (r *DynamoDBPostRepository) RemoveCommentLike(ctx context.Context, comment *models.Comment, userID string) error {
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
 		TransactItems: []types.TransactWriteItem{
 			{
 				Delete: &types.Delete{
 					TableName: aws.String(r.tableName),
 					Key: map[string]types.AttributeValue{
-						"PK": &types.AttributeValueMemberS{Value: commentPK},
-						"SK": &types.AttributeValueMemberS{Value: commentSK},
+						"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+						"SK": &types.AttributeValueMemberS{Value: "LIKEDCOMMENT#" + comment.CommentID},
 					},
+					ConditionExpression: aws.String("attribute_exists(PK)"), // Ensure the like exists
 				},
 			},
 			{
 				Update: &types.Update{
-					TableName:           aws.String(r.tableName),
-					Key:                 postKey,
-					UpdateExpression:    aws.String("ADD comment_count :dec"),
-					ConditionExpression: aws.String("comment_count > :zero"),
+					TableName: aws.String(r.tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: comment.PK},
+						"SK": &types.AttributeValueMemberS{Value: comment.SK},
+					},
+					UpdateExpression:    aws.String("ADD like_count :dec"),
+					ConditionExpression: aws.String("like_count > :zero"), // Prevent negative counts
 					ExpressionAttributeValues: map[string]types.AttributeValue{
 						":dec":  &types.AttributeValueMemberN{Value: "-1"},
 						":zero": &types.AttributeValueMemberN{Value: "0"},
@@ -330,165 +467,1449 @@ func (r *DynamoDBPostRepository) DeleteComment(ctx context.Context, post *models
 	})
 
 	if err != nil {
-		log.Printf("Error in DeleteComment transaction: %v", err)
+		if _, ok := err.(*types.TransactionCanceledException); ok {
+			return errors.New("transaction failed, possibly not liked yet or count is zero")
+		}
+		log.Printf("Error in RemoveCommentLike transaction: %v", err)
 		return err
 	}
 	return nil
 }
 
-// GetCommentBySK gets a comment by its full primary key (PK and SK)
-func (r *DynamoDBPostRepository) GetCommentBySK(ctx context.Context, postID, commentSK string) (*models.Comment, error) {
-	pk := "POST#" + postID
-	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
-		TableName: aws.String(r.tableName),
-		Key: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{Value: pk},
-			"SK": &types.AttributeValueMemberS{Value: commentSK},
-		},
-	})
-	if err != nil {
-		return nil, err
-	}
-	if result.Item == nil {
-		return nil, errors.New("comment not found")
-	}
-	var comment models.Comment
-	if err := attributevalue.UnmarshalMap(result.Item, &comment); err != nil {
-		return nil, err
-	}
-	return &comment, nil
-}
-
-// CreatePost 將新貼文儲存到 DynamoDB
-func (r *DynamoDBPostRepository) CreatePost(ctx context.Context, post *models.Post) error {
-	// 補全必要欄位
+// CreateComment 在同一個交易中寫入評論並增加貼文的 comment_count；
+// parent 不為 nil 時寫入回覆，並同時增加父評論的 reply_count (父評論已被刪除時交易會失敗)
+func (r *DynamoDBPostRepository) CreateComment(ctx context.Context, post *models.Post, parent *models.Comment, comment *models.Comment) error {
 	now := time.Now().UTC()
-	postID := uuid.New().String()
+	commentID := uuid.New().String()
 	timestamp := now.Format(time.RFC3339Nano)
 
-	post.PostID = postID
-	post.CreatedAt = timestamp
-	post.UpdatedAt = timestamp
-	post.PK = "USER#" + post.AuthorID
-	post.SK = "POST#" + timestamp + "#" + postID
-	post.GSI1PK = "POST#" + postID
-	post.GSI1SK = "METADATA"
-	post.EntityType = "POST"
+	comment.PK = "POST#" + post.PostID
+	comment.SK = fmt.Sprintf("COMMENT#%s#%s", timestamp, commentID)
+	if parent != nil {
+		comment.ParentCommentID = parent.CommentID
+		comment.SK = fmt.Sprintf("REPLY#%s#%s#%s", parent.CommentID, timestamp, commentID)
+	}
+	comment.GSI1PK = "COMMENT#" + commentID
+	comment.GSI1SK = "METADATA"
+	comment.CommentID = commentID
+	comment.EntityType = "COMMENT"
+	comment.CreatedAt = timestamp
 
-	item, err := attributevalue.MarshalMap(post)
+	commentItem, err := attributevalue.MarshalMap(comment)
 	if err != nil {
-		log.Printf("Error marshalling post for CreatePost: %v", err)
 		return err
 	}
 
-	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      item,
+	postKey, err := attributevalue.MarshalMap(map[string]string{"PK": post.PK, "SK": post.SK})
+	if err != nil {
+		return fmt.Errorf("failed to marshal post key for comment: %w", err)
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      commentItem,
+			},
+		},
+		{
+			Update: &types.Update{
+				TableName:        aws.String(r.tableName),
+				Key:              postKey,
+				UpdateExpression: aws.String("ADD comment_count :inc"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":inc": &types.AttributeValueMemberN{Value: "1"},
+				},
+			},
+		},
+	}
+	if parent != nil {
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: parent.PK},
+					"SK": &types.AttributeValueMemberS{Value: parent.SK},
+				},
+				UpdateExpression:    aws.String("ADD reply_count :inc"),
+				ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(deleted)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":inc": &types.AttributeValueMemberN{Value: "1"},
+				},
+			},
+		})
+	}
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
 	})
+
 	if err != nil {
-		log.Printf("Error putting item to DynamoDB for CreatePost: %v", err)
+		var canceled *types.TransactionCanceledException
+		if parent != nil && errors.As(err, &canceled) {
+			return errors.New("parent comment not found")
+		}
+		log.Printf("Error in CreateComment transaction: %v", err)
 		return err
 	}
 	return nil
 }
 
-// GetPostsByAuthorID 透過 PK 查詢作者的所有貼文
-func (r *DynamoDBPostRepository) GetPostsByUserID(ctx context.Context, userID string) ([]models.Post, error) {
-	pk := "USER#" + userID
-	queryInput := &dynamodb.QueryInput{
+// DeleteComment 刪除評論並減少貼文的 comment_count。
+// 仍有回覆的頂層評論不會被移除，而是清空內容並標記為 deleted，讓回覆留在原處；
+// 刪除回覆時會同時減少父評論的 reply_count，若父評論已被刪除且沒有剩餘回覆，就一併移除
+func (r *DynamoDBPostRepository) DeleteComment(ctx context.Context, post *models.Post, comment *models.Comment) error {
+	if err := r.removeComment(ctx, post, comment); err != nil {
+		return err
+	}
+	// 不論是刪除或保留佔位，編輯紀錄中的舊內容都不應再被讀到
+	if comment.EditCount > 0 {
+		r.deleteCommentEdits(ctx, comment.PostID, comment.CommentID)
+	}
+	// 按讚紀錄存放在按讚者的分割區，不會隨評論一起刪除；失敗時留給貼文清理工作補刪
+	if _, err := r.deleteCommentLikes(ctx, comment.CommentID); err != nil {
+		log.Printf("Failed to delete likes of deleted comment %s: %v", comment.CommentID, err)
+	}
+	return nil
+}
+
+// deleteCommentLikes 透過 GSI2 (COMMENT#{comment_id}) 找出評論的所有 LIKEDCOMMENT 項目並刪除，回傳刪除的筆數
+func (r *DynamoDBPostRepository) deleteCommentLikes(ctx context.Context, commentID string) (int, error) {
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
-		KeyConditionExpression: aws.String("PK = :pkval AND begins_with(SK, :skprefix)"),
+		IndexName:              aws.String("GSI2"),
+		KeyConditionExpression: aws.String("GSI2PK = :gsi2pk"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pkval":    &types.AttributeValueMemberS{Value: pk},
-			":skprefix": &types.AttributeValueMemberS{Value: "POST#"},
+			":gsi2pk": &types.AttributeValueMemberS{Value: "COMMENT#" + commentID},
 		},
-		ScanIndexForward: aws.Bool(false), // 最新貼文在前
+		ProjectionExpression: aws.String("PK, SK"),
+	})
+
+	deleted := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to query likes of comment %s: %w", commentID, err)
+		}
+		for i := 0; i < len(page.Items); i += 25 {
+			end := i + 25
+			if end > len(page.Items) {
+				end = len(page.Items)
+			}
+			requests := make([]types.WriteRequest, 0, end-i)
+			for _, item := range page.Items[i:end] {
+				requests = append(requests, types.WriteRequest{
+					DeleteRequest: &types.DeleteRequest{
+						Key: map[string]types.AttributeValue{"PK": item["PK"], "SK": item["SK"]},
+					},
+				})
+			}
+			if err := batchWriteWithRetry(ctx, r.client, r.tableName, requests); err != nil {
+				return deleted, err
+			}
+			deleted += len(requests)
+		}
 	}
+	return deleted, nil
+}
 
-	result, err := r.client.Query(ctx, queryInput)
+func (r *DynamoDBPostRepository) removeComment(ctx context.Context, post *models.Post, comment *models.Comment) error {
+	postKey, err := attributevalue.MarshalMap(map[string]string{"PK": post.PK, "SK": post.SK})
 	if err != nil {
-		log.Printf("DynamoDB Query failed for GetPostsByAuthorID PK %s: %v", pk, err)
-		return nil, err
+		return fmt.Errorf("failed to marshal post key for delete comment: %w", err)
 	}
-
-	var posts []models.Post
-	if err := attributevalue.UnmarshalListOfMaps(result.Items, &posts); err != nil {
-		log.Printf("Failed to unmarshal posts for GetPostsByAuthorID PK %s: %v", pk, err)
-		return nil, err
+	commentKey := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: comment.PK},
+		"SK": &types.AttributeValueMemberS{Value: comment.SK},
+	}
+	decrementPost := types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:           aws.String(r.tableName),
+			Key:                 postKey,
+			UpdateExpression:    aws.String("ADD comment_count :dec"),
+			ConditionExpression: aws.String("comment_count > :zero"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":dec":  &types.AttributeValueMemberN{Value: "-1"},
+				":zero": &types.AttributeValueMemberN{Value: "0"},
+			},
+		},
 	}
-	return posts, nil
-}
 
-// UpdatePost 更新貼文內容
-func (r *DynamoDBPostRepository) UpdatePost(ctx context.Context, post *models.Post) error {
-	// 為了更新，我們需要知道完整的 Key (PK, SK)
-	// Service 層應先獲取 post，然後傳遞過來
-	key, err := attributevalue.MarshalMap(map[string]string{
-		"PK": post.PK,
-		"SK": post.SK,
-	})
-	if err != nil {
-		return err
+	if comment.ParentCommentID != "" {
+		return r.deleteReply(ctx, comment, commentKey, decrementPost)
 	}
 
-	updateExpression := "SET content = :c, updatedAt = :u"
-	expressionAttributeValues, err := attributevalue.MarshalMap(map[string]interface{}{
-		":c": post.Content,
-		":u": time.Now().UTC().Format(time.RFC3339Nano),
+	// 沒有回覆時直接刪除；條件失敗代表期間有人回覆，改為保留佔位
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Delete: &types.Delete{
+					TableName:           aws.String(r.tableName),
+					Key:                 commentKey,
+					ConditionExpression: aws.String("attribute_exists(PK) AND (attribute_not_exists(reply_count) OR reply_count = :zero)"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":zero": &types.AttributeValueMemberN{Value: "0"},
+					},
+				},
+			},
+			decrementPost,
+		},
 	})
-	if err != nil {
+	if err == nil {
+		return nil
+	}
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) || !conditionFailedAt(canceled, 0) {
+		log.Printf("Error in DeleteComment transaction: %v", err)
 		return err
 	}
 
-	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
-		TableName:                 aws.String(r.tableName),
-		Key:                       key,
-		UpdateExpression:          aws.String(updateExpression),
-		ExpressionAttributeValues: expressionAttributeValues,
-		ReturnValues:              types.ReturnValueUpdatedNew,
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName:           aws.String(r.tableName),
+					Key:                 commentKey,
+					UpdateExpression:    aws.String("SET deleted = :true, content = :empty, like_count = :zero REMOVE mentions, mention_spans"),
+					ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(deleted)"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":true":  &types.AttributeValueMemberBOOL{Value: true},
+						":empty": &types.AttributeValueMemberS{Value: ""},
+						":zero":  &types.AttributeValueMemberN{Value: "0"},
+					},
+				},
+			},
+			decrementPost,
+		},
 	})
-
 	if err != nil {
-		log.Printf("Error updating post in DynamoDB: %v", err)
+		if errors.As(err, &canceled) {
+			return errors.New("comment not found")
+		}
+		log.Printf("Error soft-deleting comment %s: %v", comment.CommentID, err)
 		return err
 	}
-
 	return nil
 }
 
-// DeletePost 刪除貼文
-func (r *DynamoDBPostRepository) DeletePost(ctx context.Context, authorID, postID, createdAt string) error {
-	// 為了刪除，我們需要重建 SK
-	// 注意：這種方法要求 createdAt 的格式必須與儲存時完全一致
-	pk := "USER#" + authorID
-	// SK 的重建依賴於 CreatedAt 和 PostID
-	// 這是一個潛在的脆弱點，如果 SK 的生成邏輯改變，這裡也要改
-	sk := fmt.Sprintf("POST#%s#%s", createdAt, postID)
-
-	key, err := attributevalue.MarshalMap(map[string]string{
-		"PK": pk,
-		"SK": sk,
-	})
+// deleteReply 刪除回覆並減少父評論的 reply_count
+func (r *DynamoDBPostRepository) deleteReply(ctx context.Context, reply *models.Comment, replyKey map[string]types.AttributeValue, decrementPost types.TransactWriteItem) error {
+	parent, err := r.GetCommentByID(ctx, reply.PostID, reply.ParentCommentID)
 	if err != nil {
-		log.Printf("Error marshalling key for DeletePost: %v", err)
 		return err
 	}
-
-	_, err = r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		TableName: aws.String(r.tableName),
-		Key:       key,
-	})
-
-	if err != nil {
-		log.Printf("Error deleting post from DynamoDB: %v", err)
-		return err
+	parentKey := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: parent.PK},
+		"SK": &types.AttributeValueMemberS{Value: parent.SK},
 	}
-	return nil
-}
 
-// GetPostByID 透過 GSI 查詢單一貼文
-func (r *DynamoDBPostRepository) GetPostByID(ctx context.Context, postID string) (*models.Post, error) {
-	queryInput := &dynamodb.QueryInput{
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Delete: &types.Delete{
+					TableName:           aws.String(r.tableName),
+					Key:                 replyKey,
+					ConditionExpression: aws.String("attribute_exists(PK)"),
+				},
+			},
+			decrementPost,
+			{
+				Update: &types.Update{
+					TableName:           aws.String(r.tableName),
+					Key:                 parentKey,
+					UpdateExpression:    aws.String("ADD reply_count :dec"),
+					ConditionExpression: aws.String("reply_count > :zero"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":dec":  &types.AttributeValueMemberN{Value: "-1"},
+						":zero": &types.AttributeValueMemberN{Value: "0"},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			return errors.New("comment not found")
+		}
+		log.Printf("Error in DeleteComment transaction for reply %s: %v", reply.CommentID, err)
+		return err
+	}
+
+	// 最後一則回覆也被刪除後，已刪除的父評論不再需要佔位
+	if parent.Deleted {
+		_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:           aws.String(r.tableName),
+			Key:                 parentKey,
+			ConditionExpression: aws.String("deleted = :true AND reply_count = :zero"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":true": &types.AttributeValueMemberBOOL{Value: true},
+				":zero": &types.AttributeValueMemberN{Value: "0"},
+			},
+		})
+		var condErr *types.ConditionalCheckFailedException
+		if err != nil && !errors.As(err, &condErr) {
+			log.Printf("Failed to remove deleted parent comment %s: %v", parent.CommentID, err)
+		}
+	}
+	return nil
+}
+
+// conditionFailedAt 判斷交易中第 index 個項目是否因條件不成立而取消
+func conditionFailedAt(canceled *types.TransactionCanceledException, index int) bool {
+	if index >= len(canceled.CancellationReasons) {
+		return false
+	}
+	return aws.ToString(canceled.CancellationReasons[index].Code) == "ConditionalCheckFailed"
+}
+
+// UpdateComment 在同一個交易中寫入編輯紀錄並更新評論，以 edit_count 做樂觀鎖
+func (r *DynamoDBPostRepository) UpdateComment(ctx context.Context, previous *models.Comment, comment *models.Comment) error {
+	writtenAt := previous.EditedAt
+	if writtenAt == "" {
+		writtenAt = previous.CreatedAt
+	}
+	edit := models.CommentEdit{
+		PK:           previous.PK,
+		SK:           commentEditSK(previous.CommentID, previous.EditCount+1),
+		EntityType:   "COMMENT_EDIT",
+		CommentID:    previous.CommentID,
+		PostID:       previous.PostID,
+		Version:      previous.EditCount + 1,
+		Content:      previous.Content,
+		MentionSpans: previous.MentionSpans,
+		WrittenAt:    writtenAt,
+		ReplacedAt:   comment.EditedAt,
+	}
+	editItem, err := attributevalue.MarshalMap(edit)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment edit: %w", err)
+	}
+
+	setClauses := []string{"content = :c", "edited_at = :e", "edit_count = :n"}
+	var removeClauses []string
+	values := map[string]interface{}{
+		":c": comment.Content,
+		":e": comment.EditedAt,
+		":n": comment.EditCount,
+	}
+	if len(comment.Mentions) > 0 {
+		setClauses = append(setClauses, "mentions = :m", "mention_spans = :ms")
+		values[":m"] = comment.Mentions
+		values[":ms"] = comment.MentionSpans
+	} else {
+		removeClauses = append(removeClauses, "mentions", "mention_spans")
+	}
+	updateExpression := "SET " + strings.Join(setClauses, ", ")
+	if len(removeClauses) > 0 {
+		updateExpression += " REMOVE " + strings.Join(removeClauses, ", ")
+	}
+
+	condition := "attribute_exists(PK) AND attribute_not_exists(deleted) AND attribute_not_exists(edit_count)"
+	if previous.EditCount > 0 {
+		condition = "attribute_exists(PK) AND attribute_not_exists(deleted) AND edit_count = :prev"
+		values[":prev"] = previous.EditCount
+	}
+	expressionValues, err := attributevalue.MarshalMap(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comment update values: %w", err)
+	}
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName:           aws.String(r.tableName),
+					Item:                editItem,
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName: aws.String(r.tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: previous.PK},
+						"SK": &types.AttributeValueMemberS{Value: previous.SK},
+					},
+					UpdateExpression:          aws.String(updateExpression),
+					ConditionExpression:       aws.String(condition),
+					ExpressionAttributeValues: expressionValues,
+				},
+			},
+		},
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			return ErrCommentEditConflict
+		}
+		log.Printf("Error in UpdateComment transaction for comment %s: %v", previous.CommentID, err)
+		return err
+	}
+	return nil
+}
+
+// ListCommentEdits 查詢 COMMENTEDIT#{comment_id}# 開頭的項目；一則評論的編輯次數不多，一次讀完
+func (r *DynamoDBPostRepository) ListCommentEdits(ctx context.Context, postID, commentID string) ([]models.CommentEdit, error) {
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: "POST#" + postID},
+			":prefix": &types.AttributeValueMemberS{Value: "COMMENTEDIT#" + commentID + "#"},
+		},
+	})
+
+	var edits []models.CommentEdit
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("Error querying edit history of comment %s: %v", commentID, err)
+			return nil, err
+		}
+		var items []models.CommentEdit
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		edits = append(edits, items...)
+	}
+	return edits, nil
+}
+
+// deleteCommentEdits 刪除評論的編輯紀錄，避免刪除後仍能從歷史中讀到內容；失敗只記錄
+func (r *DynamoDBPostRepository) deleteCommentEdits(ctx context.Context, postID, commentID string) {
+	edits, err := r.ListCommentEdits(ctx, postID, commentID)
+	if err != nil {
+		log.Printf("Failed to list edit history of deleted comment %s: %v", commentID, err)
+		return
+	}
+	var requests []types.WriteRequest
+	for _, edit := range edits {
+		requests = append(requests, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: edit.PK},
+					"SK": &types.AttributeValueMemberS{Value: edit.SK},
+				},
+			},
+		})
+	}
+	for i := 0; i < len(requests); i += 25 {
+		end := i + 25
+		if end > len(requests) {
+			end = len(requests)
+		}
+		if err := batchWriteWithRetry(ctx, r.client, r.tableName, requests[i:end]); err != nil {
+			log.Printf("Failed to delete edit history of comment %s: %v", commentID, err)
+			return
+		}
+	}
+}
+
+// commentEditSK 產生編輯紀錄的 SK，版本補零讓 SK 的字典順序等於版本順序
+func commentEditSK(commentID string, version int) string {
+	return fmt.Sprintf("COMMENTEDIT#%s#%06d", commentID, version)
+}
+
+// isCommentSK 判斷 SK 是否屬於評論或回覆；POST#{post_id} 分割區中還有按讚、轉發、版本等其他項目
+func isCommentSK(sk string) bool {
+	return strings.HasPrefix(sk, "COMMENT#") || strings.HasPrefix(sk, "REPLY#")
+}
+
+// GetCommentBySK gets a comment by its full primary key (PK and SK)
+// 只接受評論與回覆的 SK，其他項目 (按讚、編輯紀錄等) 一律視為找不到評論
+func (r *DynamoDBPostRepository) GetCommentBySK(ctx context.Context, postID, commentSK string) (*models.Comment, error) {
+	if !isCommentSK(commentSK) {
+		return nil, errors.New("comment not found")
+	}
+	pk := "POST#" + postID
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: pk},
+			"SK": &types.AttributeValueMemberS{Value: commentSK},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, errors.New("comment not found")
+	}
+	var comment models.Comment
+	if err := attributevalue.UnmarshalMap(result.Item, &comment); err != nil {
+		return nil, err
+	}
+	if comment.EntityType != "COMMENT" || comment.CommentID == "" {
+		return nil, errors.New("comment not found")
+	}
+	return &comment, nil
+}
+
+// GetCommentByID 透過 GSI1 (COMMENT#{comment_id}) 查詢評論；
+// 早期建立的評論沒有 GSI1 鍵，因此找不到時改為在貼文分割區中以 comment_id 過濾
+func (r *DynamoDBPostRepository) GetCommentByID(ctx context.Context, postID, commentID string) (*models.Comment, error) {
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :gsi1pkval AND GSI1SK = :gsi1skval"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pkval": &types.AttributeValueMemberS{Value: "COMMENT#" + commentID},
+			":gsi1skval": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+	})
+	if err != nil {
+		log.Printf("Error querying GSI1 for comment %s: %v", commentID, err)
+		return nil, err
+	}
+	items := result.Items
+
+	if len(items) == 0 {
+		paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+			FilterExpression:       aws.String("comment_id = :cid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":     &types.AttributeValueMemberS{Value: "POST#" + postID},
+				":prefix": &types.AttributeValueMemberS{Value: "COMMENT#"},
+				":cid":    &types.AttributeValueMemberS{Value: commentID},
+			},
+		})
+		for paginator.HasMorePages() && len(items) == 0 {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				log.Printf("Error scanning comments of post %s for comment %s: %v", postID, commentID, err)
+				return nil, err
+			}
+			items = page.Items
+		}
+	}
+	if len(items) == 0 {
+		return nil, errors.New("comment not found")
+	}
+
+	var comment models.Comment
+	if err := attributevalue.UnmarshalMap(items[0], &comment); err != nil {
+		return nil, err
+	}
+	if comment.PostID != postID {
+		return nil, errors.New("comment not found")
+	}
+	return &comment, nil
+}
+
+// ListComments 分頁列出貼文的頂層評論 (SK 以 COMMENT#{timestamp} 開頭，因此 SK 順序即時間順序)
+func (r *DynamoDBPostRepository) ListComments(ctx context.Context, postID string, newestFirst bool, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedComments, error) {
+	return r.queryComments(ctx, postID, "COMMENT#", !newestFirst, limit, lastEvaluatedKey)
+}
+
+// ListReplies 依時間由舊到新分頁列出某則評論的回覆 (SK 以 REPLY#{parent_comment_id}# 開頭)
+func (r *DynamoDBPostRepository) ListReplies(ctx context.Context, postID, parentCommentID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedComments, error) {
+	return r.queryComments(ctx, postID, "REPLY#"+parentCommentID+"#", true, limit, lastEvaluatedKey)
+}
+
+func (r *DynamoDBPostRepository) queryComments(ctx context.Context, postID, skPrefix string, ascending bool, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedComments, error) {
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: "POST#" + postID},
+			":prefix": &types.AttributeValueMemberS{Value: skPrefix},
+		},
+		ScanIndexForward:  aws.Bool(ascending),
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: lastEvaluatedKey,
+	})
+	if err != nil {
+		log.Printf("Error querying comments (%s) of post %s: %v", skPrefix, postID, err)
+		return nil, err
+	}
+
+	var comments []models.Comment
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &comments); err != nil {
+		return nil, err
+	}
+	return &models.PaginatedComments{
+		Items:            comments,
+		LastEvaluatedKey: result.LastEvaluatedKey,
+	}, nil
+}
+
+// CreatePost 將新貼文儲存到 DynamoDB，並在同一個交易中寫入 hashtag 索引
+func (r *DynamoDBPostRepository) CreatePost(ctx context.Context, post *models.Post) error {
+	post.EntityType = models.PostEntityPost
+	transactItems, err := r.newPostPuts(post)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		log.Printf("Error writing post and tag index to DynamoDB for CreatePost: %v", err)
+		return err
+	}
+	return nil
+}
+
+// CreateRepost 在同一個交易中寫入轉發 / 引用、遞增原始貼文的 repost_count，單純轉發另外寫入 RepostLink
+func (r *DynamoDBPostRepository) CreateRepost(ctx context.Context, original *models.Post, repost *models.Post) error {
+	repost.OriginalPostID = original.PostID
+	repost.OriginalPK = original.PK
+	repost.OriginalSK = original.SK
+	transactItems, err := r.newPostPuts(repost)
+	if err != nil {
+		return err
+	}
+
+	originalIndex := len(transactItems)
+	transactItems = append(transactItems, types.TransactWriteItem{
+		Update: &types.Update{
+			TableName: aws.String(r.tableName),
+			Key: map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: original.PK},
+				"SK": &types.AttributeValueMemberS{Value: original.SK},
+			},
+			UpdateExpression:    aws.String("ADD repost_count :inc"),
+			ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(deleted_at)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":inc": &types.AttributeValueMemberN{Value: "1"},
+			},
+		},
+	})
+
+	if repost.IsRepost() {
+		link := models.RepostLink{
+			PK:           "POST#" + original.PostID,
+			SK:           "REPOST#" + repost.AuthorID,
+			EntityType:   "REPOST_LINK",
+			PostID:       original.PostID,
+			UserID:       repost.AuthorID,
+			RepostPostID: repost.PostID,
+			CreatedAt:    repost.CreatedAt,
+		}
+		linkItem, err := attributevalue.MarshalMap(link)
+		if err != nil {
+			return fmt.Errorf("failed to marshal repost link: %w", err)
+		}
+		transactItems = append(transactItems, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName:           aws.String(r.tableName),
+				Item:                linkItem,
+				ConditionExpression: aws.String("attribute_not_exists(PK)"), // 每位使用者只能轉發一次
+			},
+		})
+	}
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			if conditionFailedAt(canceled, originalIndex) {
+				return ErrPostNotFound
+			}
+			if conditionFailedAt(canceled, originalIndex+1) {
+				return ErrAlreadyReposted
+			}
+		}
+		log.Printf("Error in CreateRepost transaction for post %s: %v", original.PostID, err)
+		return err
+	}
+	return nil
+}
+
+// newPostPuts 補全貼文的 ID、時間與鍵，回傳寫入貼文與 hashtag 索引的交易項目；post.EntityType 需由呼叫端設定
+func (r *DynamoDBPostRepository) newPostPuts(post *models.Post) ([]types.TransactWriteItem, error) {
+	now := time.Now().UTC()
+	postID := uuid.New().String()
+	timestamp := now.Format(time.RFC3339Nano)
+
+	post.PostID = postID
+	post.CreatedAt = timestamp
+	post.UpdatedAt = timestamp
+	post.PK = "USER#" + post.AuthorID
+	post.SK = "POST#" + timestamp + "#" + postID
+	post.GSI1PK = "POST#" + postID
+	post.GSI1SK = "METADATA"
+
+	item, err := attributevalue.MarshalMap(post)
+	if err != nil {
+		log.Printf("Error marshalling post %s: %v", postID, err)
+		return nil, err
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      item,
+			},
+		},
+	}
+	tagPuts, err := r.tagIndexPuts(post, post.Tags)
+	if err != nil {
+		return nil, err
+	}
+	return append(transactItems, tagPuts...), nil
+}
+
+// GetRepostLink 讀取 POST#{original_post_id} / REPOST#{user_id}
+func (r *DynamoDBPostRepository) GetRepostLink(ctx context.Context, originalPostID, userID string) (*models.RepostLink, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "POST#" + originalPostID},
+			"SK": &types.AttributeValueMemberS{Value: "REPOST#" + userID},
+		},
+	})
+	if err != nil {
+		log.Printf("Error getting repost link for post %s by user %s: %v", originalPostID, userID, err)
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, errors.New("repost not found")
+	}
+
+	var link models.RepostLink
+	if err := attributevalue.UnmarshalMap(result.Item, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListRepostLinks 查詢 POST#{original_post_id} 分割區中所有 REPOST# 開頭的項目
+func (r *DynamoDBPostRepository) ListRepostLinks(ctx context.Context, originalPostID string) ([]models.RepostLink, error) {
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: "POST#" + originalPostID},
+			":prefix": &types.AttributeValueMemberS{Value: "REPOST#"},
+		},
+	})
+
+	var links []models.RepostLink
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("Error listing repost links for post %s: %v", originalPostID, err)
+			return nil, err
+		}
+		var items []models.RepostLink
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		links = append(links, items...)
+	}
+	return links, nil
+}
+
+// GetPostsByAuthorID 透過 PK 查詢作者的所有貼文
+func (r *DynamoDBPostRepository) GetPostsByUserID(ctx context.Context, userID string) ([]models.Post, error) {
+	pk := "USER#" + userID
+	// 逐頁讀取，避免超過單次 Query 1 MB 的上限時結果被截斷
+	paginator := dynamodb.NewQueryPaginator(r.client, r.authorPostsQuery(userID, 0, nil))
+
+	var posts []models.Post
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("DynamoDB Query failed for GetPostsByAuthorID PK %s: %v", pk, err)
+			return nil, err
+		}
+		var items []models.Post
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			log.Printf("Failed to unmarshal posts for GetPostsByAuthorID PK %s: %v", pk, err)
+			return nil, err
+		}
+		posts = append(posts, items...)
+	}
+	return posts, nil
+}
+
+// GetPostsPageByUserID 讀取作者貼文的其中一頁
+func (r *DynamoDBPostRepository) GetPostsPageByUserID(ctx context.Context, userID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedPosts, error) {
+	result, err := r.client.Query(ctx, r.authorPostsQuery(userID, limit, lastEvaluatedKey))
+	if err != nil {
+		log.Printf("DynamoDB Query failed for GetPostsPageByUserID %s: %v", userID, err)
+		return nil, err
+	}
+
+	var posts []models.Post
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &posts); err != nil {
+		log.Printf("Failed to unmarshal posts for GetPostsPageByUserID %s: %v", userID, err)
+		return nil, err
+	}
+	return &models.PaginatedPosts{
+		Items:            posts,
+		LastEvaluatedKey: result.LastEvaluatedKey,
+	}, nil
+}
+
+// authorPostsQuery 建立查詢作者貼文 (PK = USER#{user_id}, SK 以 POST# 開頭) 的輸入，最新貼文在前；limit 為 0 代表不限制
+func (r *DynamoDBPostRepository) authorPostsQuery(userID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) *dynamodb.QueryInput {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pkval AND begins_with(SK, :skprefix)"),
+		FilterExpression:       aws.String("attribute_not_exists(deleted_at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pkval":    &types.AttributeValueMemberS{Value: "USER#" + userID},
+			":skprefix": &types.AttributeValueMemberS{Value: "POST#"},
+		},
+		ScanIndexForward:  aws.Bool(false), // 最新貼文在前
+		ExclusiveStartKey: lastEvaluatedKey,
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+	return input
+}
+
+// UpdatePost 更新貼文的內容、媒體、地點與標籤，並在同一個交易中同步 hashtag 索引、
+// 寫入編輯前的版本 (POST#{post_id} / VERSION#{n})。post.Version 必須是讀取時的版本，
+// 期間貼文已被其他請求編輯或刪除時回傳 ErrPostEditConflict
+func (r *DynamoDBPostRepository) UpdatePost(ctx context.Context, post *models.Post) error {
+	// 為了更新，我們需要知道完整的 Key (PK, SK)
+	// Service 層應先獲取 post，然後傳遞過來
+	key, err := attributevalue.MarshalMap(map[string]string{
+		"PK": post.PK,
+		"SK": post.SK,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 讀取目前儲存的版本，才能知道哪些標籤索引需要新增或移除，並作為編輯紀錄的內容
+	stored, err := r.getPostByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	if stored.Version != post.Version || stored.DeletedAt != "" {
+		return ErrPostEditConflict
+	}
+
+	post.UpdatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	versionItem, err := attributevalue.MarshalMap(models.PostVersion{
+		PK:           "POST#" + stored.PostID,
+		SK:           postVersionSK(stored.Version + 1),
+		EntityType:   "POST_VERSION",
+		PostID:       stored.PostID,
+		Version:      stored.Version + 1,
+		Content:      stored.Content,
+		Media:        stored.Media,
+		Tags:         stored.Tags,
+		Location:     stored.Location,
+		MentionSpans: stored.MentionSpans,
+		Visibility:   stored.Visibility,
+		WrittenAt:    stored.UpdatedAt,
+		ReplacedAt:   post.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal post version: %w", err)
+	}
+
+	setClauses := []string{"content = :c", "updated_at = :u", "#ver = :v"}
+	var removeClauses []string
+	values := map[string]interface{}{
+		":c": post.Content,
+		":u": post.UpdatedAt,
+		":v": stored.Version + 1,
+	}
+	// DynamoDB 的 String Set 不能為空集合，沒有標籤時直接移除屬性
+	if len(post.Tags) > 0 {
+		setClauses = append(setClauses, "tags = :t")
+	} else {
+		removeClauses = append(removeClauses, "tags")
+	}
+	if len(post.Mentions) > 0 {
+		setClauses = append(setClauses, "mentions = :m", "mention_spans = :ms")
+		values[":m"] = post.Mentions
+		values[":ms"] = post.MentionSpans
+	} else {
+		removeClauses = append(removeClauses, "mentions", "mention_spans")
+	}
+	if len(post.Media) > 0 {
+		setClauses = append(setClauses, "media = :md")
+		values[":md"] = post.Media
+	} else {
+		removeClauses = append(removeClauses, "media")
+	}
+	if post.Visibility != "" {
+		setClauses = append(setClauses, "visibility = :vis")
+		values[":vis"] = post.Visibility
+	}
+	if post.Location != nil {
+		setClauses = append(setClauses, "#loc = :loc")
+		values[":loc"] = post.Location
+	} else {
+		removeClauses = append(removeClauses, "#loc")
+	}
+	updateExpression := "SET " + strings.Join(setClauses, ", ")
+	if len(removeClauses) > 0 {
+		updateExpression += " REMOVE " + strings.Join(removeClauses, ", ")
+	}
+
+	condition := "attribute_exists(PK) AND attribute_not_exists(deleted_at) AND attribute_not_exists(#ver)"
+	if stored.Version > 0 {
+		condition = "attribute_exists(PK) AND attribute_not_exists(deleted_at) AND #ver = :prev"
+		values[":prev"] = stored.Version
+	}
+	expressionAttributeValues, err := attributevalue.MarshalMap(values)
+	if err != nil {
+		return err
+	}
+	if len(post.Tags) > 0 {
+		expressionAttributeValues[":t"] = &types.AttributeValueMemberSS{Value: post.Tags}
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName:                 aws.String(r.tableName),
+				Key:                       key,
+				UpdateExpression:          aws.String(updateExpression),
+				ConditionExpression:       aws.String(condition),
+				ExpressionAttributeNames:  map[string]string{"#loc": "location", "#ver": "version"}, // 兩者都是 DynamoDB 的保留字
+				ExpressionAttributeValues: expressionAttributeValues,
+			},
+		},
+		{
+			Put: &types.Put{
+				TableName:           aws.String(r.tableName),
+				Item:                versionItem,
+				ConditionExpression: aws.String("attribute_not_exists(PK)"),
+			},
+		},
+	}
+
+	added, removed := diffTags(stored.Tags, post.Tags)
+	tagPuts, err := r.tagIndexPuts(stored, added)
+	if err != nil {
+		return err
+	}
+	transactItems = append(transactItems, tagPuts...)
+	transactItems = append(transactItems, r.tagIndexDeletes(stored, removed)...)
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && (conditionFailedAt(canceled, 0) || conditionFailedAt(canceled, 1)) {
+			return ErrPostEditConflict
+		}
+		log.Printf("Error updating post in DynamoDB: %v", err)
+		return err
+	}
+
+	post.Version = stored.Version + 1
+	return nil
+}
+
+// ListPostVersions 依版本由舊到新列出貼文的編輯紀錄
+func (r *DynamoDBPostRepository) ListPostVersions(ctx context.Context, postID string) ([]models.PostVersion, error) {
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":     &types.AttributeValueMemberS{Value: "POST#" + postID},
+			":prefix": &types.AttributeValueMemberS{Value: "VERSION#"},
+		},
+	})
+
+	var versions []models.PostVersion
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			log.Printf("Error querying versions of post %s: %v", postID, err)
+			return nil, err
+		}
+		var items []models.PostVersion
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		versions = append(versions, items...)
+	}
+	return versions, nil
+}
+
+// postVersionSK 產生貼文版本的 SK，版本補零讓 SK 的字典順序等於版本順序
+func postVersionSK(version int) string {
+	return fmt.Sprintf("VERSION#%06d", version)
+}
+
+// DeletePost 刪除貼文以及它的 hashtag 索引
+func (r *DynamoDBPostRepository) DeletePost(ctx context.Context, authorID, postID, createdAt string) error {
+	// 為了刪除，我們需要重建 SK
+	// 注意：這種方法要求 createdAt 的格式必須與儲存時完全一致
+	pk := "USER#" + authorID
+	// SK 的重建依賴於 CreatedAt 和 PostID
+	// 這是一個潛在的脆弱點，如果 SK 的生成邏輯改變，這裡也要改
+	sk := fmt.Sprintf("POST#%s#%s", createdAt, postID)
+
+	key, err := attributevalue.MarshalMap(map[string]string{
+		"PK": pk,
+		"SK": sk,
+	})
+	if err != nil {
+		log.Printf("Error marshalling key for DeletePost: %v", err)
+		return err
+	}
+
+	stored, err := r.getPostByKey(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	transactItems := []types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key:       key,
+			},
+		},
+	}
+	transactItems = append(transactItems, r.tagIndexDeletes(stored, stored.Tags)...)
+
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	if err != nil {
+		log.Printf("Error deleting post from DynamoDB: %v", err)
+		return err
+	}
+	return nil
+}
+
+// MarkPostDeleted 在同一個交易中寫入 deleted_at 並刪除標籤索引，讓貼文立即從所有讀取路徑消失
+func (r *DynamoDBPostRepository) MarkPostDeleted(ctx context.Context, post *models.Post) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	transactItems := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: post.PK},
+					"SK": &types.AttributeValueMemberS{Value: post.SK},
+				},
+				UpdateExpression:    aws.String("SET deleted_at = :now"),
+				ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(deleted_at)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":now": &types.AttributeValueMemberS{Value: now},
+				},
+			},
+		},
+	}
+	transactItems = append(transactItems, r.tagIndexDeletes(post, post.Tags)...)
+	originalIndex := len(transactItems)
+	transactItems = append(transactItems, r.repostUnlinkItems(post)...)
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
+	})
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) && len(transactItems) > originalIndex && !conditionFailedAt(canceled, 0) && conditionFailedAt(canceled, originalIndex) {
+		// 原始貼文已被清理，不需要再遞減 repost_count (RepostLink 也隨原始貼文的子項目一起刪除了)
+		transactItems = transactItems[:originalIndex]
+		_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: transactItems,
+		})
+	}
+	if err != nil {
+		if errors.As(err, &canceled) {
+			return ErrPostNotFound
+		}
+		log.Printf("Error marking post %s as deleted: %v", post.PostID, err)
+		return err
+	}
+	post.DeletedAt = now
+	return nil
+}
+
+// repostUnlinkItems 回傳刪除轉發 / 引用時要一併執行的交易項目：
+// 第一項遞減原始貼文的 repost_count (原始貼文不存在時條件失敗)，單純轉發另外刪除 RepostLink
+func (r *DynamoDBPostRepository) repostUnlinkItems(post *models.Post) []types.TransactWriteItem {
+	if post.OriginalPostID == "" || post.OriginalPK == "" {
+		return nil
+	}
+	items := []types.TransactWriteItem{
+		{
+			Update: &types.Update{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: post.OriginalPK},
+					"SK": &types.AttributeValueMemberS{Value: post.OriginalSK},
+				},
+				UpdateExpression:    aws.String("ADD repost_count :dec"),
+				ConditionExpression: aws.String("attribute_exists(PK)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":dec": &types.AttributeValueMemberN{Value: "-1"},
+				},
+			},
+		},
+	}
+	if post.IsRepost() {
+		items = append(items, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: "POST#" + post.OriginalPostID},
+					"SK": &types.AttributeValueMemberS{Value: "REPOST#" + post.AuthorID},
+				},
+			},
+		})
+	}
+	return items
+}
+
+// DeletePostChildren 分頁查詢 POST#{post_id} 分割區並批次刪除
+func (r *DynamoDBPostRepository) DeletePostChildren(ctx context.Context, postID string) (int, error) {
+	deleted := 0
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "POST#" + postID},
+			},
+			ProjectionExpression: aws.String("PK, SK, comment_id"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			log.Printf("DynamoDB Query failed for children of post %s: %v", postID, err)
+			return deleted, fmt.Errorf("failed to query post children: %w", err)
+		}
+
+		// 評論的按讚紀錄存放在按讚者的分割區，必須在刪除評論之前刪除，重試時才找得到評論 ID
+		for _, item := range result.Items {
+			sk, _ := item["SK"].(*types.AttributeValueMemberS)
+			commentID, _ := item["comment_id"].(*types.AttributeValueMemberS)
+			if sk == nil || commentID == nil || !isCommentSK(sk.Value) {
+				continue
+			}
+			n, err := r.deleteCommentLikes(ctx, commentID.Value)
+			deleted += n
+			if err != nil {
+				log.Printf("Error deleting comment likes of post %s: %v", postID, err)
+				return deleted, err
+			}
+		}
+
+		for i := 0; i < len(result.Items); i += 25 {
+			end := i + 25
+			if end > len(result.Items) {
+				end = len(result.Items)
+			}
+			var requests []types.WriteRequest
+			for _, item := range result.Items[i:end] {
+				requests = append(requests, types.WriteRequest{
+					DeleteRequest: &types.DeleteRequest{
+						Key: map[string]types.AttributeValue{"PK": item["PK"], "SK": item["SK"]},
+					},
+				})
+			}
+			output, err := r.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: map[string][]types.WriteRequest{r.tableName: requests},
+			})
+			if err != nil {
+				log.Printf("Error deleting children of post %s: %v", postID, err)
+				return deleted, err
+			}
+			// 未處理的項目會讓清理工作重試，重新查詢時只剩下尚未刪除的項目
+			if unprocessed := len(output.UnprocessedItems[r.tableName]); unprocessed > 0 {
+				return deleted + len(requests) - unprocessed, fmt.Errorf("%d children of post %s were not deleted", unprocessed, postID)
+			}
+			deleted += len(requests)
+		}
+
+		if result.LastEvaluatedKey == nil {
+			return deleted, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// ScanDeletedPosts 掃描整張表找出帶有軟刪除標記的貼文 (僅供對帳指令使用)
+func (r *DynamoDBPostRepository) ScanDeletedPosts(ctx context.Context) ([]models.Post, error) {
+	var posts []models.Post
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:        aws.String(r.tableName),
+			FilterExpression: aws.String("entity_type IN (:post, :repost, :quote) AND attribute_exists(deleted_at)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":post":   &types.AttributeValueMemberS{Value: models.PostEntityPost},
+				":repost": &types.AttributeValueMemberS{Value: models.PostEntityRepost},
+				":quote":  &types.AttributeValueMemberS{Value: models.PostEntityQuote},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			log.Printf("Failed to scan for deleted posts: %v", err)
+			return nil, err
+		}
+		var page []models.Post
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return nil, err
+		}
+		posts = append(posts, page...)
+		if result.LastEvaluatedKey == nil {
+			return posts, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// ScanChildPostIDs 掃描整張表找出所有 POST#{post_id} 分割區 (僅供對帳指令使用)
+func (r *DynamoDBPostRepository) ScanChildPostIDs(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:        aws.String(r.tableName),
+			FilterExpression: aws.String("begins_with(PK, :prefix)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":prefix": &types.AttributeValueMemberS{Value: "POST#"},
+			},
+			ProjectionExpression: aws.String("PK"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			log.Printf("Failed to scan for post children: %v", err)
+			return nil, err
+		}
+		for _, item := range result.Items {
+			pk, ok := item["PK"].(*types.AttributeValueMemberS)
+			if !ok {
+				continue
+			}
+			id := strings.TrimPrefix(pk.Value, "POST#")
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		if result.LastEvaluatedKey == nil {
+			return ids, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// GetPostsByAuthorAfter 查詢作者分割區中 after 之後的貼文，供混合式 Feed 在讀取時拉取高粉絲帳號的貼文
+func (r *DynamoDBPostRepository) GetPostsByAuthorAfter(ctx context.Context, authorID, after string, limit int32) ([]models.Post, error) {
+	// SK 格式為 POST#{created_at}#{post_id}；'~' 排在 '#' 與所有 ID 字元之後，因此下界不包含 created_at 等於 after 的貼文
+	lower := "POST#"
+	if after != "" {
+		lower = "POST#" + after + "#~"
+	}
+
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :lower AND :upper"),
+		FilterExpression:       aws.String("attribute_not_exists(deleted_at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: "USER#" + authorID},
+			":lower": &types.AttributeValueMemberS{Value: lower},
+			":upper": &types.AttributeValueMemberS{Value: "POST#~"},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(limit),
+	})
+	if err != nil {
+		log.Printf("DynamoDB Query failed for posts of author %s after %s: %v", authorID, after, err)
+		return nil, fmt.Errorf("failed to query author posts: %w", err)
+	}
+
+	var posts []models.Post
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// GetPostsByAuthorBefore 是 GetPostsByAuthorAfter 的反向查詢，供由新到舊的 Feed 分頁拉取高粉絲帳號的貼文
+func (r *DynamoDBPostRepository) GetPostsByAuthorBefore(ctx context.Context, authorID, before, after string, limit int32) ([]models.Post, error) {
+	lower := "POST#"
+	if after != "" {
+		lower = "POST#" + after + "#~"
+	}
+	// "POST#{before}#" 小於所有 created_at 等於 before 的 SK，因此上界同樣不包含 before
+	upper := "POST#~"
+	if before != "" {
+		upper = "POST#" + before + "#"
+	}
+
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :lower AND :upper"),
+		FilterExpression:       aws.String("attribute_not_exists(deleted_at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":    &types.AttributeValueMemberS{Value: "USER#" + authorID},
+			":lower": &types.AttributeValueMemberS{Value: lower},
+			":upper": &types.AttributeValueMemberS{Value: upper},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(limit),
+	})
+	if err != nil {
+		log.Printf("DynamoDB Query failed for posts of author %s before %s: %v", authorID, before, err)
+		return nil, fmt.Errorf("failed to query author posts: %w", err)
+	}
+
+	var posts []models.Post
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// ReloadPost 以強一致讀取重新取得貼文，用於取得按讚 / 評論後的最新計數 (GSI 只支援最終一致讀取)
+func (r *DynamoDBPostRepository) ReloadPost(ctx context.Context, post *models.Post) (*models.Post, error) {
+	key, err := attributevalue.MarshalMap(map[string]string{
+		"PK": post.PK,
+		"SK": post.SK,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.getPostByKey(ctx, key)
+}
+
+// getPostByKey 以主表的 PK/SK 強一致讀取貼文
+func (r *DynamoDBPostRepository) getPostByKey(ctx context.Context, key map[string]types.AttributeValue) (*models.Post, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		log.Printf("Error reading post by key: %v", err)
+		return nil, err
+	}
+	if result.Item == nil {
+		return nil, ErrPostNotFound
+	}
+	var post models.Post
+	if err := attributevalue.UnmarshalMap(result.Item, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// GetTagTimeline 依標籤查詢貼文索引，最新的在前
+func (r *DynamoDBPostRepository) GetTagTimeline(ctx context.Context, tag string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedTagPosts, error) {
+	pk := "TAG#" + textparse.NormalizeTag(tag)
+	queryInput := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: pk},
+		},
+		ScanIndexForward:  aws.Bool(false),
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: lastEvaluatedKey,
+	}
+
+	result, err := r.client.Query(ctx, queryInput)
+	if err != nil {
+		log.Printf("DynamoDB Query failed for tag timeline %s: %v", pk, err)
+		return nil, fmt.Errorf("failed to query tag timeline: %w", err)
+	}
+
+	var items []models.TagIndexItem
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &items); err != nil {
+		log.Printf("Failed to unmarshal tag index items for %s: %v", pk, err)
+		return nil, err
+	}
+
+	return &models.PaginatedTagPosts{
+		Items:            items,
+		LastEvaluatedKey: result.LastEvaluatedKey,
+	}, nil
+}
+
+// tagIndexPuts 為每個標籤建立一筆寫入索引的交易項目
+func (r *DynamoDBPostRepository) tagIndexPuts(post *models.Post, tags []string) ([]types.TransactWriteItem, error) {
+	var items []types.TransactWriteItem
+	for _, tag := range tags {
+		normalized := textparse.NormalizeTag(tag)
+		if normalized == "" {
+			continue
+		}
+		av, err := attributevalue.MarshalMap(models.TagIndexItem{
+			PK:         "TAG#" + normalized,
+			SK:         post.CreatedAt + "#" + post.PostID,
+			EntityType: "TAG_INDEX",
+			Tag:        normalized,
+			PostID:     post.PostID,
+			AuthorID:   post.AuthorID,
+			CreatedAt:  post.CreatedAt,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal tag index item: %w", err)
+		}
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      av,
+			},
+		})
+	}
+	return items, nil
+}
+
+// tagIndexDeletes 為每個標籤建立一筆刪除索引的交易項目
+func (r *DynamoDBPostRepository) tagIndexDeletes(post *models.Post, tags []string) []types.TransactWriteItem {
+	var items []types.TransactWriteItem
+	for _, tag := range tags {
+		normalized := textparse.NormalizeTag(tag)
+		if normalized == "" {
+			continue
+		}
+		items = append(items, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: "TAG#" + normalized},
+					"SK": &types.AttributeValueMemberS{Value: post.CreatedAt + "#" + post.PostID},
+				},
+			},
+		})
+	}
+	return items
+}
+
+// diffTags 比較新舊標籤 (以標準化後的值比較)，回傳需要新增與移除的標籤
+func diffTags(oldTags, newTags []string) (added, removed []string) {
+	oldSet := make(map[string]bool, len(oldTags))
+	for _, t := range oldTags {
+		oldSet[textparse.NormalizeTag(t)] = true
+	}
+	newSet := make(map[string]bool, len(newTags))
+	for _, t := range newTags {
+		key := textparse.NormalizeTag(t)
+		newSet[key] = true
+		if !oldSet[key] {
+			added = append(added, t)
+		}
+	}
+	for _, t := range oldTags {
+		if !newSet[textparse.NormalizeTag(t)] {
+			removed = append(removed, t)
+		}
+	}
+	return added, removed
+}
+
+// GetPostByID 透過 GSI 查詢單一貼文
+func (r *DynamoDBPostRepository) GetPostByID(ctx context.Context, postID string) (*models.Post, error) {
+	queryInput := &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
 		IndexName:              aws.String("GSI1"),
 		KeyConditionExpression: aws.String("GSI1PK = :gsi1pkval AND GSI1SK = :gsi1skval"),
@@ -503,7 +1924,7 @@ func (r *DynamoDBPostRepository) GetPostByID(ctx context.Context, postID string)
 		return nil, err
 	}
 	if len(result.Items) == 0 {
-		return nil, fmt.Errorf("post with ID %s not found", postID)
+		return nil, fmt.Errorf("%w: %s", ErrPostNotFound, postID)
 	}
 
 	var post models.Post
@@ -511,9 +1932,45 @@ func (r *DynamoDBPostRepository) GetPostByID(ctx context.Context, postID string)
 		log.Printf("Error unmarshalling post for GetPostByID %s: %v", postID, err)
 		return nil, err
 	}
+	// 已軟刪除的貼文對讀取端而言等同於不存在
+	if post.DeletedAt != "" {
+		return nil, fmt.Errorf("%w: %s", ErrPostNotFound, postID)
+	}
 	return &post, nil
 }
 
+// CheckPostsExist 並行以 GetPostByID 確認每篇貼文；只有 ErrPostNotFound 視為不存在，其他錯誤中止並回傳
+func (r *DynamoDBPostRepository) CheckPostsExist(ctx context.Context, postIDs []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(postIDs))
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, id := range postIDs {
+		wg.Add(1)
+		go func(postID string) {
+			defer wg.Done()
+			_, err := r.GetPostByID(ctx, postID)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				exists[postID] = true
+			case errors.Is(err, ErrPostNotFound):
+				exists[postID] = false
+			case firstErr == nil:
+				firstErr = fmt.Errorf("failed to check whether post %s exists: %w", postID, err)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return exists, nil
+}
+
 // GetFeedItemsByUserID 從 DynamoDB 獲取指定用戶的 Feed Item 列表
 // PK = USER#{userID}, SK starts_with FEEDITEM#
 // 返回的 feedItems 應該按 SK (時間戳) 排序
@@ -22,6 +22,15 @@ type RecommendationRepository interface {
 	GetUserRecommendations(ctx context.Context, userID string, limit int32) ([]models.UserRecommendationItem, error)
 	// GetGlobalTrending 獲取全域熱門貼文列表
 	GetGlobalTrending(ctx context.Context, algorithmVersion string, limit int32) ([]models.UserRecommendationItem, error)
+	// ReplaceTrendingTags 以新的熱門標籤列表取代舊列表
+	ReplaceTrendingTags(ctx context.Context, algorithmVersion string, items []models.TrendingTagItem) error
+	// GetTrendingTags 獲取熱門標籤列表，依分數降序排列
+	GetTrendingTags(ctx context.Context, algorithmVersion string, limit int32) ([]models.TrendingTagItem, error)
+	// RemovePostFromTrending 從全域熱門列表移除指定貼文，回傳刪除的筆數
+	RemovePostFromTrending(ctx context.Context, algorithmVersion, postID string) (int, error)
+	// ScanRecommendationItems 分頁掃描所有帶有 PostID 的推薦項目，供對帳指令使用
+	ScanRecommendationItems(ctx context.Context, fn func(items []models.UserRecommendationItem) error) error
+	DeleteRecommendationItems(ctx context.Context, items []models.UserRecommendationItem) error
 }
 
 type dynamoDBRecommendationRepository struct {
@@ -37,7 +46,7 @@ func NewDynamoDBRecommendationRepository(client *dynamodb.Client) Recommendation
 	}
 }
 
-// SaveRecommendations 使用 BatchWriteItem 批量儲存推薦項目 (此方法保持不變，因為它足夠通用)
+// SaveRecommendations 使用 BatchWriteItem 批量儲存推薦項目
 func (r *dynamoDBRecommendationRepository) SaveRecommendations(ctx context.Context, recommendations []models.UserRecommendationItem) error {
 	if len(recommendations) == 0 {
 		return nil
@@ -57,25 +66,8 @@ func (r *dynamoDBRecommendationRepository) SaveRecommendations(ctx context.Conte
 		}
 	}
 
-	chunkSize := 25
-	for i := 0; i < len(writeRequests); i += chunkSize {
-		end := i + chunkSize
-		if end > len(writeRequests) {
-			end = len(writeRequests)
-		}
-		chunk := writeRequests[i:end]
-
-		input := &dynamodb.BatchWriteItemInput{
-			RequestItems: map[string][]types.WriteRequest{
-				r.tableName: chunk,
-			},
-		}
-
-		_, err := r.client.BatchWriteItem(ctx, input)
-		if err != nil {
-			log.Printf("failed to batch write recommendation items: %v", err)
-			return fmt.Errorf("failed to batch write recommendation items: %w", err)
-		}
+	if err := r.batchWrite(ctx, writeRequests); err != nil {
+		return fmt.Errorf("failed to batch write recommendation items: %w", err)
 	}
 
 	log.Printf("Successfully saved %d recommendations.", len(recommendations))
@@ -139,4 +131,200 @@ func (r *dynamoDBRecommendationRepository) GetUserRecommendations(ctx context.Co
 	}
 
 	return recommendations, nil
-}
\ No newline at end of file
+}
+
+// ReplaceTrendingTags 先寫入新的熱門標籤列表，再刪除不在新列表中的舊項目。
+// 因為 SK 含有分數，每次重新計算都會產生新的鍵，不清理的話舊排名會一直留在列表中。
+func (r *dynamoDBRecommendationRepository) ReplaceTrendingTags(ctx context.Context, algorithmVersion string, items []models.TrendingTagItem) error {
+	pkValue := "TRENDING_TAGS#" + algorithmVersion
+
+	// 1. 收集目前列表中所有的鍵
+	existingKeys := make(map[string]bool)
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: pkValue},
+			},
+			ProjectionExpression: aws.String("SK"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			log.Printf("DynamoDB Query failed for existing trending tags %s: %v", algorithmVersion, err)
+			return fmt.Errorf("failed to query existing trending tags: %w", err)
+		}
+		for _, item := range result.Items {
+			if sk, ok := item["SK"].(*types.AttributeValueMemberS); ok {
+				existingKeys[sk.Value] = true
+			}
+		}
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		startKey = result.LastEvaluatedKey
+	}
+
+	// 2. 寫入新列表
+	var puts []types.WriteRequest
+	for _, item := range items {
+		item.PK = pkValue
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal trending tag item: %w", err)
+		}
+		puts = append(puts, types.WriteRequest{PutRequest: &types.PutRequest{Item: av}})
+		delete(existingKeys, item.SK)
+	}
+	if err := r.batchWrite(ctx, puts); err != nil {
+		return fmt.Errorf("failed to save trending tags: %w", err)
+	}
+
+	// 3. 刪除過時的項目
+	var deletes []types.WriteRequest
+	for sk := range existingKeys {
+		deletes = append(deletes, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: pkValue},
+					"SK": &types.AttributeValueMemberS{Value: sk},
+				},
+			},
+		})
+	}
+	if err := r.batchWrite(ctx, deletes); err != nil {
+		return fmt.Errorf("failed to remove stale trending tags: %w", err)
+	}
+
+	log.Printf("Replaced trending tags for %s: %d saved, %d stale removed.", algorithmVersion, len(puts), len(deletes))
+	return nil
+}
+
+// GetTrendingTags 獲取熱門標籤列表
+func (r *dynamoDBRecommendationRepository) GetTrendingTags(ctx context.Context, algorithmVersion string, limit int32) ([]models.TrendingTagItem, error) {
+	pkValue := "TRENDING_TAGS#" + algorithmVersion
+
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: pkValue},
+		},
+		ScanIndexForward: aws.Bool(false), // 按 SK (分數) 降序排序
+		Limit:            aws.Int32(limit),
+	})
+	if err != nil {
+		log.Printf("DynamoDB Query failed for trending tags %s: %v", algorithmVersion, err)
+		return nil, fmt.Errorf("failed to query trending tags: %w", err)
+	}
+
+	var items []models.TrendingTagItem
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &items); err != nil {
+		log.Printf("Failed to unmarshal trending tags: %v", err)
+		return nil, err
+	}
+	return items, nil
+}
+
+// RemovePostFromTrending 查詢熱門列表中屬於 postID 的項目並刪除
+func (r *dynamoDBRecommendationRepository) RemovePostFromTrending(ctx context.Context, algorithmVersion, postID string) (int, error) {
+	pkValue := "TRENDING#" + algorithmVersion
+
+	var stale []models.UserRecommendationItem
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk"),
+			FilterExpression:       aws.String("PostID = :pid"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":  &types.AttributeValueMemberS{Value: pkValue},
+				":pid": &types.AttributeValueMemberS{Value: postID},
+			},
+			ProjectionExpression: aws.String("PK, SK"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			log.Printf("DynamoDB Query failed for trending items of post %s: %v", postID, err)
+			return 0, fmt.Errorf("failed to query trending items: %w", err)
+		}
+		var page []models.UserRecommendationItem
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &page); err != nil {
+			return 0, err
+		}
+		stale = append(stale, page...)
+		if result.LastEvaluatedKey == nil {
+			break
+		}
+		startKey = result.LastEvaluatedKey
+	}
+
+	if err := r.DeleteRecommendationItems(ctx, stale); err != nil {
+		return 0, err
+	}
+	return len(stale), nil
+}
+
+// ScanRecommendationItems 分頁掃描推薦表並對每一頁呼叫 fn
+func (r *dynamoDBRecommendationRepository) ScanRecommendationItems(ctx context.Context, fn func(items []models.UserRecommendationItem) error) error {
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:            aws.String(r.tableName),
+			FilterExpression:     aws.String("attribute_exists(PostID)"),
+			ProjectionExpression: aws.String("PK, SK, PostID"),
+			ExclusiveStartKey:    startKey,
+		})
+		if err != nil {
+			log.Printf("failed to scan recommendation table: %v", err)
+			return fmt.Errorf("failed to scan recommendations: %w", err)
+		}
+		var items []models.UserRecommendationItem
+		if err := attributevalue.UnmarshalListOfMaps(result.Items, &items); err != nil {
+			return fmt.Errorf("failed to unmarshal recommendation items: %w", err)
+		}
+		if err := fn(items); err != nil {
+			return err
+		}
+		if result.LastEvaluatedKey == nil {
+			return nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
+
+// DeleteRecommendationItems 批次刪除推薦項目
+func (r *dynamoDBRecommendationRepository) DeleteRecommendationItems(ctx context.Context, items []models.UserRecommendationItem) error {
+	var deletes []types.WriteRequest
+	for _, item := range items {
+		deletes = append(deletes, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: item.PK},
+					"SK": &types.AttributeValueMemberS{Value: item.SK},
+				},
+			},
+		})
+	}
+	if err := r.batchWrite(ctx, deletes); err != nil {
+		return fmt.Errorf("failed to delete recommendation items: %w", err)
+	}
+	return nil
+}
+
+// batchWrite 以每批 25 個項目的方式執行 BatchWriteItem，未處理的項目以 batchWriteWithRetry 退避重試
+func (r *dynamoDBRecommendationRepository) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	chunkSize := 25
+	for i := 0; i < len(requests); i += chunkSize {
+		end := i + chunkSize
+		if end > len(requests) {
+			end = len(requests)
+		}
+		if err := batchWriteWithRetry(ctx, r.client, r.tableName, requests[i:end]); err != nil {
+			log.Printf("failed to batch write recommendation items: %v", err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// internal/repository/seen_repository_dynamodb.go
+package repository
+
+import (
+	"backend/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const SeenHistoryTableName = "SeenHistory" // SeenHistory 表名
+
+// SeenHistoryRepository 記錄使用者看過的貼文
+type SeenHistoryRepository interface {
+	// RecordSeen 寫入看過的紀錄；同一個使用者重複看同一篇貼文只會更新 SeenAt 與 TTL
+	RecordSeen(ctx context.Context, items []models.SeenItem) error
+	// GetSeenTimes 回傳 postIDs 中使用者已看過的貼文與最後一次看到的時間 (SeenAt)
+	GetSeenTimes(ctx context.Context, userID string, postIDs []string) (map[string]string, error)
+	// CountViewers 透過 GSI1 計算看過貼文的使用者數
+	CountViewers(ctx context.Context, postID string) (int, error)
+}
+
+type dynamoDBSeenHistoryRepository struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBSeenHistoryRepository 是 dynamoDBSeenHistoryRepository 的建構子
+func NewDynamoDBSeenHistoryRepository(client *dynamodb.Client) SeenHistoryRepository {
+	return &dynamoDBSeenHistoryRepository{
+		client:    client,
+		tableName: SeenHistoryTableName,
+	}
+}
+
+// RecordSeen 以 BatchWriteItem 寫入紀錄；同一批中不可有重複的主鍵，由呼叫端負責去重
+func (r *dynamoDBSeenHistoryRepository) RecordSeen(ctx context.Context, items []models.SeenItem) error {
+	writeRequests := make([]types.WriteRequest, 0, len(items))
+	for _, item := range items {
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal seen item: %w", err)
+		}
+		writeRequests = append(writeRequests, types.WriteRequest{PutRequest: &types.PutRequest{Item: av}})
+	}
+
+	// DynamoDB BatchWriteItem 每次最多處理 25 個項目
+	chunkSize := 25
+	for i := 0; i < len(writeRequests); i += chunkSize {
+		end := i + chunkSize
+		if end > len(writeRequests) {
+			end = len(writeRequests)
+		}
+		if err := batchWriteWithRetry(ctx, r.client, r.tableName, writeRequests[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSeenTimes 以 BatchGetItem 檢查紀錄是否存在
+func (r *dynamoDBSeenHistoryRepository) GetSeenTimes(ctx context.Context, userID string, postIDs []string) (map[string]string, error) {
+	seen := make(map[string]string)
+	if len(postIDs) == 0 {
+		return seen, nil
+	}
+
+	keys := make([]map[string]types.AttributeValue, 0, len(postIDs))
+	unique := make(map[string]bool, len(postIDs))
+	for _, postID := range postIDs {
+		if unique[postID] {
+			continue // BatchGetItem 不接受重複的鍵
+		}
+		unique[postID] = true
+		keys = append(keys, map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+			"SK": &types.AttributeValueMemberS{Value: "POST#" + postID},
+		})
+	}
+
+	// BatchGetItem 每次最多查詢 100 個項目，如果超過則需分批
+	chunkSize := 100
+	for i := 0; i < len(keys); i += chunkSize {
+		end := i + chunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		// 未處理的鍵 (UnprocessedKeys) 以退避重試，次數用完仍未讀到時回傳錯誤
+		items, err := batchGetWithRetry(ctx, r.client, r.tableName, keys[i:end], "SK, SeenAt")
+		if err != nil {
+			log.Printf("BatchGetItem failed for seen history of user %s: %v", userID, err)
+			return nil, fmt.Errorf("failed to get seen history: %w", err)
+		}
+		for _, itemMap := range items {
+			var item struct {
+				SK     string `dynamodbav:"SK"`
+				SeenAt string `dynamodbav:"SeenAt"`
+			}
+			if err := attributevalue.UnmarshalMap(itemMap, &item); err == nil {
+				seen[strings.TrimPrefix(item.SK, "POST#")] = item.SeenAt
+			}
+		}
+	}
+	return seen, nil
+}
+
+// CountViewers 以 Select COUNT 分頁計算 GSI1 上的項目數
+func (r *dynamoDBSeenHistoryRepository) CountViewers(ctx context.Context, postID string) (int, error) {
+	count := 0
+	var startKey map[string]types.AttributeValue
+	for {
+		result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			IndexName:              aws.String("GSI1"),
+			KeyConditionExpression: aws.String("GSI1PK = :pk"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "POST#" + postID},
+			},
+			Select:            types.SelectCount,
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			log.Printf("Failed to count viewers of post %s: %v", postID, err)
+			return 0, fmt.Errorf("failed to count viewers: %w", err)
+		}
+		count += int(result.Count)
+		if result.LastEvaluatedKey == nil {
+			return count, nil
+		}
+		startKey = result.LastEvaluatedKey
+	}
+}
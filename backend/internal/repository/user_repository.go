@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"log"
 	"strconv"
+	"strings"
 
 	"backend/internal/models"
 )
@@ -18,6 +19,8 @@ type UserRepository interface {
 	GetAllUsers() ([]models.User, error)
 	// --- Profile ---
 	GetUserProfileByUserID(userID string) (*models.UserProfile, error)
+	// GetAvatarURLs 批次查詢多位使用者的頭像網址，沒有設定頭像的使用者不會出現在結果中
+	GetAvatarURLs(userIDs []string) (map[string]string, error)
 	UpdateUserProfile(profile *models.UserProfile) error
 	CreateUserProfile(profile *models.UserProfile) error
 	// --- Follow/Unfollow ---
@@ -25,6 +28,10 @@ type UserRepository interface {
 	UnfollowUser(followerID, followedID string) error
 	GetFollowers(userID string) ([]models.User, error)
 	GetFollowing(userID string) ([]models.User, error)
+	CountFollowers(userID string) (int, error)
+	IsFollowing(followerID, followedID string) (bool, error)
+	// GetFollowedIDsWithMinFollowers 回傳 userID 追蹤的帳號中，粉絲數至少為 minFollowers 的帳號 ID
+	GetFollowedIDsWithMinFollowers(userID string, minFollowers int) ([]string, error)
 }
 
 // mysqlUserRepository 實現了 UserRepository 介面，用於 MySQL 資料庫
@@ -131,46 +138,122 @@ func (r *mysqlUserRepository) GetFollowing(userID string) ([]models.User, error)
 	return following, nil
 }
 
-// FollowUser 創建一個新的追蹤關係
+// CountFollowers 讀取指定使用者的粉絲數 (users.follower_count，由追蹤與取消追蹤維護)
+func (r *mysqlUserRepository) CountFollowers(userID string) (int, error) {
+	userIDNum, _ := strconv.ParseUint(userID, 10, 64)
+	ctx := context.Background()
+	query := "SELECT follower_count FROM users WHERE id = ?"
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userIDNum).Scan(&count); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		log.Printf("Error counting followers for user ID %d: %v", userIDNum, err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// IsFollowing 檢查 followerID 是否正在追蹤 followedID
+func (r *mysqlUserRepository) IsFollowing(followerID, followedID string) (bool, error) {
+	followerIDNum, _ := strconv.ParseUint(followerID, 10, 64)
+	followedIDNum, _ := strconv.ParseUint(followedID, 10, 64)
+	ctx := context.Background()
+	query := "SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = ? AND followed_id = ?)"
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, query, followerIDNum, followedIDNum).Scan(&exists); err != nil {
+		log.Printf("Error checking follow relation %d -> %d: %v", followerIDNum, followedIDNum, err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// GetFollowedIDsWithMinFollowers 獲取指定使用者追蹤的帳號中，粉絲數達到門檻的帳號 ID；
+// 每次讀取 Feed 都會呼叫，因此使用預先維護的 users.follower_count 而不是逐筆 COUNT
+func (r *mysqlUserRepository) GetFollowedIDsWithMinFollowers(userID string, minFollowers int) ([]string, error) {
+	userIDNum, _ := strconv.ParseUint(userID, 10, 64)
+	ctx := context.Background()
+	query := `
+		SELECT f.followed_id
+		FROM follows f
+		JOIN users u ON u.id = f.followed_id
+		WHERE f.follower_id = ?
+		  AND u.follower_count >= ?`
+
+	rows, err := r.db.QueryContext(ctx, query, userIDNum, minFollowers)
+	if err != nil {
+		log.Printf("Error querying high-follower accounts followed by user ID %d: %v", userIDNum, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id uint64
+		if err := rows.Scan(&id); err != nil {
+			log.Printf("Error scanning followed ID row: %v", err)
+			continue
+		}
+		ids = append(ids, strconv.FormatUint(id, 10))
+	}
+	return ids, rows.Err()
+}
+
+// FollowUser 創建一個新的追蹤關係，並在同一個交易中遞增被追蹤者的粉絲數
 func (r *mysqlUserRepository) FollowUser(followerID, followedID string) error {
 	followerIDNum, _ := strconv.ParseUint(followerID, 10, 64)
 	followedIDNum, _ := strconv.ParseUint(followedID, 10, 64)
 	ctx := context.Background()
-	query := "INSERT INTO follows (follower_id, followed_id) VALUES (?, ?)"
-	stmt, err := r.db.PrepareContext(ctx, query)
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error preparing statement for FollowUser: %v", err)
+		log.Printf("Error starting transaction for FollowUser: %v", err)
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.ExecContext(ctx, followerIDNum, followedIDNum)
-	if err != nil {
+	if _, err := tx.ExecContext(ctx, "INSERT INTO follows (follower_id, followed_id) VALUES (?, ?)", followerIDNum, followedIDNum); err != nil {
 		log.Printf("Error executing statement for FollowUser: %v", err)
 		return err
 	}
-	return nil
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET follower_count = follower_count + 1 WHERE id = ?", followedIDNum); err != nil {
+		log.Printf("Error incrementing follower count for user ID %d: %v", followedIDNum, err)
+		return err
+	}
+	return tx.Commit()
 }
 
-// UnfollowUser 移除一個追蹤關係
+// UnfollowUser 移除一個追蹤關係；只有真的刪除了追蹤關係時才遞減粉絲數
 func (r *mysqlUserRepository) UnfollowUser(followerID, followedID string) error {
 	followerIDNum, _ := strconv.ParseUint(followerID, 10, 64)
 	followedIDNum, _ := strconv.ParseUint(followedID, 10, 64)
 	ctx := context.Background()
-	query := "DELETE FROM follows WHERE follower_id = ? AND followed_id = ?"
-	stmt, err := r.db.PrepareContext(ctx, query)
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		log.Printf("Error preparing statement for UnfollowUser: %v", err)
+		log.Printf("Error starting transaction for UnfollowUser: %v", err)
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	_, err = stmt.ExecContext(ctx, followerIDNum, followedIDNum)
+	result, err := tx.ExecContext(ctx, "DELETE FROM follows WHERE follower_id = ? AND followed_id = ?", followerIDNum, followedIDNum)
 	if err != nil {
 		log.Printf("Error executing statement for UnfollowUser: %v", err)
 		return err
 	}
-	return nil
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET follower_count = follower_count - 1 WHERE id = ? AND follower_count > 0", followedIDNum); err != nil {
+			log.Printf("Error decrementing follower count for user ID %d: %v", followedIDNum, err)
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // CreateUser 將新使用者儲存到 MySQL 資料庫
@@ -292,6 +375,41 @@ func (r *mysqlUserRepository) GetUserProfileByUserID(userID string) (*models.Use
 	return &profile, nil
 }
 
+// GetAvatarURLs 以單次 IN 查詢取得多位使用者的頭像網址
+func (r *mysqlUserRepository) GetAvatarURLs(userIDs []string) (map[string]string, error) {
+	avatars := make(map[string]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return avatars, nil
+	}
+	ctx := context.Background()
+
+	placeholders := make([]string, len(userIDs))
+	args := make([]interface{}, len(userIDs))
+	for i, id := range userIDs {
+		placeholders[i] = "?"
+		args[i], _ = strconv.ParseUint(id, 10, 64)
+	}
+	query := `SELECT user_id, avatar_url FROM user_profiles WHERE avatar_url IS NOT NULL AND user_id IN (` + strings.Join(placeholders, ",") + `)`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error querying avatar URLs for %d users: %v", len(userIDs), err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uint64
+		var avatarURL string
+		if err := rows.Scan(&id, &avatarURL); err != nil {
+			log.Printf("Error scanning avatar URL row: %v", err)
+			continue
+		}
+		avatars[strconv.FormatUint(id, 10)] = avatarURL
+	}
+	return avatars, rows.Err()
+}
+
 // UpdateUserProfile 更新使用者的個人資料
 func (r *mysqlUserRepository) UpdateUserProfile(profile *models.UserProfile) error {
 	ctx := context.Background()
@@ -12,7 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func NewRouter(mysqlDB *sql.DB, dynamoDBClient *dynamodb.Client, authHandler *handler.AuthHandler, profileHandler *handler.ProfileHandler, postHandler *handler.PostHandler, userHandler *handler.UserHandler, userRepo repository.UserRepository, authMiddleware *middleware.AuthMiddleware) *gin.Engine {
+func NewRouter(mysqlDB *sql.DB, dynamoDBClient *dynamodb.Client, authHandler *handler.AuthHandler, profileHandler *handler.ProfileHandler, postHandler *handler.PostHandler, userHandler *handler.UserHandler, recommendationHandler *handler.RecommendationHandler, notificationHandler *handler.NotificationHandler, streamHandler *handler.StreamHandler, muteHandler *handler.MuteHandler, bookmarkHandler *handler.BookmarkHandler, draftHandler *handler.DraftHandler, userRepo repository.UserRepository, authMiddleware *middleware.AuthMiddleware) *gin.Engine {
 	r := gin.Default()
 
 	// --- CORS 中介軟體設定 ---
@@ -36,6 +36,9 @@ func NewRouter(mysqlDB *sql.DB, dynamoDBClient *dynamodb.Client, authHandler *ha
 		authPublicRoutes.POST("/login", authHandler.Login)
 	}
 
+	// 公開貼文的分享連結 (未登入也能瀏覽，只回傳公開貼文)
+	apiV1.GET("/public/posts/:postID", postHandler.GetPublicPost)
+
     // --- 保護路由 (需要身份驗證) ---
 	// 任何使用此中介軟體的路由群組都需要一個有效的 JWT
 	authRequired := apiV1.Group("/")
@@ -54,12 +57,73 @@ func NewRouter(mysqlDB *sql.DB, dynamoDBClient *dynamodb.Client, authHandler *ha
 			userRoutes.GET("/:userID/following", userHandler.GetFollowing)
 		}
 
+		// 貼文曝光紀錄 (客戶端在貼文捲動進入畫面時批次回報)
+		authRequired.POST("/impressions", postHandler.RecordImpressions)
+
+		// 單篇貼文
+		postRoutes := authRequired.Group("/posts/:postID")
+		{
+			postRoutes.GET("", postHandler.GetPost)
+			postRoutes.GET("/views", postHandler.GetPostViews)
+			postRoutes.GET("/history", postHandler.GetPostHistory)
+		}
+
+		// 即時推播 (Server-Sent Events)
+		authRequired.GET("/stream", streamHandler.Stream)
+
+		// 通知
+		notificationRoutes := authRequired.Group("/notifications")
+		{
+			notificationRoutes.GET("", notificationHandler.ListNotifications)
+			notificationRoutes.GET("/unread-count", notificationHandler.GetUnreadCount)
+			notificationRoutes.GET("/preferences", notificationHandler.GetPreferences)
+			notificationRoutes.PUT("/preferences", notificationHandler.UpdatePreferences)
+			notificationRoutes.PUT("/read-all", notificationHandler.MarkAllAsRead)
+			notificationRoutes.PUT("/:notificationSK/read", notificationHandler.MarkAsRead)
+		}
+
+		// 靜音條件 (從 Feed 與推薦中隱藏含特定字詞或標籤的貼文)
+		muteRoutes := authRequired.Group("/mutes")
+		{
+			muteRoutes.GET("", muteHandler.ListMuteFilters)
+			muteRoutes.POST("", muteHandler.CreateMuteFilter)
+			muteRoutes.DELETE("/:filterID", muteHandler.DeleteMuteFilter)
+		}
+
+		// 收藏 (只有本人看得到)
+		bookmarkRoutes := authRequired.Group("/bookmarks")
+		{
+			bookmarkRoutes.GET("", bookmarkHandler.ListBookmarks)
+			bookmarkRoutes.GET("/collections", bookmarkHandler.ListCollections)
+			bookmarkRoutes.PUT("/:postID", bookmarkHandler.AddBookmark)
+			bookmarkRoutes.DELETE("/:postID", bookmarkHandler.RemoveBookmark)
+		}
+
+		// 草稿與排程貼文 (只有本人看得到)
+		draftRoutes := authRequired.Group("/drafts")
+		{
+			draftRoutes.GET("", draftHandler.ListDrafts)
+			draftRoutes.POST("", draftHandler.CreateDraft)
+			draftRoutes.GET("/:draftID", draftHandler.GetDraft)
+			draftRoutes.PUT("/:draftID", draftHandler.UpdateDraft)
+			draftRoutes.DELETE("/:draftID", draftHandler.DeleteDraft)
+			draftRoutes.POST("/:draftID/publish", draftHandler.PublishDraft)
+		}
+
+		// Hashtag 相關操作
+		tagRoutes := authRequired.Group("/tags")
+		{
+			tagRoutes.GET("/trending", recommendationHandler.GetTrendingTags)
+			tagRoutes.GET("/:tag/posts", postHandler.GetPostsByTag)
+		}
+
 		// 頁面相關內容的群組
 		pagesRoutes := authRequired.Group("/pages")
 		{
 			// --- 貼文 ---
 			pagesRoutes.POST("/posts", postHandler.CreatePost)
-			pagesRoutes.GET("/posts/:userID", postHandler.GetPostsByUserID)
+			// gin 要求同一層的萬用字元名稱相同，GET /pages/posts/ 之下的使用者 ID 與貼文 ID 因此都叫 :id
+			pagesRoutes.GET("/posts/:id", postHandler.GetPostsByUserID)
 			pagesRoutes.POST("/posts/delete", postHandler.DeletePost)
 			pagesRoutes.PUT("/posts/edit", postHandler.UpdatePost)
 
@@ -68,8 +132,22 @@ func NewRouter(mysqlDB *sql.DB, dynamoDBClient *dynamodb.Client, authHandler *ha
 			{
 				postInteractionRoutes.PUT("/like", postHandler.LikePost)
 				postInteractionRoutes.PUT("/unlike", postHandler.UnlikePost)
+				postInteractionRoutes.PUT("/repost", postHandler.RepostPost)
+				postInteractionRoutes.PUT("/unrepost", postHandler.UnrepostPost)
+				postInteractionRoutes.POST("/quote", postHandler.QuotePost)
 				postInteractionRoutes.POST("/comment", postHandler.CreateComment)
+				postInteractionRoutes.PUT("/comment/:commentSK", postHandler.UpdateComment)
 				postInteractionRoutes.DELETE("/comment/:commentSK", postHandler.DeleteComment)
+				postInteractionRoutes.PUT("/comment/:commentSK/like", postHandler.LikeComment)
+				postInteractionRoutes.PUT("/comment/:commentSK/unlike", postHandler.UnlikeComment)
+			}
+
+			// --- 評論列表 ---
+			commentRoutes := pagesRoutes.Group("/posts/:id/comments")
+			{
+				commentRoutes.GET("", postHandler.ListComments)
+				commentRoutes.GET("/:commentID/replies", postHandler.ListReplies)
+				commentRoutes.GET("/:commentID/history", postHandler.GetCommentHistory)
 			}
 
 			// --- 動態消息 (Feed) ---
@@ -0,0 +1,162 @@
+// internal/service/bookmark_service.go
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// maxCollectionNameLength 是收藏集名稱的長度上限 (字元數)
+	maxCollectionNameLength = 50
+	// bookmarkRefillRounds 是收藏的貼文被刪除或無權查看時，最多讀取幾頁來補滿一頁
+	bookmarkRefillRounds = 3
+)
+
+// ErrInvalidCollection 表示收藏集名稱不合法
+var ErrInvalidCollection = errors.New("invalid collection name")
+
+// BookmarkService 管理使用者私人的收藏與收藏集
+type BookmarkService struct {
+	bookmarkRepo repository.BookmarkRepository
+	postRepo     repository.PostRepository
+	postService  *PostService // 收藏列表沿用貼文的可見性過濾與 DTO 轉換
+}
+
+// NewBookmarkService 是 BookmarkService 的建構子
+func NewBookmarkService(bookmarkRepo repository.BookmarkRepository, postRepo repository.PostRepository, postService *PostService) *BookmarkService {
+	return &BookmarkService{
+		bookmarkRepo: bookmarkRepo,
+		postRepo:     postRepo,
+		postService:  postService,
+	}
+}
+
+// normalizeCollection 去除前後空白並驗證收藏集名稱；'#' 是鍵的分隔符號，不能出現在名稱中
+func normalizeCollection(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxCollectionNameLength || strings.Contains(name, "#") {
+		return "", fmt.Errorf("%w: must be at most %d characters and cannot contain '#'", ErrInvalidCollection, maxCollectionNameLength)
+	}
+	return name, nil
+}
+
+// AddBookmark 收藏貼文；已收藏時改為移到指定的收藏集 (collection 相同時不做任何事)
+func (s *BookmarkService) AddBookmark(ctx context.Context, userID, postID string, payload models.BookmarkPayload) (*models.Bookmark, error) {
+	collection, err := normalizeCollection(payload.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.postService.CanView(ctx, post, userID) {
+		return nil, ErrPostNotVisible
+	}
+
+	existing, err := s.bookmarkRepo.GetBookmark(ctx, userID, postID)
+	switch {
+	case err == nil && existing.Collection == collection:
+		return existing, nil
+	case err == nil:
+		// 移動時保留原本的收藏時間，收藏在全部收藏中的位置不變
+		if err := s.bookmarkRepo.MoveBookmark(ctx, existing, collection); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	case !errors.Is(err, repository.ErrBookmarkNotFound):
+		return nil, err
+	}
+
+	bookmark := &models.Bookmark{
+		UserID:     userID,
+		PostID:     postID,
+		Collection: collection,
+	}
+	if err := s.bookmarkRepo.AddBookmark(ctx, bookmark); err != nil {
+		return nil, err
+	}
+	return bookmark, nil
+}
+
+// RemoveBookmark 取消收藏
+func (s *BookmarkService) RemoveBookmark(ctx context.Context, userID, postID string) error {
+	bookmark, err := s.bookmarkRepo.GetBookmark(ctx, userID, postID)
+	if err != nil {
+		return err
+	}
+	return s.bookmarkRepo.RemoveBookmark(ctx, bookmark)
+}
+
+// ListBookmarkedPosts 依收藏時間由新到舊分頁列出收藏的貼文；collection 不為空時只列出該收藏集。
+// 已刪除或作者改為僅限粉絲而無法查看的貼文會被略過，收藏本身保留，貼文恢復可見時會再次出現。
+func (s *BookmarkService) ListBookmarkedPosts(ctx context.Context, userID, collection string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) ([]models.PostFeedDTO, map[string]types.AttributeValue, error) {
+	collection, err := normalizeCollection(collection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var posts []models.Post
+	nextKey := lastEvaluatedKey
+	for round := 0; int32(len(posts)) < limit && round < bookmarkRefillRounds; round++ {
+		page, err := s.bookmarkRepo.ListBookmarks(ctx, userID, collection, limit-int32(len(posts)), nextKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		nextKey = page.LastEvaluatedKey
+
+		postIDs := make([]string, 0, len(page.Items))
+		for _, bookmark := range page.Items {
+			postIDs = append(postIDs, bookmark.PostID)
+		}
+		found, err := s.postRepo.GetPostsByIDs(ctx, postIDs)
+		if err != nil {
+			log.Printf("Error getting bookmarked posts for user %s: %v", userID, err)
+			return nil, nil, err
+		}
+
+		// GetPostsByIDs 不保證順序，依收藏的順序重新排列
+		byID := make(map[string]models.Post, len(found))
+		for _, post := range found {
+			byID[post.PostID] = post
+		}
+		ordered := make([]models.Post, 0, len(found))
+		for _, postID := range postIDs {
+			if post, ok := byID[postID]; ok {
+				ordered = append(ordered, post)
+			}
+		}
+		posts = append(posts, s.postService.filterVisible(ctx, ordered, userID)...)
+
+		if len(nextKey) == 0 {
+			break
+		}
+	}
+
+	if len(posts) == 0 {
+		return []models.PostFeedDTO{}, nextKey, nil
+	}
+	return s.postService.BuildPostFeedDTOs(ctx, posts, userID), nextKey, nil
+}
+
+// ListCollections 列出使用者的收藏集
+func (s *BookmarkService) ListCollections(ctx context.Context, userID string) ([]models.BookmarkCollection, error) {
+	collections, err := s.bookmarkRepo.ListCollections(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if collections == nil {
+		collections = []models.BookmarkCollection{}
+	}
+	return collections, nil
+}
@@ -0,0 +1,256 @@
+// internal/service/digest_service.go
+package service
+
+import (
+	"backend/internal/mailer"
+	"backend/internal/models"
+	"backend/internal/recommendation"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	// digestMaxNotifications 是單份摘要列出的通知上限
+	digestMaxNotifications = 20
+	// digestTrendingPosts 是摘要中附帶的熱門貼文數量
+	digestTrendingPosts = 5
+	// digestSnippetLength 是熱門貼文內文摘錄的字元數
+	digestSnippetLength = 120
+)
+
+// DigestService 定期寄送未讀通知與熱門貼文的電子郵件摘要
+type DigestService struct {
+	notificationRepo repository.NotificationRepository
+	userRepo         repository.UserRepository
+	postRepo         repository.PostRepository
+	recoRepo         repository.RecommendationRepository
+	mailer           mailer.Mailer
+}
+
+// NewDigestService 是 DigestService 的建構子
+func NewDigestService(notificationRepo repository.NotificationRepository, userRepo repository.UserRepository, postRepo repository.PostRepository, recoRepo repository.RecommendationRepository, m mailer.Mailer) *DigestService {
+	return &DigestService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		postRepo:         postRepo,
+		recoRepo:         recoRepo,
+		mailer:           m,
+	}
+}
+
+// SendDueDigests 為所有到期的使用者寄送摘要，回傳實際寄出的數量。
+// 只讀取摘要排程索引中已到期的使用者，並逐頁處理；
+// 每位使用者的摘要以 ClaimDigest 條件更新領取，多個 replica 同時執行時不會重複寄送。
+func (s *DigestService) SendDueDigests(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	var trending []string
+	trendingLoaded := false
+	loadTrending := func() []string {
+		if !trendingLoaded {
+			trending = s.trendingLines(ctx)
+			trendingLoaded = true
+		}
+		return trending
+	}
+
+	sent := 0
+	err := s.notificationRepo.ListDueDigests(ctx, now.Format(time.RFC3339), func(userIDs []string) error {
+		for _, userID := range userIDs {
+			if s.sendDigest(ctx, userID, now, loadTrending) {
+				sent++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return sent, fmt.Errorf("could not list due digests: %w", err)
+	}
+	return sent, nil
+}
+
+// sendDigest 為單一使用者寄送摘要，回傳是否寄出；尚未到期的使用者在排程索引中延後到下一次寄送時間
+func (s *DigestService) sendDigest(ctx context.Context, userID string, now time.Time, loadTrending func() []string) bool {
+	prefs, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		log.Printf("Digest: failed to read preferences for user %s: %v", userID, err)
+		return false
+	}
+	if prefs == nil {
+		return false
+	}
+	period, ok := digestPeriod(prefs.DigestFrequency)
+	if !ok {
+		return false
+	}
+	nextDue := now.Add(period).Format(time.RFC3339)
+
+	since := now.Add(-period).Format(time.RFC3339Nano)
+	if prefs.LastDigestAt != "" {
+		last, err := time.Parse(time.RFC3339Nano, prefs.LastDigestAt)
+		if err == nil && now.Sub(last) < period {
+			s.reschedule(ctx, userID, last.Add(period).Format(time.RFC3339))
+			return false
+		}
+		since = prefs.LastDigestAt
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Digest: failed to load user %s: %v", userID, err)
+		return false
+	}
+	if user.Email == "" {
+		s.reschedule(ctx, userID, nextDue)
+		return false
+	}
+
+	unread, err := s.notificationRepo.ListUnreadSince(ctx, userID, since, digestMaxNotifications)
+	if err != nil {
+		log.Printf("Digest: failed to list unread notifications for user %s: %v", userID, err)
+		return false
+	}
+	var activity []models.Notification
+	for _, n := range unread {
+		if prefs.ChannelFor(n.NotificationType) == models.NotificationChannelEmail {
+			activity = append(activity, n)
+		}
+	}
+	trending := loadTrending()
+
+	// 先領取再寄送：寄送失敗時這一期的摘要會被略過，但不會重複寄出
+	if err := s.notificationRepo.ClaimDigest(ctx, userID, prefs.LastDigestAt, now.Format(time.RFC3339Nano), nextDue); err != nil {
+		if !errors.Is(err, repository.ErrDigestAlreadyClaimed) {
+			log.Printf("Digest: failed to claim digest for user %s: %v", userID, err)
+		}
+		return false
+	}
+
+	if len(activity) == 0 && len(trending) == 0 {
+		return false
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: digestSubject(prefs.DigestFrequency, len(activity)),
+		Body:    digestBody(user.Username, activity, trending),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Digest: failed to send digest to user %s: %v", userID, err)
+		return false
+	}
+	return true
+}
+
+// reschedule 將使用者在摘要排程索引中延後，失敗時只記錄 (下一次執行會再處理)
+func (s *DigestService) reschedule(ctx context.Context, userID, nextDue string) {
+	if err := s.notificationRepo.RescheduleDigest(ctx, userID, nextDue); err != nil {
+		log.Printf("Digest: failed to reschedule digest for user %s: %v", userID, err)
+	}
+}
+
+// trendingLines 取得熱門貼文並轉成摘要中的文字列
+func (s *DigestService) trendingLines(ctx context.Context) []string {
+	recommendations, err := s.recoRepo.GetGlobalTrending(ctx, recommendation.TrendingAlgorithmKey, digestTrendingPosts)
+	if err != nil {
+		log.Printf("Digest: could not fetch global trending: %v", err)
+		return nil
+	}
+	if len(recommendations) == 0 {
+		return nil
+	}
+
+	postIDs := make([]string, 0, len(recommendations))
+	for _, rec := range recommendations {
+		postIDs = append(postIDs, rec.PostID)
+	}
+	posts, err := s.postRepo.GetPostsByIDs(ctx, postIDs)
+	if err != nil {
+		log.Printf("Digest: could not fetch trending posts: %v", err)
+		return nil
+	}
+
+	byID := make(map[string]models.Post, len(posts))
+	for _, post := range posts {
+		byID[post.PostID] = post
+	}
+
+	authorNames := make(map[string]string)
+	var lines []string
+	for _, id := range postIDs {
+		post, ok := byID[id]
+		if !ok {
+			continue
+		}
+		name, ok := authorNames[post.AuthorID]
+		if !ok {
+			name = "User ID: " + post.AuthorID
+			if author, err := s.userRepo.GetUserByID(post.AuthorID); err == nil {
+				name = author.Username
+			}
+			authorNames[post.AuthorID] = name
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s (%d likes, %d comments)", name, snippet(post.Content, digestSnippetLength), post.LikeCount, post.CommentCount))
+	}
+	return lines
+}
+
+// digestPeriod 回傳摘要頻率對應的間隔
+func digestPeriod(frequency string) (time.Duration, bool) {
+	switch frequency {
+	case models.DigestFrequencyDaily:
+		return 24 * time.Hour, true
+	case models.DigestFrequencyWeekly:
+		return 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+func digestSubject(frequency string, activityCount int) string {
+	period := "daily"
+	if frequency == models.DigestFrequencyWeekly {
+		period = "weekly"
+	}
+	if activityCount == 0 {
+		return fmt.Sprintf("Your %s digest: trending posts", period)
+	}
+	return fmt.Sprintf("Your %s digest: %d new notifications", period, activityCount)
+}
+
+func digestBody(username string, activity []models.Notification, trending []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hi %s,\n\n", username)
+
+	if len(activity) > 0 {
+		b.WriteString("Here is what you missed:\n")
+		for _, n := range activity {
+			fmt.Fprintf(&b, "  - %s\n", n.Message)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(trending) > 0 {
+		b.WriteString("Trending right now:\n")
+		for _, line := range trending {
+			fmt.Fprintf(&b, "  - %s\n", line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("You can change how often you receive this email in your notification preferences.\n")
+	return b.String()
+}
+
+// snippet 將內文截斷為最多 n 個字元並移除換行
+func snippet(content string, n int) string {
+	content = strings.Join(strings.Fields(content), " ")
+	runes := []rune(content)
+	if len(runes) <= n {
+		return content
+	}
+	return string(runes[:n]) + "..."
+}
@@ -0,0 +1,323 @@
+// internal/service/draft_service.go
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// scheduledPublishBatchSize 是每次排程執行最多處理的到期項目數，其餘留到下一次
+	scheduledPublishBatchSize = 100
+	// draftPublishLease 是領取草稿後完成發布的期限，程序在期限內中斷時由其他程序重新領取
+	draftPublishLease = 5 * time.Minute
+	// maxScheduleAhead 是排程貼文最晚可以設定的發布時間
+	maxScheduleAhead = 365 * 24 * time.Hour
+	// scheduledPublishMaxAttempts 是排程項目連續失敗多少次後放棄自動發布，草稿改為 failed 留給作者處理
+	scheduledPublishMaxAttempts = 8
+	// scheduledPublishRetryBase 是第一次失敗後延後重試的時間，之後每次加倍
+	scheduledPublishRetryBase = time.Minute
+)
+
+var (
+	// ErrInvalidPublishAt 表示 publish_at 格式錯誤、不在未來或超過排程上限
+	ErrInvalidPublishAt = errors.New("publish_at must be a future RFC3339 time within one year")
+	// ErrDraftContentRequired 表示排程或發布的草稿沒有內容
+	ErrDraftContentRequired = errors.New("content is required to schedule or publish a draft")
+)
+
+// DraftService 管理只有作者看得到的草稿與排程貼文，並負責在發布時間到達時發布
+type DraftService struct {
+	draftRepo   repository.DraftRepository
+	postRepo    repository.PostRepository
+	postService *PostService // 發布時沿用貼文的標籤、提及、fan-out 與通知流程
+}
+
+// NewDraftService 是 DraftService 的建構子
+func NewDraftService(draftRepo repository.DraftRepository, postRepo repository.PostRepository, postService *PostService) *DraftService {
+	return &DraftService{
+		draftRepo:   draftRepo,
+		postRepo:    postRepo,
+		postService: postService,
+	}
+}
+
+// applyDraftPayload 驗證請求內容並寫入草稿；publish_at 不為空時成為排程貼文
+func applyDraftPayload(draft *models.Draft, payload models.SaveDraftPayload, now time.Time) error {
+	visibility := payload.Visibility
+	if visibility == "" {
+		visibility = models.PostVisibilityPublic
+	}
+	if !models.ValidPostVisibility(visibility) {
+		return ErrInvalidVisibility
+	}
+
+	draft.Status = models.DraftStatusDraft
+	draft.PublishAt = ""
+	if payload.PublishAt != "" {
+		publishAt, err := time.Parse(time.RFC3339, payload.PublishAt)
+		if err != nil || !publishAt.After(now) || publishAt.Sub(now) > maxScheduleAhead {
+			return ErrInvalidPublishAt
+		}
+		if strings.TrimSpace(payload.Content) == "" {
+			return ErrDraftContentRequired
+		}
+		draft.Status = models.DraftStatusScheduled
+		// 排程索引以字串比較 publish_at，統一為固定長度的 UTC 格式
+		draft.PublishAt = publishAt.UTC().Format(time.RFC3339)
+	}
+
+	draft.Content = payload.Content
+	draft.Media = payload.Media
+	draft.Tags = payload.Tags
+	draft.Location = payload.Location
+	draft.Visibility = visibility
+	draft.UpdatedAt = now.Format(time.RFC3339Nano)
+	return nil
+}
+
+// CreateDraft 建立草稿或排程貼文
+func (s *DraftService) CreateDraft(ctx context.Context, authorID string, payload models.SaveDraftPayload) (*models.Draft, error) {
+	now := time.Now().UTC()
+	draft := &models.Draft{
+		DraftID:   uuid.New().String(),
+		AuthorID:  authorID,
+		CreatedAt: now.Format(time.RFC3339Nano),
+	}
+	if err := applyDraftPayload(draft, payload, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.draftRepo.CreateDraft(ctx, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// GetDraft 取得作者的草稿
+func (s *DraftService) GetDraft(ctx context.Context, authorID, draftID string) (*models.Draft, error) {
+	return s.draftRepo.GetDraft(ctx, authorID, draftID)
+}
+
+// ListDrafts 依最後修改時間由新到舊列出作者的草稿與排程貼文
+func (s *DraftService) ListDrafts(ctx context.Context, authorID string) ([]models.Draft, error) {
+	drafts, err := s.draftRepo.ListDrafts(ctx, authorID)
+	if err != nil {
+		return nil, err
+	}
+	if drafts == nil {
+		drafts = []models.Draft{}
+	}
+	return drafts, nil
+}
+
+// UpdateDraft 以新的內容覆寫草稿；清除 publish_at 會取消排程並改回草稿。
+// 已被排程領取發布的草稿無法再編輯，回傳 repository.ErrDraftConflict
+func (s *DraftService) UpdateDraft(ctx context.Context, authorID, draftID string, payload models.SaveDraftPayload) (*models.Draft, error) {
+	previous, err := s.draftRepo.GetDraft(ctx, authorID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Status == models.DraftStatusPublishing {
+		return nil, repository.ErrDraftConflict
+	}
+
+	draft := *previous
+	if err := applyDraftPayload(&draft, payload, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := s.draftRepo.UpdateDraft(ctx, previous, &draft); err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// DeleteDraft 刪除草稿或取消排程貼文
+func (s *DraftService) DeleteDraft(ctx context.Context, authorID, draftID string) error {
+	draft, err := s.draftRepo.GetDraft(ctx, authorID, draftID)
+	if err != nil {
+		return err
+	}
+	if draft.Status == models.DraftStatusPublishing {
+		return repository.ErrDraftConflict
+	}
+	return s.draftRepo.DeleteDraft(ctx, draft)
+}
+
+// PublishDraftNow 立即發布草稿。先將草稿排程在現在，再與排程程序走相同的領取與發布流程，
+// 因此請求中途失敗時排程程序會接手完成，也不會與排程程序重複發布
+func (s *DraftService) PublishDraftNow(ctx context.Context, authorID, draftID string) (*models.Post, error) {
+	previous, err := s.draftRepo.GetDraft(ctx, authorID, draftID)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Status == models.DraftStatusPublishing {
+		return nil, repository.ErrDraftConflict
+	}
+	if strings.TrimSpace(previous.Content) == "" {
+		return nil, ErrDraftContentRequired
+	}
+
+	now := time.Now().UTC()
+	draft := *previous
+	draft.Status = models.DraftStatusScheduled
+	draft.PublishAt = now.Format(time.RFC3339)
+	draft.UpdatedAt = now.Format(time.RFC3339Nano)
+	if err := s.draftRepo.UpdateDraft(ctx, previous, &draft); err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.draftRepo.ClaimDraft(ctx, authorID, draftID, draft.PublishAt, now.Add(draftPublishLease).Format(time.RFC3339))
+	if err != nil {
+		if errors.Is(err, repository.ErrDraftAlreadyClaimed) {
+			// 排程程序在這之間搶先領取，由它完成發布
+			return nil, repository.ErrDraftConflict
+		}
+		return nil, err
+	}
+	return s.publishClaimed(ctx, claimed)
+}
+
+// PublishDuePosts 發布所有已到期的排程貼文，回傳本次發布的數量。
+// 每篇先以 ClaimDraft 條件更新領取，多個 replica 同時執行時不會重複發布；
+// 貼文建立後才放入 fan-out，程序在兩者之間中斷時，下一次執行會依排程索引補做 fan-out。
+// 失敗的項目以指數退避延後重試，不會一直佔住每次讀取的前幾筆
+func (s *DraftService) PublishDuePosts(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	nowString := now.Format(time.RFC3339)
+	entries, err := s.draftRepo.ListDueScheduledPosts(ctx, nowString, scheduledPublishBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("could not list due scheduled posts: %w", err)
+	}
+
+	published := 0
+	for i := range entries {
+		entry := &entries[i]
+		if entry.PublishedPostID != "" {
+			s.resumeFanOut(ctx, entry, now)
+			continue
+		}
+
+		draft, err := s.draftRepo.ClaimDraft(ctx, entry.AuthorID, entry.DraftID, nowString, now.Add(draftPublishLease).Format(time.RFC3339))
+		if err != nil {
+			if errors.Is(err, repository.ErrDraftAlreadyClaimed) {
+				s.skipUnclaimable(ctx, entry, nowString)
+			} else {
+				log.Printf("Scheduled posts: failed to claim draft %s: %v", entry.DraftID, err)
+				s.retryLater(ctx, entry, now, "")
+			}
+			continue
+		}
+
+		if _, err := s.publishClaimed(ctx, draft); err != nil {
+			if !errors.Is(err, repository.ErrDraftLeaseLost) {
+				log.Printf("Scheduled posts: failed to publish draft %s: %v", draft.DraftID, err)
+				s.retryLater(ctx, entry, now, draft.LeaseUntil)
+			}
+			continue
+		}
+		published++
+	}
+	return published, nil
+}
+
+// retryLater 記錄一次失敗並延後重試；連續失敗達到 scheduledPublishMaxAttempts 次時不再重試：
+// 尚未發布的草稿改為 failed 讓作者處理，已發布但無法補做 fan-out 的項目則直接移除。
+// leaseUntil 是這次領取草稿時取得的領取期限，沒有領取時為空字串
+func (s *DraftService) retryLater(ctx context.Context, entry *models.ScheduledPost, now time.Time, leaseUntil string) {
+	attempts := entry.Attempts + 1
+	if attempts < scheduledPublishMaxAttempts {
+		retryAt := now.Add(scheduledPublishRetryBase << (attempts - 1)).Format(time.RFC3339)
+		if err := s.draftRepo.DeferScheduledPost(ctx, entry, attempts, retryAt); err != nil {
+			log.Printf("Scheduled posts: failed to defer entry for draft %s: %v", entry.DraftID, err)
+		}
+		return
+	}
+
+	if entry.PublishedPostID != "" {
+		log.Printf("Scheduled posts: giving up fan-out of post %s (draft %s) after %d attempts", entry.PublishedPostID, entry.DraftID, attempts)
+		if err := s.draftRepo.CompleteScheduledPost(ctx, entry); err != nil {
+			log.Printf("Scheduled posts: failed to complete entry for draft %s: %v", entry.DraftID, err)
+		}
+		return
+	}
+	log.Printf("Scheduled posts: giving up on draft %s after %d attempts, marking it failed", entry.DraftID, attempts)
+	if err := s.draftRepo.FailScheduledPost(ctx, entry, now.Format(time.RFC3339), leaseUntil); err != nil {
+		log.Printf("Scheduled posts: failed to mark draft %s as failed: %v", entry.DraftID, err)
+	}
+}
+
+// publishClaimed 將已領取的草稿發布為貼文，之後才放入 fan-out 並通知被提及的使用者
+func (s *DraftService) publishClaimed(ctx context.Context, draft *models.Draft) (*models.Post, error) {
+	post := &models.Post{
+		AuthorID:   draft.AuthorID,
+		Content:    draft.Content,
+		Media:      draft.Media,
+		Tags:       resolvePostTags(draft.Tags, draft.Content),
+		Location:   draft.Location,
+		Visibility: draft.Visibility,
+	}
+	post.Mentions, post.MentionSpans = s.postService.resolveMentions(draft.Content)
+
+	if err := s.draftRepo.PublishDraft(ctx, draft, post); err != nil {
+		return nil, err
+	}
+
+	s.postService.enqueueFanOut(ctx, post)
+	go s.postService.notifyMentions(post.Mentions, post.AuthorID, post.PostID, "")
+
+	if err := s.draftRepo.CompleteScheduledPost(ctx, &models.ScheduledPost{
+		DraftID:         draft.DraftID,
+		AuthorID:        draft.AuthorID,
+		PublishAt:       draft.PublishAt,
+		PublishedPostID: post.PostID,
+	}); err != nil {
+		// 索引留著只會讓下一次執行再放入一次 fan-out，fan-out 可以安全地重複執行
+		log.Printf("Scheduled posts: failed to complete entry for draft %s: %v", draft.DraftID, err)
+	}
+	return post, nil
+}
+
+// resumeFanOut 為已發布但在放入 fan-out 前中斷的排程貼文補做 fan-out
+func (s *DraftService) resumeFanOut(ctx context.Context, entry *models.ScheduledPost, now time.Time) {
+	post, err := s.postRepo.ReloadPost(ctx, &models.Post{PostID: entry.PublishedPostID, PK: entry.PostPK, SK: entry.PostSK})
+	switch {
+	case err != nil && !errors.Is(err, repository.ErrPostNotFound):
+		log.Printf("Scheduled posts: failed to reload published post %s: %v", entry.PublishedPostID, err)
+		s.retryLater(ctx, entry, now, "")
+		return
+	case err == nil && post.DeletedAt == "":
+		s.postService.enqueueFanOut(ctx, post)
+	}
+
+	if err := s.draftRepo.CompleteScheduledPost(ctx, entry); err != nil {
+		log.Printf("Scheduled posts: failed to complete entry for draft %s: %v", entry.DraftID, err)
+	}
+}
+
+// skipUnclaimable 處理無法領取的排程索引：草稿已不存在 (例如被刪除) 或已不在這個時間排程時移除殘留的索引；
+// 草稿正由其他程序發布時延後到領取期限過後再檢查，不必每次執行都重新讀取草稿
+func (s *DraftService) skipUnclaimable(ctx context.Context, entry *models.ScheduledPost, now string) {
+	draft, err := s.draftRepo.GetDraft(ctx, entry.AuthorID, entry.DraftID)
+	switch {
+	case errors.Is(err, repository.ErrDraftNotFound),
+		err == nil && (draft.PublishAt != entry.PublishAt || (draft.Status != models.DraftStatusScheduled && draft.Status != models.DraftStatusPublishing)):
+		if err := s.draftRepo.CompleteScheduledPost(ctx, entry); err != nil {
+			log.Printf("Scheduled posts: failed to remove stale entry for draft %s: %v", entry.DraftID, err)
+		}
+	case err == nil && draft.Status == models.DraftStatusPublishing && draft.LeaseUntil > now:
+		if err := s.draftRepo.DeferScheduledPost(ctx, entry, entry.Attempts, draft.LeaseUntil); err != nil {
+			log.Printf("Scheduled posts: failed to defer entry for draft %s: %v", entry.DraftID, err)
+		}
+	case err != nil:
+		log.Printf("Scheduled posts: failed to load draft %s: %v", entry.DraftID, err)
+	}
+}
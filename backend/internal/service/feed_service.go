@@ -0,0 +1,444 @@
+// internal/service/feed_service.go
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/queue"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// feedRetention 是 Feed 內容的存活時間，與 UserFeed 項目的 TTL 相同
+const feedRetention = 90 * 24 * time.Hour
+
+// feedBackfillPosts 是追蹤後補進 Feed 的最近貼文數量
+const feedBackfillPosts = 20
+
+// feedPullConcurrency 是讀取 Feed 時同時拉取高粉絲作者貼文的上限
+const feedPullConcurrency = 8
+
+// 追蹤關係變更後的 Feed 佇列工作類型
+const (
+	JobTypeBackfillFeed = "backfill_feed"
+	JobTypePruneFeed    = "prune_feed"
+)
+
+// followJobPayload 是 backfill / prune 工作的內容
+type followJobPayload struct {
+	FollowerID string `json:"follower_id"`
+	AuthorID   string `json:"author_id"`
+}
+
+// FeedService 組合使用者的追蹤 Feed。
+// 粉絲數達到 pullThreshold 的作者不會被 fan-out (push)，而是在讀取時拉取 (pull) 其最新貼文，
+// 再與 UserFeed 中 push 進來的項目依時間合併。
+type FeedService struct {
+	feedRepo      repository.FeedRepository
+	postRepo      repository.PostRepository
+	userRepo      repository.UserRepository
+	jobQueue      queue.Queue // backfill / prune 工作佇列，可為 nil (退回在 goroutine 中直接執行)
+	pullThreshold int
+}
+
+// NewFeedService 是 FeedService 的建構子；pullThreshold <= 0 代表所有作者都使用 fan-out
+func NewFeedService(feedRepo repository.FeedRepository, postRepo repository.PostRepository, userRepo repository.UserRepository, jobQueue queue.Queue, pullThreshold int) *FeedService {
+	return &FeedService{
+		feedRepo:      feedRepo,
+		postRepo:      postRepo,
+		userRepo:      userRepo,
+		jobQueue:      jobQueue,
+		pullThreshold: pullThreshold,
+	}
+}
+
+// GetUserFeed 回傳一頁依時間由新到舊排列的 Feed 項目，before 為上一頁最後一個項目的 created_at (不含)，空字串代表第一頁。
+// UserFeed 表的 SK 就是 created_at，因此同一個 before 同時是 UserFeed 查詢的起點，也是拉取高粉絲作者貼文的時間上界。
+// 回傳的 LastEvaluatedKey 不為 nil 代表還有下一頁，其 SK 即為下一頁的 before。
+func (s *FeedService) GetUserFeed(ctx context.Context, userID string, limit int32, before string) (*models.PaginatedFeed, error) {
+	var startKey map[string]types.AttributeValue
+	if before != "" {
+		startKey = feedKey(userID, before)
+	}
+	pushed, err := s.feedRepo.GetUserFeed(ctx, userID, limit, startKey)
+	if err != nil {
+		return nil, err
+	}
+
+	floor := time.Now().UTC().Add(-feedRetention).Format(time.RFC3339Nano)
+	return s.mergePulled(ctx, userID, limit, pushed, false, func(authorID string) ([]models.Post, error) {
+		return s.postRepo.GetPostsByAuthorBefore(ctx, authorID, before, floor, limit)
+	})
+}
+
+// GetUserFeedSince 依時間由舊到新回傳 after (不含) 之後新增的 Feed 項目，供客戶端輪詢。
+// 回傳的 LastEvaluatedKey 不為 nil 代表新項目超過 limit，客戶端應以最後一個項目的 created_at 立即再查詢一次。
+func (s *FeedService) GetUserFeedSince(ctx context.Context, userID, after string, limit int32) (*models.PaginatedFeed, error) {
+	if floor := time.Now().UTC().Add(-feedRetention).Format(time.RFC3339Nano); after < floor {
+		after = floor
+	}
+	pushed, err := s.feedRepo.GetUserFeedSince(ctx, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mergePulled(ctx, userID, limit, pushed, true, func(authorID string) ([]models.Post, error) {
+		return s.postRepo.GetPostsByAuthorAfter(ctx, authorID, after, limit)
+	})
+}
+
+// mergePulled 將高粉絲作者的貼文與 UserFeed 中 push 進來的項目依時間合併，ascending 決定排序方向
+func (s *FeedService) mergePulled(ctx context.Context, userID string, limit int32, pushed *models.PaginatedFeed, ascending bool, pull func(authorID string) ([]models.Post, error)) (*models.PaginatedFeed, error) {
+	if s.pullThreshold <= 0 {
+		return pushed, nil
+	}
+
+	authorIDs, err := s.userRepo.GetFollowedIDsWithMinFollowers(userID, s.pullThreshold)
+	if err != nil {
+		// 拉取失敗時仍回傳 push 的部分，不讓整個 Feed 失敗
+		log.Printf("Failed to get high-follower accounts followed by user %s: %v", userID, err)
+		return pushed, nil
+	}
+	if len(authorIDs) == 0 {
+		return pushed, nil
+	}
+
+	items := pushed.Items
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		seen[item.PostID] = true
+	}
+
+	// 各作者的查詢互相獨立，並行拉取後依原順序合併
+	pulled := make([][]models.Post, len(authorIDs))
+	sem := make(chan struct{}, feedPullConcurrency)
+	var wg sync.WaitGroup
+	for i, authorID := range authorIDs {
+		wg.Add(1)
+		go func(i int, authorID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			posts, err := pull(authorID)
+			if err != nil {
+				log.Printf("Failed to pull posts of author %s for user %s: %v", authorID, userID, err)
+				return
+			}
+			pulled[i] = posts
+		}(i, authorID)
+	}
+	wg.Wait()
+
+	morePulled := false
+	for _, posts := range pulled {
+		if int32(len(posts)) >= limit {
+			morePulled = true
+		}
+		for _, post := range posts {
+			// 作者跨過門檻前已 fan-out 的貼文可能同時存在於 UserFeed
+			if seen[post.PostID] {
+				continue
+			}
+			seen[post.PostID] = true
+			items = append(items, models.UserFeedItem{
+				PK:       "USER#" + userID,
+				SK:       post.CreatedAt,
+				PostID:   post.PostID,
+				AuthorID: post.AuthorID,
+			})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if ascending {
+			return items[i].SK < items[j].SK
+		}
+		return items[i].SK > items[j].SK
+	})
+	truncated := int32(len(items)) > limit
+	if truncated {
+		items = items[:limit]
+	}
+
+	merged := &models.PaginatedFeed{Items: items}
+	if len(items) > 0 && (truncated || morePulled || pushed.LastEvaluatedKey != nil) {
+		merged.LastEvaluatedKey = feedKey(userID, items[len(items)-1].SK)
+	}
+	return merged, nil
+}
+
+// ShouldFanOut 判斷作者的新貼文是否要 fan-out 到粉絲的 UserFeed
+func (s *FeedService) ShouldFanOut(authorID string) (bool, error) {
+	if s.pullThreshold <= 0 {
+		return true, nil
+	}
+	count, err := s.userRepo.CountFollowers(authorID)
+	if err != nil {
+		return false, err
+	}
+	return count < s.pullThreshold, nil
+}
+
+// ScheduleBackfill 在追蹤成功後排程，將作者最近的貼文補進粉絲的 Feed
+func (s *FeedService) ScheduleBackfill(ctx context.Context, followerID, authorID string) {
+	s.schedule(ctx, JobTypeBackfillFeed, followerID, authorID)
+}
+
+// SchedulePrune 在取消追蹤後排程，從粉絲的 Feed 移除該作者的項目
+func (s *FeedService) SchedulePrune(ctx context.Context, followerID, authorID string) {
+	s.schedule(ctx, JobTypePruneFeed, followerID, authorID)
+}
+
+func (s *FeedService) schedule(ctx context.Context, jobType, followerID, authorID string) {
+	payload := followJobPayload{FollowerID: followerID, AuthorID: authorID}
+	if s.jobQueue != nil {
+		job, err := queue.NewJob(jobType, payload)
+		if err == nil {
+			err = s.jobQueue.Enqueue(ctx, job)
+		}
+		if err == nil {
+			return
+		}
+		log.Printf("Failed to enqueue %s for %s -> %s, running in-process: %v", jobType, followerID, authorID, err)
+	}
+
+	go func() {
+		var err error
+		if jobType == JobTypeBackfillFeed {
+			err = s.backfill(context.Background(), payload)
+		} else {
+			err = s.prune(context.Background(), payload)
+		}
+		if err != nil {
+			log.Printf("In-process %s for %s -> %s failed: %v", jobType, followerID, authorID, err)
+		}
+	}()
+}
+
+// HandleBackfillJob 是 backfill 工作的 Handler
+func (s *FeedService) HandleBackfillJob(ctx context.Context, job *queue.Job) error {
+	var payload followJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid backfill payload: %w", err)
+	}
+	return s.backfill(ctx, payload)
+}
+
+// HandlePruneJob 是 prune 工作的 Handler
+func (s *FeedService) HandlePruneJob(ctx context.Context, job *queue.Job) error {
+	var payload followJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid prune payload: %w", err)
+	}
+	return s.prune(ctx, payload)
+}
+
+// backfill 將作者最近的貼文寫入粉絲的 Feed。
+// 兩種工作都以執行當下的追蹤狀態為準，而不是排程當下的事件：
+// 快速地追蹤 / 取消追蹤時，不論工作以什麼順序執行，Feed 最後都會與追蹤關係一致。
+func (s *FeedService) backfill(ctx context.Context, p followJobPayload) error {
+	following, err := s.userRepo.IsFollowing(p.FollowerID, p.AuthorID)
+	if err != nil {
+		return err
+	}
+	if !following {
+		return nil
+	}
+
+	// 高粉絲帳號的貼文在讀取時拉取，不需要寫入 UserFeed
+	push, err := s.ShouldFanOut(p.AuthorID)
+	if err != nil {
+		return err
+	}
+	if !push {
+		return nil
+	}
+
+	posts, err := s.postRepo.GetPostsByUserID(ctx, p.AuthorID) // 最新的在前
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	var items []models.UserFeedItem
+	for _, post := range posts {
+		if len(items) >= feedBackfillPosts {
+			break
+		}
+		createdAt, err := time.Parse(time.RFC3339Nano, post.CreatedAt)
+		if err != nil || now.Sub(createdAt) > feedRetention {
+			continue
+		}
+		items = append(items, models.UserFeedItem{
+			PK:       "USER#" + p.FollowerID,
+			SK:       post.CreatedAt,
+			PostID:   post.PostID,
+			AuthorID: post.AuthorID,
+			// 補進來的項目與 fan-out 的項目在同一個時間點過期
+			TTLTimestamp: createdAt.Add(feedRetention).Unix(),
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	if err := s.feedRepo.BatchAddToFeed(ctx, items); err != nil {
+		return err
+	}
+
+	// 寫入期間若已取消追蹤，prune 工作可能比這次寫入更早完成，因此再檢查一次並自行清除
+	following, err = s.userRepo.IsFollowing(p.FollowerID, p.AuthorID)
+	if err != nil {
+		return err
+	}
+	if !following {
+		return s.prune(ctx, p)
+	}
+	log.Printf("Backfilled %d posts of user %s into feed of user %s", len(items), p.AuthorID, p.FollowerID)
+	return nil
+}
+
+// prune 在使用者已不再追蹤作者時，移除 Feed 中該作者的所有項目
+func (s *FeedService) prune(ctx context.Context, p followJobPayload) error {
+	following, err := s.userRepo.IsFollowing(p.FollowerID, p.AuthorID)
+	if err != nil {
+		return err
+	}
+	if following {
+		return nil
+	}
+
+	removed, err := s.feedRepo.RemoveAuthorFromFeed(ctx, p.FollowerID, p.AuthorID)
+	if err != nil {
+		return err
+	}
+	log.Printf("Pruned %d feed items of user %s from feed of user %s", removed, p.AuthorID, p.FollowerID)
+	return nil
+}
+
+// RebuildFeed 依 MySQL 的追蹤關係與 Posts 表中作者的貼文重建使用者的 Feed。
+// dryRun 為 true 時只回傳差異，不寫入任何資料。
+func (s *FeedService) RebuildFeed(ctx context.Context, userID string, dryRun bool) (*models.FeedRebuildReport, error) {
+	report := &models.FeedRebuildReport{UserID: userID}
+
+	// 1. 應該存在的項目：追蹤中、且使用 fan-out 的作者在保留期間內的貼文
+	following, err := s.userRepo.GetFollowing(userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	expected := make(map[string]models.UserFeedItem) // 以 SK (created_at) 為鍵，與 UserFeed 主鍵相同
+	authorPosts := make(map[string]bool)             // 追蹤中作者仍存在的貼文
+	pushAuthors := make(map[string]bool)
+	for _, author := range following {
+		posts, err := s.postRepo.GetPostsByUserID(ctx, author.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, post := range posts {
+			authorPosts[post.PostID] = true
+		}
+
+		push, err := s.ShouldFanOut(author.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !push {
+			continue // 高粉絲帳號在讀取時拉取
+		}
+		pushAuthors[author.ID] = true
+		for _, post := range posts {
+			createdAt, err := time.Parse(time.RFC3339Nano, post.CreatedAt)
+			if err != nil || now.Sub(createdAt) > feedRetention {
+				continue
+			}
+			expected[post.CreatedAt] = models.UserFeedItem{
+				PK:           "USER#" + userID,
+				SK:           post.CreatedAt,
+				PostID:       post.PostID,
+				AuthorID:     post.AuthorID,
+				TTLTimestamp: createdAt.Add(feedRetention).Unix(),
+			}
+		}
+	}
+	report.Expected = len(expected)
+
+	// 2. 實際存在的項目
+	actual, err := s.feedRepo.ListFeedItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不屬於追蹤中作者的項目需要確認貼文是否還存在，才能區分 extra 與 orphaned
+	var unknownIDs []string
+	for _, item := range actual {
+		if !authorPosts[item.PostID] {
+			unknownIDs = append(unknownIDs, item.PostID)
+		}
+	}
+	existing := make(map[string]bool)
+	for i := 0; i < len(unknownIDs); i += existenceCheckBatch {
+		end := i + existenceCheckBatch
+		if end > len(unknownIDs) {
+			end = len(unknownIDs)
+		}
+		// 讀取失敗時中止重建，不能把暫時讀不到的貼文當作已刪除
+		found, err := s.postRepo.CheckPostsExist(ctx, unknownIDs[i:end])
+		if err != nil {
+			return nil, err
+		}
+		for id, ok := range found {
+			existing[id] = ok
+		}
+	}
+
+	// 3. 比對
+	present := make(map[string]bool) // 已存在且正確的 SK
+	for _, item := range actual {
+		switch {
+		case !authorPosts[item.PostID] && !existing[item.PostID]:
+			report.Orphaned = append(report.Orphaned, item)
+		case !pushAuthors[item.AuthorID]:
+			report.Extra = append(report.Extra, item)
+		case expected[item.SK].PostID == item.PostID:
+			present[item.SK] = true
+		default:
+			// 貼文存在且作者仍在追蹤中，但已超過保留期間或主鍵與貼文時間不符
+			report.Extra = append(report.Extra, item)
+		}
+	}
+	for sk, item := range expected {
+		if !present[sk] {
+			report.Missing = append(report.Missing, item)
+		}
+	}
+	sort.Slice(report.Missing, func(i, j int) bool { return report.Missing[i].SK > report.Missing[j].SK })
+
+	if dryRun {
+		return report, nil
+	}
+
+	// 4. 修正：先刪除再寫入，避免刪掉同一個 SK 上剛補回的項目
+	stale := append(append([]models.UserFeedItem{}, report.Extra...), report.Orphaned...)
+	if err := s.feedRepo.DeleteFeedItems(ctx, stale); err != nil {
+		return nil, err
+	}
+	if err := s.feedRepo.BatchAddToFeed(ctx, report.Missing); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// feedKey 組出 UserFeed 表的主鍵，作為查詢起點
+func feedKey(userID, createdAt string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+		"SK": &types.AttributeValueMemberS{Value: createdAt},
+	}
+}
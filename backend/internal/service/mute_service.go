@@ -0,0 +1,225 @@
+// internal/service/mute_service.go
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/textparse"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxMuteFilters 是每位使用者可以同時生效的靜音條件數量
+	maxMuteFilters = 100
+	// maxMuteValueLength 是單一靜音條件的長度上限 (字元數)
+	maxMuteValueLength = 100
+	// maxMuteDays 是靜音條件可設定的最長有效天數
+	maxMuteDays = 365
+)
+
+var (
+	// ErrInvalidMuteFilter 表示新增的靜音條件不合法
+	ErrInvalidMuteFilter = errors.New("invalid mute filter")
+	// ErrTooManyMuteFilters 表示使用者的靜音條件已達上限
+	ErrTooManyMuteFilters = errors.New("too many mute filters")
+)
+
+// MuteService 管理使用者的靜音條件，並從 Feed 與推薦中過濾符合條件的貼文
+type MuteService struct {
+	muteRepo repository.MuteRepository
+}
+
+// NewMuteService 是 MuteService 的建構子
+func NewMuteService(muteRepo repository.MuteRepository) *MuteService {
+	return &MuteService{muteRepo: muteRepo}
+}
+
+// CreateFilter 驗證並新增靜音條件
+func (s *MuteService) CreateFilter(ctx context.Context, userID string, payload models.CreateMuteFilterPayload) (*models.MuteFilter, error) {
+	kind := strings.ToLower(strings.TrimSpace(payload.Kind))
+	value := strings.TrimSpace(payload.Value)
+	switch kind {
+	case models.MuteKindWord:
+		if strings.IndexFunc(value, unicode.IsSpace) >= 0 {
+			return nil, fmt.Errorf("%w: a word cannot contain spaces, use kind %q instead", ErrInvalidMuteFilter, models.MuteKindPhrase)
+		}
+	case models.MuteKindPhrase:
+	case models.MuteKindHashtag:
+		value = textparse.NormalizeTag(value)
+	default:
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalidMuteFilter, payload.Kind)
+	}
+	if value == "" || utf8.RuneCountInString(value) > maxMuteValueLength {
+		return nil, fmt.Errorf("%w: value must be 1-%d characters", ErrInvalidMuteFilter, maxMuteValueLength)
+	}
+	if payload.ExpiresInDays < 0 || payload.ExpiresInDays > maxMuteDays {
+		return nil, fmt.Errorf("%w: expires_in_days must be between 0 and %d", ErrInvalidMuteFilter, maxMuteDays)
+	}
+
+	active, err := s.ListFilters(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) >= maxMuteFilters {
+		return nil, ErrTooManyMuteFilters
+	}
+
+	filter := &models.MuteFilter{UserID: userID, Kind: kind, Value: value}
+	if payload.ExpiresInDays > 0 {
+		expiresAt := time.Now().UTC().AddDate(0, 0, payload.ExpiresInDays)
+		filter.ExpiresAt = expiresAt.Format(time.RFC3339Nano)
+		filter.TTLTimestamp = expiresAt.Unix()
+	}
+	if err := s.muteRepo.CreateMuteFilter(ctx, filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// ListFilters 列出仍有效的靜音條件。已過期的項目由 DynamoDB TTL 清除，
+// 在清除之前只在這裡略過，不在讀取路徑上寫入
+func (s *MuteService) ListFilters(ctx context.Context, userID string) ([]models.MuteFilter, error) {
+	filters, err := s.muteRepo.ListMuteFilters(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	active := make([]models.MuteFilter, 0, len(filters))
+	for _, f := range filters {
+		if f.ExpiresAt != "" && f.ExpiresAt <= now {
+			continue
+		}
+		active = append(active, f)
+	}
+	return active, nil
+}
+
+// DeleteFilter 刪除靜音條件
+func (s *MuteService) DeleteFilter(ctx context.Context, userID, filterID string) error {
+	return s.muteRepo.DeleteMuteFilter(ctx, userID, filterID)
+}
+
+// ActiveFilters 取得 Feed 要套用的靜音條件；讀取失敗時不過濾，避免 Feed 因此失敗
+func (s *MuteService) ActiveFilters(ctx context.Context, userID string) []models.MuteFilter {
+	filters, err := s.ListFilters(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load mute filters for user %s, serving unfiltered: %v", userID, err)
+		return nil
+	}
+	return filters
+}
+
+// FilterPosts 依原順序回傳沒有被靜音的貼文。
+// 轉發沒有自己的內容，引用只含自己的評論，因此也會比對被嵌入的原始貼文，原始貼文符合條件時一併隱藏；
+// 原始貼文讀取失敗時只比對貼文本身，與 ActiveFilters 一樣不讓 Feed 因此失敗
+func FilterPosts(ctx context.Context, postRepo repository.PostRepository, posts []models.Post, filters []models.MuteFilter) []models.Post {
+	if len(filters) == 0 {
+		return posts
+	}
+	originals := loadOriginals(ctx, postRepo, posts)
+	kept := make([]models.Post, 0, len(posts))
+	for _, post := range posts {
+		if IsMuted(post, filters) {
+			continue
+		}
+		if original, ok := originals[post.OriginalPostID]; ok && IsMuted(original, filters) {
+			continue
+		}
+		kept = append(kept, post)
+	}
+	return kept
+}
+
+// loadOriginals 讀取轉發與引用貼文所嵌入的原始貼文，以貼文 ID 為索引
+func loadOriginals(ctx context.Context, postRepo repository.PostRepository, posts []models.Post) map[string]models.Post {
+	var originalIDs []string
+	seen := make(map[string]bool)
+	for _, post := range posts {
+		if post.OriginalPostID != "" && !seen[post.OriginalPostID] {
+			seen[post.OriginalPostID] = true
+			originalIDs = append(originalIDs, post.OriginalPostID)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return nil
+	}
+	found, err := postRepo.GetPostsByIDs(ctx, originalIDs)
+	if err != nil {
+		log.Printf("Failed to load original posts for mute filtering: %v", err)
+		return nil
+	}
+	originals := make(map[string]models.Post, len(found))
+	for _, post := range found {
+		originals[post.PostID] = post
+	}
+	return originals
+}
+
+// IsMuted 檢查貼文是否符合任一個靜音條件
+func IsMuted(post models.Post, filters []models.MuteFilter) bool {
+	if len(filters) == 0 {
+		return false
+	}
+	content := strings.ToLower(post.Content)
+	var tags map[string]bool
+	for _, f := range filters {
+		switch f.Kind {
+		case models.MuteKindWord:
+			if containsWord(content, strings.ToLower(f.Value)) {
+				return true
+			}
+		case models.MuteKindPhrase:
+			if strings.Contains(content, strings.ToLower(f.Value)) {
+				return true
+			}
+		case models.MuteKindHashtag:
+			if tags == nil {
+				tags = make(map[string]bool)
+				for _, tag := range post.Tags {
+					tags[textparse.NormalizeTag(tag)] = true
+				}
+				for _, tag := range textparse.ExtractHashtags(post.Content) {
+					tags[textparse.NormalizeTag(tag)] = true
+				}
+			}
+			if tags[f.Value] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsWord 檢查 word 是否以完整單字出現在 content 中 (兩者皆已轉小寫)。
+// 中日文不以空白分詞，因此漢字與假名視為單字邊界，相當於子字串比對。
+func containsWord(content, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(content[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(content[:i])
+		after, _ := utf8.DecodeRuneInString(content[end:])
+		if (i == 0 || !isWordRune(before)) && (end == len(content) || !isWordRune(after)) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(content[i:])
+		start = i + size
+	}
+}
+
+func isWordRune(r rune) bool {
+	if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,325 @@
+// internal/service/notification_service.go
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/realtime"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// maxGroupedActors 是群組通知中保留的最近使用者數量
+	maxGroupedActors = 3
+	// maxGroupRetries 是群組通知遇到並行修改時的重試次數
+	maxGroupRetries = 3
+)
+
+// NotificationService 負責建立、列出與標記站內通知
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	userRepo         repository.UserRepository
+	hub              *realtime.Hub // 可為 nil，代表不做即時推播
+}
+
+// NewNotificationService 是 NotificationService 的建構子
+func NewNotificationService(notificationRepo repository.NotificationRepository, userRepo repository.UserRepository, hub *realtime.Hub) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		hub:              hub,
+	}
+}
+
+// ErrInvalidNotificationPreferences 表示更新通知偏好的內容不合法
+var ErrInvalidNotificationPreferences = errors.New("invalid notification preferences")
+
+// NotifyPostLiked 通知貼文作者有人按讚，同一篇貼文的未讀按讚通知會合併為一則
+func (s *NotificationService) NotifyPostLiked(ctx context.Context, post *models.Post, actorID string) {
+	s.notifyGrouped(ctx, post.AuthorID, actorID, models.NotificationTypeNewLike, post.PostID, "")
+}
+
+// NotifyPostCommented 通知貼文作者有新評論，同一篇貼文的未讀評論通知會合併為一則
+func (s *NotificationService) NotifyPostCommented(ctx context.Context, post *models.Post, actorID, commentID string) {
+	s.notifyGrouped(ctx, post.AuthorID, actorID, models.NotificationTypeNewComment, post.PostID, commentID)
+}
+
+// NotifyCommentLiked 通知評論作者有人按讚，同一則評論的未讀按讚通知會合併為一則
+func (s *NotificationService) NotifyCommentLiked(ctx context.Context, comment *models.Comment, actorID string) {
+	s.notifyGrouped(ctx, comment.AuthorID, actorID, models.NotificationTypeCommentLike, comment.PostID, comment.CommentID)
+}
+
+// NotifyPostReposted 通知原始貼文的作者有人轉發或引用了貼文，同一篇貼文的通知會合併
+func (s *NotificationService) NotifyPostReposted(ctx context.Context, original *models.Post, actorID, repostID string) {
+	s.notifyGrouped(ctx, original.AuthorID, actorID, models.NotificationTypeRepost, original.PostID, repostID)
+}
+
+// NotifyFollowed 通知使用者有新的粉絲
+func (s *NotificationService) NotifyFollowed(ctx context.Context, followedID, followerID string) {
+	if followedID == followerID || !s.wantsNotification(ctx, followedID, models.NotificationTypeNewFollower) {
+		return
+	}
+	notification := &models.Notification{
+		RecipientUserID:  followedID,
+		NotificationType: models.NotificationTypeNewFollower,
+		ActorID:          followerID,
+		Message:          fmt.Sprintf("%s started following you.", s.actorName(followerID)),
+	}
+	if err := s.notificationRepo.CreateNotification(ctx, notification); err != nil {
+		log.Printf("Failed to create follow notification for user %s: %v", followedID, err)
+		return
+	}
+	s.push(ctx, notification, nil)
+}
+
+// NotifyMention 通知使用者在貼文或評論中被提及；commentID 為空代表是在貼文內文中被提及
+func (s *NotificationService) NotifyMention(ctx context.Context, recipientID, actorID, postID, commentID string) {
+	if recipientID == actorID || !s.wantsNotification(ctx, recipientID, models.NotificationTypeMention) {
+		return
+	}
+
+	where := "a post"
+	if commentID != "" {
+		where = "a comment"
+	}
+	notification := &models.Notification{
+		RecipientUserID:  recipientID,
+		NotificationType: models.NotificationTypeMention,
+		ActorID:          actorID,
+		TargetEntityType: "POST",
+		TargetEntityID:   postID,
+		RelatedEntityID:  commentID,
+		Message:          fmt.Sprintf("%s mentioned you in %s.", s.actorName(actorID), where),
+	}
+	if err := s.notificationRepo.CreateNotification(ctx, notification); err != nil {
+		log.Printf("Failed to create mention notification for user %s: %v", recipientID, err)
+		return
+	}
+	s.push(ctx, notification, nil)
+}
+
+// ListNotifications 分頁列出使用者的通知，最新的在前
+func (s *NotificationService) ListNotifications(ctx context.Context, userID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) (*models.PaginatedNotifications, error) {
+	page, err := s.notificationRepo.ListNotifications(ctx, userID, limit, lastEvaluatedKey)
+	if err != nil {
+		return nil, err
+	}
+	if page.Items == nil {
+		page.Items = []models.Notification{}
+	}
+	return page, nil
+}
+
+// MarkAsRead 將單一通知標記為已讀
+func (s *NotificationService) MarkAsRead(ctx context.Context, userID, notificationSK string) error {
+	return s.notificationRepo.MarkAsRead(ctx, userID, notificationSK)
+}
+
+// MarkAllAsRead 將所有未讀通知標記為已讀，回傳更新的筆數
+func (s *NotificationService) MarkAllAsRead(ctx context.Context, userID string) (int, error) {
+	return s.notificationRepo.MarkAllAsRead(ctx, userID)
+}
+
+// GetUnreadCount 取得未讀通知數量
+func (s *NotificationService) GetUnreadCount(ctx context.Context, userID string) (int, error) {
+	return s.notificationRepo.CountUnread(ctx, userID)
+}
+
+// GetPreferences 取得使用者的通知偏好，尚未設定的類別以預設值補齊
+func (s *NotificationService) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+	prefs, err := s.notificationRepo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defaults := models.DefaultNotificationPreferences(userID)
+	if prefs == nil {
+		return defaults, nil
+	}
+	if prefs.Channels == nil {
+		prefs.Channels = make(map[string]string)
+	}
+	for category, channel := range defaults.Channels {
+		if _, ok := prefs.Channels[category]; !ok {
+			prefs.Channels[category] = channel
+		}
+	}
+	if prefs.DigestFrequency == "" {
+		prefs.DigestFrequency = defaults.DigestFrequency
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences 驗證並合併使用者提交的通知偏好，回傳更新後的完整設定
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID string, payload models.UpdateNotificationPreferencesPayload) (*models.NotificationPreferences, error) {
+	prefs, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for category, channel := range payload.Channels {
+		if !isNotificationCategory(category) {
+			return nil, fmt.Errorf("%w: unknown category %q", ErrInvalidNotificationPreferences, category)
+		}
+		switch channel {
+		case models.NotificationChannelInApp, models.NotificationChannelEmail, models.NotificationChannelOff:
+			prefs.Channels[category] = channel
+		default:
+			return nil, fmt.Errorf("%w: unknown channel %q for %s", ErrInvalidNotificationPreferences, channel, category)
+		}
+	}
+
+	switch payload.DigestFrequency {
+	case "":
+	case models.DigestFrequencyOff, models.DigestFrequencyDaily, models.DigestFrequencyWeekly:
+		prefs.DigestFrequency = payload.DigestFrequency
+	default:
+		return nil, fmt.Errorf("%w: unknown digest frequency %q", ErrInvalidNotificationPreferences, payload.DigestFrequency)
+	}
+
+	prefs.UserID = userID
+	if err := s.notificationRepo.SavePreferences(ctx, prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// isNotificationCategory 檢查是否為可設定偏好的類別
+func isNotificationCategory(category string) bool {
+	for _, c := range models.NotificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsNotification 檢查收件者是否關閉了此類型的通知；讀取偏好失敗時仍建立通知
+func (s *NotificationService) wantsNotification(ctx context.Context, recipientID, notificationType string) bool {
+	prefs, err := s.notificationRepo.GetPreferences(ctx, recipientID)
+	if err != nil {
+		log.Printf("Failed to read notification preferences for user %s, defaulting to in-app: %v", recipientID, err)
+		return true
+	}
+	if prefs == nil {
+		return true
+	}
+	return prefs.ChannelFor(notificationType) != models.NotificationChannelOff
+}
+
+// notifyGrouped 建立或合併同類型、同一篇貼文的未讀通知，例如 "A and 5 others liked your post."
+func (s *NotificationService) notifyGrouped(ctx context.Context, recipientID, actorID, notificationType, postID, relatedID string) {
+	if recipientID == "" || recipientID == actorID || !s.wantsNotification(ctx, recipientID, notificationType) {
+		return
+	}
+	groupKey := notificationType + "#" + postID
+	// 評論按讚以評論為單位合併，同一篇貼文中不同評論的按讚各自成為一則通知
+	if notificationType == models.NotificationTypeCommentLike {
+		groupKey = notificationType + "#" + relatedID
+	}
+
+	for attempt := 0; attempt < maxGroupRetries; attempt++ {
+		previous, pointerSK, err := s.notificationRepo.GetOpenGroupNotification(ctx, recipientID, groupKey)
+		if err != nil {
+			log.Printf("Failed to read notification group %s for user %s: %v", groupKey, recipientID, err)
+			return
+		}
+
+		actorIDs := []string{actorID}
+		actorCount := 1
+		if previous != nil {
+			alreadyCounted := false
+			for _, id := range previous.ActorIDs {
+				if id == actorID {
+					alreadyCounted = true
+					continue
+				}
+				if len(actorIDs) < maxGroupedActors {
+					actorIDs = append(actorIDs, id)
+				}
+			}
+			actorCount = previous.ActorCount
+			if actorCount == 0 {
+				actorCount = len(previous.ActorIDs)
+			}
+			if !alreadyCounted {
+				actorCount++
+			}
+		}
+
+		next := &models.Notification{
+			RecipientUserID:  recipientID,
+			NotificationType: notificationType,
+			ActorID:          actorID,
+			ActorIDs:         actorIDs,
+			ActorCount:       actorCount,
+			GroupKey:         groupKey,
+			TargetEntityType: "POST",
+			TargetEntityID:   postID,
+			RelatedEntityID:  relatedID,
+			Message:          s.groupedMessage(notificationType, actorID, actorCount),
+		}
+
+		err = s.notificationRepo.SaveGroupNotification(ctx, pointerSK, previous, next)
+		if err == nil {
+			s.push(ctx, next, previous)
+			return
+		}
+		if !errors.Is(err, repository.ErrNotificationGroupConflict) {
+			log.Printf("Failed to save grouped notification %s for user %s: %v", groupKey, recipientID, err)
+			return
+		}
+	}
+	log.Printf("Gave up grouping notification %s for user %s after %d conflicts", groupKey, recipientID, maxGroupRetries)
+}
+
+// notificationEvent 是推播給客戶端的通知事件；群組通知更新時 replaces 為被取代的舊通知 SK
+type notificationEvent struct {
+	Notification *models.Notification `json:"notification"`
+	Replaces     string               `json:"replaces,omitempty"`
+}
+
+// push 將新建立的通知即時推播給收件者
+func (s *NotificationService) push(ctx context.Context, notification, replaced *models.Notification) {
+	event := notificationEvent{Notification: notification}
+	if replaced != nil {
+		event.Replaces = replaced.SK
+	}
+	s.hub.PublishToUser(ctx, notification.RecipientUserID, realtime.EventNotification, event)
+}
+
+// groupedMessage 產生群組通知的訊息文字
+func (s *NotificationService) groupedMessage(notificationType, actorID string, actorCount int) string {
+	action := "liked your post."
+	switch notificationType {
+	case models.NotificationTypeNewComment:
+		action = "commented on your post."
+	case models.NotificationTypeCommentLike:
+		action = "liked your comment."
+	case models.NotificationTypeRepost:
+		action = "reposted your post."
+	}
+
+	name := s.actorName(actorID)
+	switch others := actorCount - 1; {
+	case others <= 0:
+		return fmt.Sprintf("%s %s", name, action)
+	case others == 1:
+		return fmt.Sprintf("%s and 1 other %s", name, action)
+	default:
+		return fmt.Sprintf("%s and %d others %s", name, others, action)
+	}
+}
+
+// actorName 取得觸發通知的使用者名稱，查不到時退回使用 ID
+func (s *NotificationService) actorName(actorID string) string {
+	user, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return "User ID: " + actorID
+	}
+	return user.Username
+}
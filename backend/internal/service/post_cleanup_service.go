@@ -0,0 +1,296 @@
+// internal/service/post_cleanup_service.go
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/queue"
+	"backend/internal/recommendation"
+	"backend/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// JobTypeCleanupPost 是清理已軟刪除貼文相依資料的佇列工作類型
+const JobTypeCleanupPost = "cleanup_post"
+
+// staleDeletionAge 是軟刪除標記存在超過此時間仍未清理時，對帳指令視為清理失敗
+const staleDeletionAge = time.Hour
+
+// existenceCheckBatch 是對帳時一次確認存在與否的貼文數量
+const existenceCheckBatch = 100
+
+// cleanupJobPayload 是清理工作的內容
+type cleanupJobPayload struct {
+	PostID string `json:"post_id"`
+	PK     string `json:"pk"`
+	SK     string `json:"sk"`
+}
+
+// OrphanReport 是對帳指令的結果
+type OrphanReport struct {
+	StaleDeletedPosts     []string // 軟刪除後超過 staleDeletionAge 仍未清理的貼文
+	OrphanChildPostIDs    []string // 貼文已不存在，但仍有按讚 / 評論的貼文 ID
+	OrphanFeedItems       []models.UserFeedItem
+	OrphanRecommendations []models.UserRecommendationItem
+}
+
+// PostCleanupService 負責刪除貼文後在 Posts、UserFeed 與 UserRecommendations 三張表中的連鎖清理
+type PostCleanupService struct {
+	postRepo repository.PostRepository
+	feedRepo repository.FeedRepository
+	recoRepo repository.RecommendationRepository
+	userRepo repository.UserRepository
+	// feedService 判斷作者是否使用 fan-out；為 nil 時視為所有作者都有 fan-out
+	feedService *FeedService
+}
+
+// NewPostCleanupService 是 PostCleanupService 的建構子
+func NewPostCleanupService(postRepo repository.PostRepository, feedRepo repository.FeedRepository, recoRepo repository.RecommendationRepository, userRepo repository.UserRepository, feedService *FeedService) *PostCleanupService {
+	return &PostCleanupService{
+		postRepo:    postRepo,
+		feedRepo:    feedRepo,
+		recoRepo:    recoRepo,
+		userRepo:    userRepo,
+		feedService: feedService,
+	}
+}
+
+// HandleCleanupJob 是清理工作的 Handler
+func (s *PostCleanupService) HandleCleanupJob(ctx context.Context, job *queue.Job) error {
+	var payload cleanupJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid cleanup payload: %w", err)
+	}
+
+	post, err := s.postRepo.ReloadPost(ctx, &models.Post{PostID: payload.PostID, PK: payload.PK, SK: payload.SK})
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			return nil // 先前的執行已完成清理
+		}
+		return err
+	}
+	return s.CleanupPost(ctx, post)
+}
+
+// CleanupPost 刪除已軟刪除貼文的所有相依資料，最後才刪除貼文本身。
+// 每一步都可以重複執行；中途失敗時貼文與軟刪除標記仍在，重試或對帳指令可以從頭再跑一次。
+func (s *PostCleanupService) CleanupPost(ctx context.Context, post *models.Post) error {
+	if post.DeletedAt == "" {
+		return fmt.Errorf("post %s is not marked as deleted", post.PostID)
+	}
+
+	// 0. 單純轉發沒有自己的內容，隨原始貼文一起刪除 (引用則保留，顯示為原始貼文無法查看)
+	if err := s.removeReposts(ctx, post.PostID); err != nil {
+		return err
+	}
+
+	// 1. Posts 表：按讚、評論與轉發紀錄
+	children, err := s.postRepo.DeletePostChildren(ctx, post.PostID)
+	if err != nil {
+		return err
+	}
+
+	// 2. UserFeed 表：fan-out 到粉絲 Feed 的項目 (已取消追蹤者的項目由 prune 工作處理)。
+	// 高粉絲作者的貼文是在讀取時拉取的，沒有寫入粉絲的 Feed，不必對每位粉絲逐筆刪除；
+	// 作者在發文後才跨過門檻而殘留的項目，讀取 Feed 時會因貼文不存在而略過，並由對帳指令清除
+	feedItems, err := s.removeFromFeeds(ctx, post)
+	if err != nil {
+		return err
+	}
+
+	// 3. UserRecommendations 表：全域熱門列表
+	recommendations, err := s.recoRepo.RemovePostFromTrending(ctx, recommendation.TrendingAlgorithmKey, post.PostID)
+	if err != nil {
+		return err
+	}
+
+	// 4. 貼文本身 (同時移除殘留的標籤索引)
+	if err := s.postRepo.DeletePost(ctx, post.AuthorID, post.PostID, post.CreatedAt); err != nil {
+		return err
+	}
+
+	log.Printf("Cleaned up post %s: %d children, %d feed items, %d recommendations removed", post.PostID, children, feedItems, recommendations)
+	return nil
+}
+
+// removeFromFeeds 從粉絲的 Feed 刪除貼文，回傳刪除的筆數；不使用 fan-out 的作者直接略過
+func (s *PostCleanupService) removeFromFeeds(ctx context.Context, post *models.Post) (int, error) {
+	if s.feedService != nil {
+		push, err := s.feedService.ShouldFanOut(post.AuthorID)
+		if err != nil {
+			return 0, err
+		}
+		if !push {
+			return 0, nil
+		}
+	}
+	followers, err := s.userRepo.GetFollowers(post.AuthorID)
+	if err != nil {
+		return 0, err
+	}
+	followerIDs := make([]string, 0, len(followers))
+	for _, follower := range followers {
+		followerIDs = append(followerIDs, follower.ID)
+	}
+	return s.feedRepo.RemovePostFromFeeds(ctx, followerIDs, post.CreatedAt, post.PostID)
+}
+
+// removeReposts 軟刪除並清理某篇貼文的所有單純轉發；已被刪除的轉發會被略過，因此可以重複執行
+func (s *PostCleanupService) removeReposts(ctx context.Context, postID string) error {
+	links, err := s.postRepo.ListRepostLinks(ctx, postID)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		repost, err := s.postRepo.GetPostByID(ctx, link.RepostPostID)
+		if err != nil {
+			if errors.Is(err, repository.ErrPostNotFound) {
+				continue // 已軟刪除，會由它自己的清理工作處理
+			}
+			// 其他讀取錯誤 (例如節流) 不能當成已刪除，否則原始貼文被刪除後轉發仍會留著
+			return err
+		}
+		if err := s.postRepo.MarkPostDeleted(ctx, repost); err != nil {
+			if errors.Is(err, repository.ErrPostNotFound) {
+				continue // 轉發者剛好同時取消了轉發
+			}
+			return err
+		}
+		if err := s.CleanupPost(ctx, repost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReconcileOrphans 掃描三張表找出殘留的資料；fix 為 true 時一併修復。
+// 這是全表掃描，應以指令的方式在離峰時間執行，而不是放在請求路徑上。
+func (s *PostCleanupService) ReconcileOrphans(ctx context.Context, fix bool) (*OrphanReport, error) {
+	report := &OrphanReport{}
+	cache := make(map[string]bool)
+
+	// 1. 清理停滯的軟刪除貼文
+	deleted, err := s.postRepo.ScanDeletedPosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().UTC().Add(-staleDeletionAge).Format(time.RFC3339Nano)
+	for i := range deleted {
+		post := &deleted[i]
+		if post.DeletedAt > cutoff {
+			continue // 清理工作可能仍在佇列中
+		}
+		report.StaleDeletedPosts = append(report.StaleDeletedPosts, post.PostID)
+		if fix {
+			if err := s.CleanupPost(ctx, post); err != nil {
+				log.Printf("Reconcile: failed to clean up post %s: %v", post.PostID, err)
+			}
+		}
+	}
+
+	// 2. 貼文已不存在的按讚 / 評論
+	childIDs, err := s.postRepo.ScanChildPostIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := s.existingPosts(ctx, childIDs, cache)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range childIDs {
+		if exists[id] {
+			continue
+		}
+		report.OrphanChildPostIDs = append(report.OrphanChildPostIDs, id)
+		if fix {
+			if _, err := s.postRepo.DeletePostChildren(ctx, id); err != nil {
+				log.Printf("Reconcile: failed to delete children of post %s: %v", id, err)
+			}
+		}
+	}
+
+	// 3. 指向不存在貼文的 Feed 項目
+	err = s.feedRepo.ScanFeedItems(ctx, func(items []models.UserFeedItem) error {
+		ids := make([]string, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.PostID)
+		}
+		exists, err := s.existingPosts(ctx, ids, cache)
+		if err != nil {
+			return err
+		}
+		var orphans []models.UserFeedItem
+		for _, item := range items {
+			if !exists[item.PostID] {
+				orphans = append(orphans, item)
+			}
+		}
+		report.OrphanFeedItems = append(report.OrphanFeedItems, orphans...)
+		if fix && len(orphans) > 0 {
+			return s.feedRepo.DeleteFeedItems(ctx, orphans)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. 指向不存在貼文的推薦項目
+	err = s.recoRepo.ScanRecommendationItems(ctx, func(items []models.UserRecommendationItem) error {
+		ids := make([]string, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.PostID)
+		}
+		exists, err := s.existingPosts(ctx, ids, cache)
+		if err != nil {
+			return err
+		}
+		var orphans []models.UserRecommendationItem
+		for _, item := range items {
+			if !exists[item.PostID] {
+				orphans = append(orphans, item)
+			}
+		}
+		report.OrphanRecommendations = append(report.OrphanRecommendations, orphans...)
+		if fix && len(orphans) > 0 {
+			return s.recoRepo.DeleteRecommendationItems(ctx, orphans)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// existingPosts 確認貼文是否存在 (已軟刪除的視為不存在)，結果會記錄在 cache 中避免重複查詢
+func (s *PostCleanupService) existingPosts(ctx context.Context, postIDs []string, cache map[string]bool) (map[string]bool, error) {
+	var unknown []string
+	for _, id := range postIDs {
+		if _, ok := cache[id]; !ok {
+			unknown = append(unknown, id)
+			cache[id] = false
+		}
+	}
+
+	for i := 0; i < len(unknown); i += existenceCheckBatch {
+		end := i + existenceCheckBatch
+		if end > len(unknown) {
+			end = len(unknown)
+		}
+		// GetPostsByIDs 會略過讀取失敗的貼文，不能用來判斷是否存在；任何讀取失敗都中止，避免誤刪仍存在的貼文的資料
+		found, err := s.postRepo.CheckPostsExist(ctx, unknown[i:end])
+		if err != nil {
+			return nil, err
+		}
+		for id, ok := range found {
+			cache[id] = ok
+		}
+	}
+	return cache, nil
+}
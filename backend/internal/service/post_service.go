@@ -3,70 +3,192 @@ package service
 
 import (
 	"backend/internal/models"
+	"backend/internal/queue"
+	"backend/internal/realtime"
 	"backend/internal/repository"
+	"backend/internal/textparse"
 	"context"
+	"encoding/json"
 	"errors"
 	"log"
+	"sort"
+	"sync"
 	"time"
 	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// maxTagsPerPost 限制單篇貼文的標籤數量，標籤索引與貼文寫在同一個交易中 (上限 100 個項目)
+const maxTagsPerPost = 30
+
+// ErrInvalidVisibility 表示貼文的 visibility 不是支援的值
+var ErrInvalidVisibility = errors.New("visibility must be public or followers")
+
+// ErrPostNotVisible 表示瀏覽者沒有權限查看此貼文 (僅限粉絲的貼文)
+var ErrPostNotVisible = errors.New("post is only visible to the author's followers")
+
+// ErrNotPostOwner 表示操作者不是貼文的作者，不能刪除貼文
+var ErrNotPostOwner = errors.New("user not authorized to delete this post")
+
+// ErrRepostNotAllowed 表示貼文不是公開的，不能被轉發或引用
+var ErrRepostNotAllowed = errors.New("only public posts can be reposted or quoted")
+
+// ErrRepostNotEditable 表示單純轉發沒有自己的內容，不能被編輯
+var ErrRepostNotEditable = errors.New("reposts cannot be edited")
+
+// PostDetailCommentLimit 是單篇貼文頁面附帶的第一頁評論數
+const PostDetailCommentLimit = 20
+
+// CommentReplyPreviewCount 是評論列表中每則頂層評論附帶的回覆數量，其餘回覆透過回覆列表端點分頁讀取
+const CommentReplyPreviewCount = 3
+
+// ProfileRefillRounds 是作者貼文被可見性過濾後，最多讀取幾頁來補滿一頁
+const ProfileRefillRounds = 3
+
+// JobTypeFanOutPost 是將新貼文寫入粉絲 Feed 的佇列工作類型
+const JobTypeFanOutPost = "fanout_post"
+
+// fanOutJobPayload 只記錄貼文的主鍵，執行時再以強一致讀取取得貼文，已刪除的貼文就不再 fan-out
+type fanOutJobPayload struct {
+	PostID string `json:"post_id"`
+	PK     string `json:"pk"`
+	SK     string `json:"sk"`
+}
+
 type PostService struct {
-	postRepo repository.PostRepository
-	userRepo repository.UserRepository 
-	feedRepo repository.FeedRepository // <--- 新增 feed repository
+	postRepo            repository.PostRepository
+	userRepo            repository.UserRepository 
+	feedRepo            repository.FeedRepository // <--- 新增 feed repository
+	feedService         *FeedService              // 決定作者是否使用 fan-out
+	notificationService *NotificationService
+	hub                 *realtime.Hub                 // 可為 nil，代表不做即時推播
+	jobQueue            queue.Queue                   // fan-out 工作佇列，可為 nil (退回在 goroutine 中直接執行)
+	affinityRepo        repository.AffinityRepository // 排序 Feed 使用的作者親密度，可為 nil
+	bookmarkRepo        repository.BookmarkRepository // 標示瀏覽者是否收藏過貼文，可為 nil
 }
 
 
-func NewPostService(postRepo repository.PostRepository, userRepo repository.UserRepository, feedRepo repository.FeedRepository) *PostService {
+func NewPostService(postRepo repository.PostRepository, userRepo repository.UserRepository, feedRepo repository.FeedRepository, feedService *FeedService, notificationService *NotificationService, hub *realtime.Hub, jobQueue queue.Queue, affinityRepo repository.AffinityRepository, bookmarkRepo repository.BookmarkRepository) *PostService {
 	return &PostService{
-		postRepo: postRepo,
-		userRepo: userRepo,
-		feedRepo: feedRepo, // <--- 初始化 feed repository
+		postRepo:            postRepo,
+		userRepo:            userRepo,
+		feedRepo:            feedRepo, // <--- 初始化 feed repository
+		feedService:         feedService,
+		notificationService: notificationService,
+		hub:                 hub,
+		jobQueue:            jobQueue,
+		affinityRepo:        affinityRepo,
+		bookmarkRepo:        bookmarkRepo,
 	}
 }
 
 // CreatePost 處理創建貼文的邏輯
 func (s *PostService) CreatePost(ctx context.Context, payload models.CreatePostPayload) (*models.Post, error) {
+	visibility := payload.Visibility
+	if visibility == "" {
+		visibility = models.PostVisibilityPublic
+	}
+	if !models.ValidPostVisibility(visibility) {
+		return nil, ErrInvalidVisibility
+	}
+
 	post := &models.Post{
-		AuthorID: payload.AuthorID,
-		Content:  payload.Content,
-		Media:    payload.Media,
-		Tags:     payload.Tags,
-		Location: payload.Location,
+		AuthorID:   payload.AuthorID,
+		Content:    payload.Content,
+		Media:      payload.Media,
+		Tags:       resolvePostTags(payload.Tags, payload.Content),
+		Location:   payload.Location,
+		Visibility: visibility,
 	}
+	post.Mentions, post.MentionSpans = s.resolveMentions(payload.Content)
 
 	if err := s.postRepo.CreatePost(ctx, post); err != nil {
 		log.Printf("Error creating post in service: %v", err)
 		return nil, err
 	}
 
+	s.enqueueFanOut(ctx, post)
+	go s.notifyMentions(post.Mentions, post.AuthorID, post.PostID, "")
+
+	return post, nil
+}
+
+// enqueueFanOut 將 fan-out 放入持久化佇列；佇列無法使用時退回在 goroutine 中直接執行
+func (s *PostService) enqueueFanOut(ctx context.Context, post *models.Post) {
+	if s.jobQueue != nil {
+		job, err := queue.NewJob(JobTypeFanOutPost, fanOutJobPayload{PostID: post.PostID, PK: post.PK, SK: post.SK})
+		if err == nil {
+			err = s.jobQueue.Enqueue(ctx, job)
+		}
+		if err == nil {
+			return
+		}
+		log.Printf("Failed to enqueue fan-out for post %s, falling back to in-process fan-out: %v", post.PostID, err)
+	}
 	go s.fanOutToFollowers(post)
+}
 
-	// Fan-out logic would go here in a real application
+// HandleFanOutJob 是 fan-out 工作的 Handler。
+// 寫入 UserFeed 的項目以 (粉絲, 貼文時間) 為主鍵，重複執行只會覆寫相同的項目，因此可以安全地重試。
+func (s *PostService) HandleFanOutJob(ctx context.Context, job *queue.Job) error {
+	var payload fanOutJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return fmt.Errorf("invalid fan-out payload: %w", err)
+	}
 
-	return post, nil
+	post, err := s.postRepo.ReloadPost(ctx, &models.Post{PostID: payload.PostID, PK: payload.PK, SK: payload.SK})
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			log.Printf("Skipping fan-out for post %s: post no longer exists", payload.PostID)
+			return nil
+		}
+		return err
+	}
+	if post.DeletedAt != "" {
+		log.Printf("Skipping fan-out for post %s: post has been deleted", payload.PostID)
+		return nil
+	}
+	return s.fanOut(ctx, post)
 }
 
 
 func (s *PostService) fanOutToFollowers(post *models.Post) {
     // 建立一個新的 context，因為原始的 HTTP request context 可能在 fan-out 完成前就結束了
-    ctx := context.Background()
+    if err := s.fanOut(context.Background(), post); err != nil {
+        log.Printf("Fan-out for post %s failed: %v", post.PostID, err)
+    }
+}
+
+// fanOut 將貼文寫入所有粉絲的 Feed，失敗時回傳錯誤讓佇列重試
+func (s *PostService) fanOut(ctx context.Context, post *models.Post) error {
+    // 0. 高粉絲帳號改為在讀取 Feed 時拉取，不寫入每位粉絲的 UserFeed
+    if s.feedService != nil {
+        push, err := s.feedService.ShouldFanOut(post.AuthorID)
+        if err != nil {
+            log.Printf("Error counting followers for user %s: %v", post.AuthorID, err)
+            return err
+        }
+        if !push {
+            log.Printf("User %s is above the fan-out threshold; post %s will be pulled at read time.", post.AuthorID, post.PostID)
+            return nil
+        }
+    }
 
     // 1. 獲取發文者的粉絲列表
     followers, err := s.userRepo.GetFollowers(post.AuthorID)
     if err != nil {
         log.Printf("Error getting followers for user %s: %v", post.AuthorID, err)
-        return
+        return err
     }
     
     if len(followers) == 0 {
         log.Printf("User %s has no followers to fan-out to.", post.AuthorID)
-        return
+        return nil
     }
     
     // 設定 Feed 內容的存活時間 (TTL)，例如 90 天
-    ttl := time.Now().Add(90 * 24 * time.Hour).Unix()
+    ttl := time.Now().Add(feedRetention).Unix()
 
     var feedItems []models.UserFeedItem
     for _, follower := range followers {
@@ -83,49 +205,278 @@ func (s *PostService) fanOutToFollowers(post *models.Post) {
     // 3. 使用 BatchWriteItem 進行批量寫入以提高效率
     if err := s.feedRepo.BatchAddToFeed(ctx, feedItems); err != nil {
         log.Printf("Failed to execute batch add to feed for post %s: %v", post.PostID, err)
+        return err
     }
 
     log.Printf("Fanning out post %s to %d followers.", post.PostID, len(followers))
+
+    // 4. 即時推播新動態給在線的粉絲；新貼文尚無人按讚，所有粉絲看到的內容相同
+    if s.hub != nil {
+        dtos := s.BuildPostFeedDTOs(ctx, []models.Post{*post}, "")
+        for _, follower := range followers {
+            s.hub.PublishToUser(ctx, follower.ID, realtime.EventFeedItem, dtos[0])
+        }
+    }
+    return nil
+}
+
+func (s *PostService) GetPostsByUserID(ctx context.Context, userID string, viewerID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) ([]models.PostFeedDTO, map[string]types.AttributeValue, error) {
+	// 1. 從 repository 分頁讀取貼文 (最新的在前)。
+	// 已刪除或瀏覽者無權查看的貼文會被過濾，因此繼續往下讀，盡量補滿一頁
+	var posts []models.Post
+	nextKey := lastEvaluatedKey
+	for round := 0; int32(len(posts)) < limit && round < ProfileRefillRounds; round++ {
+		page, err := s.postRepo.GetPostsPageByUserID(ctx, userID, limit-int32(len(posts)), nextKey)
+		if err != nil {
+			log.Printf("Error getting posts from repo for user ID %s: %v", userID, err)
+			return nil, nil, err
+		}
+		posts = append(posts, s.filterVisible(ctx, page.Items, viewerID)...)
+		nextKey = page.LastEvaluatedKey
+		if len(nextKey) == 0 {
+			break
+		}
+	}
+
+	if len(posts) == 0 {
+		return []models.PostFeedDTO{}, nextKey, nil
+	}
+
+	// 2. 轉換為 DTO，並附上作者名稱與瀏覽者的按讚狀態
+	return s.BuildPostFeedDTOs(ctx, posts, viewerID), nextKey, nil
+}
+
+// GetPostsByTag 依 hashtag 取得貼文 (最新的在前)，略過符合 filters 的貼文，並回傳下一頁的起始鍵
+func (s *PostService) GetPostsByTag(ctx context.Context, tag string, viewerID string, filters []models.MuteFilter, limit int32, lastEvaluatedKey map[string]types.AttributeValue) ([]models.PostFeedDTO, map[string]types.AttributeValue, error) {
+	page, err := s.postRepo.GetTagTimeline(ctx, tag, limit, lastEvaluatedKey)
+	if err != nil {
+		log.Printf("Error getting tag timeline for tag %s: %v", tag, err)
+		return nil, nil, err
+	}
+
+	if len(page.Items) == 0 {
+		return []models.PostFeedDTO{}, page.LastEvaluatedKey, nil
+	}
+
+	postIDs := make([]string, 0, len(page.Items))
+	for _, item := range page.Items {
+		postIDs = append(postIDs, item.PostID)
+	}
+
+	posts, err := s.postRepo.GetPostsByIDs(ctx, postIDs)
+	if err != nil {
+		log.Printf("Error fetching posts for tag %s: %v", tag, err)
+		return nil, nil, err
+	}
+
+	// GetPostsByIDs 為並行查詢，需依索引的順序重新排序
+	postOrder := make(map[string]int, len(postIDs))
+	for i, id := range postIDs {
+		postOrder[id] = i
+	}
+	sort.SliceStable(posts, func(i, j int) bool {
+		return postOrder[posts[i].PostID] < postOrder[posts[j].PostID]
+	})
+
+	// 僅限粉絲的貼文不出現在標籤列表中，作者本人除外
+	visible := posts[:0]
+	for _, post := range posts {
+		if post.IsPublic() || post.AuthorID == viewerID {
+			visible = append(visible, post)
+		}
+	}
+
+	visible = FilterPosts(ctx, s.postRepo, visible, filters)
+
+	return s.BuildPostFeedDTOs(ctx, visible, viewerID), page.LastEvaluatedKey, nil
 }
 
-func (s *PostService) GetPostsByUserID(ctx context.Context, userID string, viewerID string) ([]models.PostFeedDTO, error) {
-    // 1. 從 repository 獲取原始的貼文資料 (此處已按時間排序)
-    posts, err := s.postRepo.GetPostsByUserID(ctx, userID)
+// CanView 判斷瀏覽者能否看到貼文；viewerID 為空代表未登入的訪客，只能看到公開貼文
+func (s *PostService) CanView(ctx context.Context, post *models.Post, viewerID string) bool {
+	if post.IsPublic() || post.AuthorID == viewerID {
+		return true
+	}
+	if viewerID == "" {
+		return false
+	}
+	following, err := s.userRepo.IsFollowing(viewerID, post.AuthorID)
+	if err != nil {
+		log.Printf("Failed to check follow relation %s -> %s: %v", viewerID, post.AuthorID, err)
+		return false
+	}
+	return following
+}
+
+// filterVisible 移除瀏覽者無權查看的貼文，每位作者的追蹤關係只查詢一次
+func (s *PostService) filterVisible(ctx context.Context, posts []models.Post, viewerID string) []models.Post {
+	allowed := make(map[string]bool)
+	visible := make([]models.Post, 0, len(posts))
+	for i := range posts {
+		post := &posts[i]
+		if post.IsPublic() {
+			visible = append(visible, *post)
+			continue
+		}
+		ok, checked := allowed[post.AuthorID]
+		if !checked {
+			ok = s.CanView(ctx, post, viewerID)
+			allowed[post.AuthorID] = ok
+		}
+		if ok {
+			visible = append(visible, *post)
+		}
+	}
+	return visible
+}
+
+// VisiblePostIDs 回傳 postIDs 中存在且瀏覽者有權查看的貼文 ID，順序不保證
+func (s *PostService) VisiblePostIDs(ctx context.Context, postIDs []string, viewerID string) ([]string, error) {
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+	posts, err := s.postRepo.GetPostsByIDs(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+	visible := s.filterVisible(ctx, posts, viewerID)
+	ids := make([]string, 0, len(visible))
+	for _, post := range visible {
+		ids = append(ids, post.PostID)
+	}
+	return ids, nil
+}
+
+// getVisiblePost 讀取貼文並確認瀏覽者有權查看；以單篇貼文為範圍的讀取與互動都先經過這裡
+func (s *PostService) getVisiblePost(ctx context.Context, postID, viewerID string) (*models.Post, error) {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.CanView(ctx, post, viewerID) {
+		return nil, ErrPostNotVisible
+	}
+	return post, nil
+}
+
+// GetPostDetail 回傳單篇貼文、作者資訊與第一頁評論；viewerID 為空代表未登入的訪客
+func (s *PostService) GetPostDetail(ctx context.Context, postID, viewerID string) (*models.PostDetailDTO, error) {
+	post, err := s.getVisiblePost(ctx, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := s.BuildPostFeedDTOs(ctx, []models.Post{*post}, viewerID)
+	detail := &models.PostDetailDTO{
+		PostFeedDTO: dtos[0],
+		Author: models.PostAuthorDTO{
+			UserID:   post.AuthorID,
+			Username: dtos[0].AuthorName,
+		},
+	}
+	if profile, err := s.userRepo.GetUserProfileByUserID(post.AuthorID); err == nil {
+		detail.Author.Username = profile.Username
+		detail.Author.AvatarURL = profile.AvatarURL
+		detail.Author.Bio = profile.Bio
+	}
+
+	threads, lastEvaluatedKey, err := s.listCommentThreads(ctx, postID, viewerID, false, PostDetailCommentLimit, nil)
+	if err != nil {
+		return nil, err
+	}
+	detail.Comments = threads
+	detail.CommentsLastEvaluatedKey = lastEvaluatedKey
+	return detail, nil
+}
+
+// BuildPostFeedDTOs 將貼文轉換為前端使用的 DTO，附上作者名稱與瀏覽者的按讚狀態；
+// 轉發與引用會附上原始貼文，原始貼文已刪除或瀏覽者無權查看時標記為 original_unavailable
+func (s *PostService) BuildPostFeedDTOs(ctx context.Context, posts []models.Post, viewerID string) []models.PostFeedDTO {
+    feedDTOs := s.buildPostDTOs(ctx, posts, viewerID)
+
+    seen := make(map[string]bool)
+    var originalIDs []string
+    for _, post := range posts {
+        if post.OriginalPostID != "" && !seen[post.OriginalPostID] {
+            seen[post.OriginalPostID] = true
+            originalIDs = append(originalIDs, post.OriginalPostID)
+        }
+    }
+    if len(originalIDs) == 0 {
+        return feedDTOs
+    }
+
+    // 已刪除的原始貼文不會出現在 GetPostsByIDs 的結果中
+    originals, err := s.postRepo.GetPostsByIDs(ctx, originalIDs)
     if err != nil {
-        log.Printf("Error getting posts from repo for user ID %s: %v", userID, err)
-        return nil, err
+        log.Printf("Could not load original posts for reposts: %v", err)
+    }
+    originals = s.filterVisible(ctx, originals, viewerID)
+    originalDTOs := make(map[string]models.PostFeedDTO, len(originals))
+    for _, dto := range s.buildPostDTOs(ctx, originals, viewerID) {
+        originalDTOs[dto.PostID] = dto
     }
 
-    if len(posts) == 0 {
-        return []models.PostFeedDTO{}, nil
+    for i, post := range posts {
+        if post.OriginalPostID == "" {
+            continue
+        }
+        if original, ok := originalDTOs[post.OriginalPostID]; ok {
+            feedDTOs[i].OriginalPost = &original
+        } else {
+            feedDTOs[i].OriginalUnavailable = true
+        }
     }
+    return feedDTOs
+}
 
-    // 2. 如果瀏覽者已登入，則檢查其按讚狀態
+// buildPostDTOs 轉換貼文本身的欄位，不展開轉發與引用的原始貼文
+func (s *PostService) buildPostDTOs(ctx context.Context, posts []models.Post, viewerID string) []models.PostFeedDTO {
+    // 如果瀏覽者已登入，則檢查其按讚、轉發與收藏狀態
     likedStatusMap := make(map[string]bool)
+    repostedStatusMap := make(map[string]bool)
+    bookmarkedStatusMap := make(map[string]bool)
     if viewerID != "" && len(posts) > 0 {
         var postIDs []string
         for _, post := range posts {
             postIDs = append(postIDs, post.PostID)
         }
         // 調用已有的 repository 方法進行批量檢查
-        likedStatusMap, err = s.postRepo.CheckIfPostsLikedBy(ctx, postIDs, viewerID)
+        statusMap, err := s.postRepo.CheckIfPostsLikedBy(ctx, postIDs, viewerID)
         if err != nil {
             log.Printf("Could not check liked status for viewer %s: %v", viewerID, err)
-            likedStatusMap = make(map[string]bool)
+        } else {
+            likedStatusMap = statusMap
+        }
+        statusMap, err = s.postRepo.CheckIfPostsRepostedBy(ctx, postIDs, viewerID)
+        if err != nil {
+            log.Printf("Could not check reposted status for viewer %s: %v", viewerID, err)
+        } else {
+            repostedStatusMap = statusMap
+        }
+        if s.bookmarkRepo != nil {
+            statusMap, err = s.bookmarkRepo.CheckIfPostsBookmarkedBy(ctx, postIDs, viewerID)
+            if err != nil {
+                log.Printf("Could not check bookmarked status for viewer %s: %v", viewerID, err)
+            } else {
+                bookmarkedStatusMap = statusMap
+            }
         }
     }
 
-    // 3. 將 []models.Post 轉換為 []models.PostFeedDTO
-    var feedDTOs []models.PostFeedDTO
+    authorCache := make(map[string]string)
+    feedDTOs := make([]models.PostFeedDTO, 0, len(posts))
     for _, post := range posts {
-        var authorName string
-        user, userErr := s.userRepo.GetUserByID(post.AuthorID)
-        if userErr != nil {
-            authorName = "User ID: " + post.AuthorID
-        } else {
-            authorName = user.Username
+        authorName, found := authorCache[post.AuthorID]
+        if !found {
+            user, userErr := s.userRepo.GetUserByID(post.AuthorID)
+            if userErr != nil {
+                authorName = "User ID: " + post.AuthorID
+            } else {
+                authorName = user.Username
+            }
+            authorCache[post.AuthorID] = authorName
         }
-        
+
         dto := models.PostFeedDTO{
             PostID:       post.PostID,
             AuthorID:     post.AuthorID,
@@ -138,56 +489,185 @@ func (s *PostService) GetPostsByUserID(ctx context.Context, userID string, viewe
             CommentCount: post.CommentCount,
             CreatedAt:    post.CreatedAt,
             UpdatedAt:    post.UpdatedAt,
+            Mentions:     post.MentionSpans,
             IsLiked:      likedStatusMap[post.PostID],
+            Bookmarked:   bookmarkedStatusMap[post.PostID],
+            Visibility:   models.PostVisibilityPublic,
+            RepostCount:  post.RepostCount,
+            IsReposted:   repostedStatusMap[post.PostID],
+            PostType:     models.PostTypePost,
+        }
+        switch post.EntityType {
+        case models.PostEntityRepost:
+            dto.PostType = models.PostTypeRepost
+        case models.PostEntityQuote:
+            dto.PostType = models.PostTypeQuote
+        }
+        if !post.IsPublic() {
+            dto.Visibility = post.Visibility
         }
         feedDTOs = append(feedDTOs, dto)
     }
 
-    return feedDTOs, nil
+    return feedDTOs
+}
+
+// resolvePostTags 決定貼文的標籤：有明確提供就使用，否則從內文的 #hashtag 解析
+func resolvePostTags(explicit []string, content string) []string {
+	tags := textparse.UniqueTags(explicit)
+	if len(tags) == 0 {
+		tags = textparse.ExtractHashtags(content)
+	}
+	if len(tags) > maxTagsPerPost {
+		tags = tags[:maxTagsPerPost]
+	}
+	return tags
+}
+
+// mergeEditedTags 在內文被編輯時重新計算標籤：
+// 保留原本不是從舊內文解析出來的標籤 (使用者明確指定的)，再加上新內文中的 hashtag
+func mergeEditedTags(oldTags []string, oldContent, newContent string) []string {
+	derived := make(map[string]bool)
+	for _, t := range textparse.ExtractHashtags(oldContent) {
+		derived[textparse.NormalizeTag(t)] = true
+	}
+	var kept []string
+	for _, t := range oldTags {
+		if !derived[textparse.NormalizeTag(t)] {
+			kept = append(kept, t)
+		}
+	}
+	tags := textparse.UniqueTags(append(kept, textparse.ExtractHashtags(newContent)...))
+	if len(tags) > maxTagsPerPost {
+		tags = tags[:maxTagsPerPost]
+	}
+	return tags
 }
 
-// UpdatePost 處理更新貼文的邏輯
+// UpdatePost 處理更新貼文的邏輯，只有作者本人可以編輯，編輯前的內容會保留為一個版本
 func (s *PostService) UpdatePost(ctx context.Context, payload models.UpdatePostPayload) (*models.Post, error) {
 	// 1. 先獲取原始貼文，以確認其存在並取得完整 Key
 	existingPost, err := s.postRepo.GetPostByID(ctx, payload.PostID)
 	if err != nil {
 		return nil, err // Post not found
 	}
+	if existingPost.AuthorID != payload.AuthorID {
+		return nil, errors.New("user not authorized to edit this post")
+	}
+	if existingPost.IsRepost() {
+		return nil, ErrRepostNotEditable
+	}
+	if payload.Visibility != "" && !models.ValidPostVisibility(payload.Visibility) {
+		return nil, ErrInvalidVisibility
+	}
 
-	// 2. 更新欄位
+	// 2. 更新欄位，內文中的 hashtag 與 @提及 變動時同步更新
+	previousMentions := existingPost.Mentions
+	if payload.Tags != nil {
+		existingPost.Tags = resolvePostTags(*payload.Tags, payload.Content)
+	} else {
+		existingPost.Tags = mergeEditedTags(existingPost.Tags, existingPost.Content, payload.Content)
+	}
 	existingPost.Content = payload.Content
-	// ... 更新其他允許的欄位
+	existingPost.Mentions, existingPost.MentionSpans = s.resolveMentions(payload.Content)
+	if payload.Media != nil {
+		existingPost.Media = *payload.Media
+	}
+	if payload.RemoveLocation {
+		existingPost.Location = nil
+	} else if payload.Location != nil {
+		existingPost.Location = payload.Location
+	}
+	if payload.Visibility != "" {
+		existingPost.Visibility = payload.Visibility
+	}
 
 	// 3. 呼叫 repo 進行更新
 	if err := s.postRepo.UpdatePost(ctx, existingPost); err != nil {
 		return nil, err
 	}
 
+	// 只通知這次編輯新加入的提及，避免重複通知
+	go s.notifyMentions(newlyMentioned(previousMentions, existingPost.Mentions), existingPost.AuthorID, existingPost.PostID, "")
+
 	return existingPost, nil
 }
 
+// GetPostHistory 依版本由舊到新回傳貼文的所有版本，最後一筆為目前內容
+func (s *PostService) GetPostHistory(ctx context.Context, postID, viewerID string) ([]models.PostVersion, error) {
+	post, err := s.getVisiblePost(ctx, postID, viewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []models.PostVersion{}
+	if post.Version > 0 {
+		versions, err := s.postRepo.ListPostVersions(ctx, postID)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, versions...)
+	}
+	history = append(history, models.PostVersion{
+		PostID:       post.PostID,
+		Version:      post.Version + 1,
+		Content:      post.Content,
+		Media:        post.Media,
+		Tags:         post.Tags,
+		Location:     post.Location,
+		MentionSpans: post.MentionSpans,
+		Visibility:   post.Visibility,
+		WrittenAt:    post.UpdatedAt,
+	})
+	return history, nil
+}
+
 // DeletePost 處理刪除貼文的邏輯
 func (s *PostService) DeletePost(ctx context.Context, payload models.DeletePostPayload) error {
 	// 為了更可靠地刪除，我們先根據 postID 查詢貼文，以獲取完整的 SK
 	post, err := s.postRepo.GetPostByID(ctx, payload.PostID)
 	if err != nil {
 		log.Printf("Cannot delete post: post with ID %s not found. Error: %v", payload.PostID, err)
-		return err // Or return a specific "not found" error
+		return err
 	}
 
-	// 檢查操作者是否有權限刪除 (可選，但推薦)
-	// if post.AuthorID != payload.AuthorID {
-	// 	log.Printf("User %s is not authorized to delete post %s owned by %s", payload.AuthorID, payload.PostID, post.AuthorID)
-	// 	return models.ErrForbidden // 您需要定義這個錯誤
-	// }
+	// 刪除會連帶清除按讚、評論、Feed 與所有轉發且無法復原，只有作者本人可以執行
+	if post.AuthorID != payload.AuthorID {
+		log.Printf("User %s is not authorized to delete post %s owned by %s", payload.AuthorID, payload.PostID, post.AuthorID)
+		return ErrNotPostOwner
+	}
+
+	// 先寫入軟刪除標記，貼文立即從所有讀取路徑中消失；
+	// 按讚、評論、Feed 與推薦項目則交給清理工作非同步刪除
+	if err := s.postRepo.MarkPostDeleted(ctx, post); err != nil {
+		log.Printf("Error marking post %s as deleted: %v", post.PostID, err)
+		return err
+	}
+	s.enqueueCleanup(ctx, post)
+	return nil
+}
 
-	// 使用從查詢中得到的 AuthorID, PostID 和 CreatedAt 來刪除
-	return s.postRepo.DeletePost(ctx, post.AuthorID, post.PostID, post.CreatedAt)
+// enqueueCleanup 將清理工作放入佇列；失敗時只記錄，殘留的軟刪除貼文會由 reconcile-orphans 指令補清
+func (s *PostService) enqueueCleanup(ctx context.Context, post *models.Post) {
+	if s.jobQueue == nil {
+		log.Printf("No job queue configured; post %s will be cleaned up by reconcile-orphans", post.PostID)
+		return
+	}
+	job, err := queue.NewJob(JobTypeCleanupPost, cleanupJobPayload{PostID: post.PostID, PK: post.PK, SK: post.SK})
+	if err == nil {
+		err = s.jobQueue.Enqueue(ctx, job)
+	}
+	if err != nil {
+		log.Printf("Failed to enqueue cleanup for post %s; it will be picked up by reconcile-orphans: %v", post.PostID, err)
+	}
 }
 
 func (s *PostService) LikePost(ctx context.Context, postID, userID string) error {
 	// 1. Fetch the post first to get its full details (including PK and SK)
-	post, err := s.postRepo.GetPostByID(ctx, postID)
+	post, err := s.getVisiblePost(ctx, postID, userID)
+	if errors.Is(err, ErrPostNotVisible) {
+		return err
+	}
 	if err != nil {
 		log.Printf("LikePost failed: could not find post with ID %s. Error: %v", postID, err)
 		return errors.New("post not found")
@@ -198,13 +678,22 @@ func (s *PostService) LikePost(ctx context.Context, postID, userID string) error
 		log.Printf("Error liking post in service: %v", err)
 		return err
 	}
+
+	if s.notificationService != nil {
+		go s.notificationService.NotifyPostLiked(context.Background(), post, userID)
+	}
+	go s.recordAffinity(userID, post.AuthorID, 1, 0)
+	go s.publishPostCounts(post)
 	return nil
 }
 
 // UnlikePost 處理取消按讚的邏輯
 func (s *PostService) UnlikePost(ctx context.Context, postID, userID string) error {
 
-	posts, err := s.postRepo.GetPostByID(ctx, postID)
+	posts, err := s.getVisiblePost(ctx, postID, userID)
+	if errors.Is(err, ErrPostNotVisible) {
+		return err
+	}
 	if err != nil {
 		log.Printf("UnlikePost failed: could not find post with ID %s. Error: %v", postID, err)
 		return errors.New("post not found")
@@ -214,6 +703,158 @@ func (s *PostService) UnlikePost(ctx context.Context, postID, userID string) err
 		log.Printf("Error unliking post in service: %v", err)
 		return err
 	}
+	go s.recordAffinity(userID, posts.AuthorID, -1, 0)
+	go s.publishPostCounts(posts)
+	return nil
+}
+
+// RepostPost 處理轉發貼文的邏輯；轉發一則轉發時改為轉發其原始貼文
+func (s *PostService) RepostPost(ctx context.Context, postID, userID string) (*models.Post, error) {
+	original, err := s.resolveRepostTarget(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	repost := &models.Post{
+		AuthorID:   userID,
+		EntityType: models.PostEntityRepost,
+		Visibility: models.PostVisibilityPublic,
+	}
+	if err := s.postRepo.CreateRepost(ctx, original, repost); err != nil {
+		log.Printf("Error reposting post %s in service: %v", original.PostID, err)
+		return nil, err
+	}
+
+	s.enqueueFanOut(ctx, repost)
+	if s.notificationService != nil {
+		go s.notificationService.NotifyPostReposted(context.Background(), original, userID, repost.PostID)
+	}
+	go s.publishPostCounts(original)
+	return repost, nil
+}
+
+// UnrepostPost 處理取消轉發的邏輯，轉發項目與一般貼文一樣以軟刪除移除
+func (s *PostService) UnrepostPost(ctx context.Context, postID, userID string) error {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return err
+	}
+	originalID := post.PostID
+	if post.IsRepost() {
+		originalID = post.OriginalPostID
+	}
+
+	link, err := s.postRepo.GetRepostLink(ctx, originalID, userID)
+	if err != nil {
+		return err
+	}
+	repost, err := s.postRepo.GetPostByID(ctx, link.RepostPostID)
+	if err != nil {
+		return errors.New("repost not found")
+	}
+	if err := s.postRepo.MarkPostDeleted(ctx, repost); err != nil {
+		log.Printf("Error removing repost %s: %v", repost.PostID, err)
+		return err
+	}
+	s.enqueueCleanup(ctx, repost)
+	go s.publishPostCounts(&models.Post{PostID: originalID, PK: repost.OriginalPK, SK: repost.OriginalSK})
+	return nil
+}
+
+// QuotePost 處理引用貼文的邏輯：附上自己的內容，並與一般貼文一樣 fan-out 給粉絲
+func (s *PostService) QuotePost(ctx context.Context, postID, userID string, payload models.CreateQuotePayload) (*models.Post, error) {
+	visibility := payload.Visibility
+	if visibility == "" {
+		visibility = models.PostVisibilityPublic
+	}
+	if !models.ValidPostVisibility(visibility) {
+		return nil, ErrInvalidVisibility
+	}
+
+	original, err := s.resolveRepostTarget(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &models.Post{
+		AuthorID:   userID,
+		EntityType: models.PostEntityQuote,
+		Content:    payload.Content,
+		Media:      payload.Media,
+		Tags:       resolvePostTags(payload.Tags, payload.Content),
+		Visibility: visibility,
+	}
+	quote.Mentions, quote.MentionSpans = s.resolveMentions(payload.Content)
+	if err := s.postRepo.CreateRepost(ctx, original, quote); err != nil {
+		log.Printf("Error quoting post %s in service: %v", original.PostID, err)
+		return nil, err
+	}
+
+	s.enqueueFanOut(ctx, quote)
+	go s.notifyMentions(quote.Mentions, quote.AuthorID, quote.PostID, "")
+	if s.notificationService != nil {
+		go s.notificationService.NotifyPostReposted(context.Background(), original, userID, quote.PostID)
+	}
+	go s.publishPostCounts(original)
+	return quote, nil
+}
+
+// resolveRepostTarget 取得要被轉發或引用的貼文：單純轉發會被展開為其原始貼文，且只有公開貼文可以被分享
+func (s *PostService) resolveRepostTarget(ctx context.Context, postID string) (*models.Post, error) {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.IsRepost() {
+		post, err = s.postRepo.GetPostByID(ctx, post.OriginalPostID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !post.IsPublic() {
+		return nil, ErrRepostNotAllowed
+	}
+	return post, nil
+}
+
+// LikeComment 處理對評論按讚的邏輯
+func (s *PostService) LikeComment(ctx context.Context, postID, commentSK, userID string) error {
+	if _, err := s.getVisiblePost(ctx, postID, userID); err != nil {
+		return err
+	}
+	comment, err := s.postRepo.GetCommentBySK(ctx, postID, commentSK)
+	if err != nil {
+		return err
+	}
+	if comment.Deleted {
+		return errors.New("comment not found")
+	}
+
+	if err := s.postRepo.AddCommentLike(ctx, comment, userID); err != nil {
+		log.Printf("Error liking comment in service: %v", err)
+		return err
+	}
+
+	if s.notificationService != nil {
+		go s.notificationService.NotifyCommentLiked(context.Background(), comment, userID)
+	}
+	return nil
+}
+
+// UnlikeComment 處理取消評論按讚的邏輯
+func (s *PostService) UnlikeComment(ctx context.Context, postID, commentSK, userID string) error {
+	if _, err := s.getVisiblePost(ctx, postID, userID); err != nil {
+		return err
+	}
+	comment, err := s.postRepo.GetCommentBySK(ctx, postID, commentSK)
+	if err != nil {
+		return err
+	}
+
+	if err := s.postRepo.RemoveCommentLike(ctx, comment, userID); err != nil {
+		log.Printf("Error unliking comment in service: %v", err)
+		return err
+	}
 	return nil
 }
 
@@ -231,46 +872,433 @@ func (s *PostService) CreateComment(ctx context.Context, payload models.CreateCo
         AuthorName: user.Username, // 填入使用者名稱
         Content:    payload.Content,
     }
+    comment.Mentions, comment.MentionSpans = s.resolveMentions(payload.Content)
 
-    posts, err := s.postRepo.GetPostByID(ctx, payload.PostID)
+    posts, err := s.getVisiblePost(ctx, payload.PostID, payload.AuthorID)
+    if errors.Is(err, ErrPostNotVisible) {
+        return nil, err
+    }
     if err != nil {
         log.Printf("CreateComment failed: could not find post with ID %s. Error: %v", payload.PostID, err)
         return nil, errors.New("post not found")
     }
 
-    if err := s.postRepo.CreateComment(ctx, posts, comment); err != nil {
+    var parent *models.Comment
+    if payload.ParentCommentID != "" {
+        parent, err = s.resolveReplyParent(ctx, payload.PostID, payload.ParentCommentID)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    if err := s.postRepo.CreateComment(ctx, posts, parent, comment); err != nil {
         log.Printf("Error creating comment in service: %v", err)
         return nil, err
     }
+
+    if s.notificationService != nil {
+        go s.notificationService.NotifyPostCommented(context.Background(), posts, comment.AuthorID, comment.CommentID)
+    }
+    go s.notifyMentions(comment.Mentions, comment.AuthorID, comment.PostID, comment.CommentID)
+    go s.recordAffinity(comment.AuthorID, posts.AuthorID, 0, 1)
+    go s.publishPostCounts(posts)
     return comment, nil
 }
 
+// resolveReplyParent 找出回覆要掛在哪一則頂層評論之下。
+// 討論串只有一層，回覆一則回覆時會歸到同一個頂層評論
+func (s *PostService) resolveReplyParent(ctx context.Context, postID, parentCommentID string) (*models.Comment, error) {
+	parent, err := s.postRepo.GetCommentByID(ctx, postID, parentCommentID)
+	if err != nil {
+		return nil, errors.New("parent comment not found")
+	}
+	if parent.ParentCommentID != "" {
+		parent, err = s.postRepo.GetCommentByID(ctx, postID, parent.ParentCommentID)
+		if err != nil {
+			return nil, errors.New("parent comment not found")
+		}
+	}
+	if parent.Deleted {
+		return nil, errors.New("parent comment not found")
+	}
+	return parent, nil
+}
+
 // DeleteComment 處理刪除評論的邏輯
 func (s *PostService) DeleteComment(ctx context.Context, postID, commentSK, userID string) error {
+	posts, err := s.getVisiblePost(ctx, postID, userID)
+	if errors.Is(err, ErrPostNotVisible) {
+		return err
+	}
+	if err != nil {
+		log.Printf("DeleteComment failed: could not find post with ID %s. Error: %v", postID, err)
+		return errors.New("post not found")
+	}
+
 	// 1. 獲取評論，以進行授權檢查
 	comment, err := s.postRepo.GetCommentBySK(ctx, postID, commentSK)
 	if err != nil {
 		return err
 	}
+	// 已刪除但仍保留給回覆的佔位評論，對使用者而言已不存在
+	if comment.Deleted {
+		return errors.New("comment not found")
+	}
 
 	// 2. 授權檢查：只有評論者本人可以刪除
 	if comment.AuthorID != userID {
 		return errors.New("user not authorized to delete this comment")
 	}
 
-	posts, err := s.postRepo.GetPostByID(ctx, postID)
-	if err != nil {
-		log.Printf("DeleteComment failed: could not find post with ID %s. Error: %v", postID, err)
-		return errors.New("post not found")
-	}
-
-	// 3. 執行刪除
-	err = s.postRepo.DeleteComment(ctx, posts, commentSK)
+	// 3. 執行刪除 (仍有回覆的評論會改為保留 [deleted] 佔位)
+	err = s.postRepo.DeleteComment(ctx, posts, comment)
 	if err != nil {
 		log.Printf("Error deleting comment in service: %v", err)
 		return err
 	}
 
+	go s.recordAffinity(userID, posts.AuthorID, 0, -1)
+	go s.publishPostCounts(posts)
 	return nil
 
 }
+
+// UpdateComment 處理編輯評論的邏輯，舊內容會保留在編輯紀錄中
+func (s *PostService) UpdateComment(ctx context.Context, postID, commentSK, userID string, payload models.UpdateCommentPayload) (*models.CommentDTO, error) {
+	if _, err := s.getVisiblePost(ctx, postID, userID); err != nil {
+		return nil, err
+	}
+	previous, err := s.postRepo.GetCommentBySK(ctx, postID, commentSK)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Deleted {
+		return nil, errors.New("comment not found")
+	}
+	if previous.AuthorID != userID {
+		return nil, errors.New("user not authorized to edit this comment")
+	}
+
+	updated := *previous
+	updated.Content = payload.Content
+	updated.Mentions, updated.MentionSpans = s.resolveMentions(payload.Content)
+	updated.EditCount = previous.EditCount + 1
+	updated.EditedAt = time.Now().UTC().Format(time.RFC3339Nano)
+
+	if err := s.postRepo.UpdateComment(ctx, previous, &updated); err != nil {
+		log.Printf("Error updating comment %s in service: %v", previous.CommentID, err)
+		return nil, err
+	}
+
+	// 只通知這次編輯新加入的提及，避免重複通知
+	go s.notifyMentions(newlyMentioned(previous.Mentions, updated.Mentions), userID, postID, updated.CommentID)
+
+	dto := toCommentDTO(updated)
+	s.enrichComments(ctx, userID, []*models.CommentDTO{&dto})
+	return &dto, nil
+}
+
+// GetCommentHistory 依版本由舊到新回傳評論的所有版本，最後一筆為目前內容
+func (s *PostService) GetCommentHistory(ctx context.Context, postID, commentID, viewerID string) ([]models.CommentEdit, error) {
+	if _, err := s.getVisiblePost(ctx, postID, viewerID); err != nil {
+		return nil, err
+	}
+	comment, err := s.postRepo.GetCommentByID(ctx, postID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.Deleted {
+		return nil, errors.New("comment not found")
+	}
+
+	history := []models.CommentEdit{}
+	if comment.EditCount > 0 {
+		edits, err := s.postRepo.ListCommentEdits(ctx, postID, commentID)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, edits...)
+	}
+
+	writtenAt := comment.EditedAt
+	if writtenAt == "" {
+		writtenAt = comment.CreatedAt
+	}
+	history = append(history, models.CommentEdit{
+		CommentID:    comment.CommentID,
+		PostID:       comment.PostID,
+		Version:      comment.EditCount + 1,
+		Content:      comment.Content,
+		MentionSpans: comment.MentionSpans,
+		WrittenAt:    writtenAt,
+	})
+	return history, nil
+}
+
+// ListCommentThreads 分頁列出貼文的頂層評論，每則評論附上最前面 CommentReplyPreviewCount 則回覆
+func (s *PostService) ListCommentThreads(ctx context.Context, postID, viewerID string, newestFirst bool, limit int32, lastEvaluatedKey map[string]types.AttributeValue) ([]models.CommentThreadDTO, map[string]types.AttributeValue, error) {
+	if _, err := s.getVisiblePost(ctx, postID, viewerID); err != nil {
+		return nil, nil, err
+	}
+	return s.listCommentThreads(ctx, postID, viewerID, newestFirst, limit, lastEvaluatedKey)
+}
+
+func (s *PostService) listCommentThreads(ctx context.Context, postID, viewerID string, newestFirst bool, limit int32, lastEvaluatedKey map[string]types.AttributeValue) ([]models.CommentThreadDTO, map[string]types.AttributeValue, error) {
+	page, err := s.postRepo.ListComments(ctx, postID, newestFirst, limit, lastEvaluatedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	threads := make([]models.CommentThreadDTO, len(page.Items))
+	for i, comment := range page.Items {
+		threads[i] = models.CommentThreadDTO{
+			CommentDTO: toCommentDTO(comment),
+			Replies:    []models.CommentDTO{},
+		}
+	}
+
+	// 每則評論的預覽回覆在不同的 REPLY# 前綴下，各自查詢但並行執行，避免逐則等待
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, comment := range page.Items {
+		if comment.ReplyCount == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(thread *models.CommentThreadDTO, commentID string) {
+			defer wg.Done()
+			replies, err := s.postRepo.ListReplies(ctx, postID, commentID, CommentReplyPreviewCount, nil)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			thread.Replies = toCommentDTOs(replies.Items)
+			thread.RepliesLastEvaluatedKey = replies.LastEvaluatedKey
+		}(&threads[i], comment.CommentID)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+
+	// 評論與預覽回覆一起補上頭像與按讚狀態，各只需一次批次查詢
+	var all []*models.CommentDTO
+	for i := range threads {
+		all = append(all, &threads[i].CommentDTO)
+		for j := range threads[i].Replies {
+			all = append(all, &threads[i].Replies[j])
+		}
+	}
+	s.enrichComments(ctx, viewerID, all)
+	return threads, page.LastEvaluatedKey, nil
+}
+
+// ListReplies 依時間由舊到新分頁列出某則頂層評論的回覆
+func (s *PostService) ListReplies(ctx context.Context, postID, commentID, viewerID string, limit int32, lastEvaluatedKey map[string]types.AttributeValue) ([]models.CommentDTO, map[string]types.AttributeValue, error) {
+	if _, err := s.getVisiblePost(ctx, postID, viewerID); err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.postRepo.GetCommentByID(ctx, postID, commentID); err != nil {
+		return nil, nil, err
+	}
+
+	page, err := s.postRepo.ListReplies(ctx, postID, commentID, limit, lastEvaluatedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	replies := toCommentDTOs(page.Items)
+	all := make([]*models.CommentDTO, len(replies))
+	for i := range replies {
+		all[i] = &replies[i]
+	}
+	s.enrichComments(ctx, viewerID, all)
+	return replies, page.LastEvaluatedKey, nil
+}
+
+// enrichComments 補上作者頭像與目前使用者的按讚狀態；查詢失敗只記錄，評論仍照常回傳
+func (s *PostService) enrichComments(ctx context.Context, viewerID string, comments []*models.CommentDTO) {
+	if len(comments) == 0 {
+		return
+	}
+	var authorIDs, commentIDs []string
+	for _, comment := range comments {
+		if comment.Deleted {
+			continue
+		}
+		authorIDs = append(authorIDs, comment.AuthorID)
+		commentIDs = append(commentIDs, comment.CommentID)
+	}
+
+	avatars, err := s.userRepo.GetAvatarURLs(uniqueStrings(authorIDs))
+	if err != nil {
+		log.Printf("Failed to load comment author avatars: %v", err)
+	}
+	liked := make(map[string]bool)
+	if viewerID != "" {
+		liked, err = s.postRepo.CheckIfCommentsLikedBy(ctx, commentIDs, viewerID)
+		if err != nil {
+			log.Printf("Failed to check comment likes for user %s: %v", viewerID, err)
+		}
+	}
+
+	for _, comment := range comments {
+		if comment.Deleted {
+			continue
+		}
+		comment.AuthorAvatarURL = avatars[comment.AuthorID]
+		comment.IsLiked = liked[comment.CommentID]
+	}
+}
+
+// toCommentDTO 轉換評論，已刪除的評論只保留在討論串中的位置，不再顯示作者與內容
+func toCommentDTO(comment models.Comment) models.CommentDTO {
+	dto := models.CommentDTO{
+		CommentID:       comment.CommentID,
+		CommentSK:       comment.SK,
+		PostID:          comment.PostID,
+		ParentCommentID: comment.ParentCommentID,
+		AuthorID:        comment.AuthorID,
+		AuthorName:      comment.AuthorName,
+		Content:         comment.Content,
+		Mentions:        comment.MentionSpans,
+		ReplyCount:      comment.ReplyCount,
+		LikeCount:       comment.LikeCount,
+		EditedAt:        comment.EditedAt,
+		Deleted:         comment.Deleted,
+		CreatedAt:       comment.CreatedAt,
+	}
+	if comment.Deleted {
+		dto.AuthorID = ""
+		dto.AuthorName = ""
+		dto.Content = models.DeletedCommentPlaceholder
+		dto.Mentions = nil
+	}
+	return dto
+}
+
+func toCommentDTOs(comments []models.Comment) []models.CommentDTO {
+	dtos := make([]models.CommentDTO, 0, len(comments))
+	for _, comment := range comments {
+		dtos = append(dtos, toCommentDTO(comment))
+	}
+	return dtos
+}
+
+// recordAffinity 更新使用者對作者的互動計數，失敗只記錄 (只影響排序 Feed 的分數)
+func (s *PostService) recordAffinity(userID, authorID string, likes, comments int) {
+	if s.affinityRepo == nil || userID == authorID {
+		return
+	}
+	if err := s.affinityRepo.IncrementAffinity(context.Background(), userID, authorID, likes, comments); err != nil {
+		log.Printf("Failed to record affinity %s -> %s: %v", userID, authorID, err)
+	}
+}
+
+// postCountsEvent 是推播給貼文瀏覽者的計數更新
+type postCountsEvent struct {
+	PostID       string `json:"post_id"`
+	LikeCount    int    `json:"like_count"`
+	CommentCount int    `json:"comment_count"`
+	RepostCount  int    `json:"repost_count"`
+}
+
+// publishPostCounts 重新讀取貼文的最新計數並推播給正在瀏覽此貼文的連線
+func (s *PostService) publishPostCounts(post *models.Post) {
+	if s.hub == nil {
+		return
+	}
+	ctx := context.Background()
+	latest, err := s.postRepo.ReloadPost(ctx, post)
+	if err != nil {
+		log.Printf("Failed to reload post %s for realtime counts: %v", post.PostID, err)
+		return
+	}
+	s.hub.PublishToPost(ctx, latest.PostID, realtime.EventPostCounts, postCountsEvent{
+		PostID:       latest.PostID,
+		LikeCount:    latest.LikeCount,
+		CommentCount: latest.CommentCount,
+		RepostCount:  latest.RepostCount,
+	})
+}
+
+// resolveMentions 解析內文中的 @username 並以 GetUserByUsername 轉換為使用者 ID，
+// 找不到的使用者名稱會被忽略
+func (s *PostService) resolveMentions(content string) ([]string, []models.Mention) {
+	tokens := textparse.ExtractMentions(content)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	resolved := make(map[string]*models.User)
+	var userIDs []string
+	var spans []models.Mention
+	for _, token := range tokens {
+		user, cached := resolved[token.Username]
+		if !cached {
+			u, err := s.userRepo.GetUserByUsername(token.Username)
+			if err != nil {
+				u = nil
+			}
+			resolved[token.Username] = u
+			user = u
+			if user != nil {
+				userIDs = append(userIDs, user.ID)
+			}
+		}
+		if user == nil {
+			continue
+		}
+		spans = append(spans, models.Mention{
+			UserID:   user.ID,
+			Username: user.Username,
+			Start:    token.Start,
+			End:      token.End,
+		})
+	}
+	return uniqueStrings(userIDs), spans
+}
+
+// notifyMentions 在背景對每位被提及的使用者發送通知
+func (s *PostService) notifyMentions(userIDs []string, actorID, postID, commentID string) {
+	if s.notificationService == nil {
+		return
+	}
+	ctx := context.Background()
+	for _, userID := range userIDs {
+		s.notificationService.NotifyMention(ctx, userID, actorID, postID, commentID)
+	}
+}
+
+// newlyMentioned 回傳只出現在新列表中的使用者 ID
+func newlyMentioned(previous, current []string) []string {
+	before := make(map[string]bool, len(previous))
+	for _, id := range previous {
+		before[id] = true
+	}
+	var added []string
+	for _, id := range current {
+		if !before[id] {
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+// uniqueStrings 去除重複的字串並保留原本的順序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var result []string
+	for _, v := range values {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,194 @@
+// internal/service/ranking_service.go
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/recommendation"
+	"backend/internal/repository"
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// rankingFeedCandidates 是從追蹤 Feed 取出的候選貼文數
+	rankingFeedCandidates = 100
+	// rankingTrendingCandidates 是從全域熱門列表取出的候選貼文數
+	rankingTrendingCandidates = 50
+)
+
+// 候選貼文的來源
+const (
+	RankingSourceFeed     = "feed"
+	RankingSourceTrending = "trending"
+)
+
+// RankingWeights 是排序 Feed 各項訊號的權重
+type RankingWeights struct {
+	Recency         float64       // 時間衰減分數 (0~1) 的權重
+	RecencyHalfLife time.Duration // 時間衰減的半衰期
+	Affinity        float64       // 與作者互動次數 (取 log) 的權重
+	Velocity        float64       // 每小時互動數 (取 log) 的權重
+	SeenPenalty     float64       // 已看過的貼文扣除的分數
+}
+
+// DefaultRankingWeights 回傳預設權重
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{
+		Recency:         1.0,
+		RecencyHalfLife: 24 * time.Hour,
+		Affinity:        0.5,
+		Velocity:        0.3,
+		SeenPenalty:     1.5,
+	}
+}
+
+// RankedFeedPage 是排序 Feed 的一頁
+type RankedFeedPage struct {
+	Posts   []models.Post
+	Scores  []models.ScoreBreakdown // 與 Posts 一一對應
+	HasMore bool
+}
+
+// RankingService 從追蹤 Feed 與熱門推薦收集候選貼文，依時間衰減、作者親密度、互動速度與看過紀錄評分排序
+type RankingService struct {
+	feedService  *FeedService
+	postRepo     repository.PostRepository
+	recoRepo     repository.RecommendationRepository
+	seenRepo     repository.SeenHistoryRepository
+	affinityRepo repository.AffinityRepository
+	weights      RankingWeights
+}
+
+// NewRankingService 是 RankingService 的建構子
+func NewRankingService(feedService *FeedService, postRepo repository.PostRepository, recoRepo repository.RecommendationRepository, seenRepo repository.SeenHistoryRepository, affinityRepo repository.AffinityRepository, weights RankingWeights) *RankingService {
+	return &RankingService{
+		feedService:  feedService,
+		postRepo:     postRepo,
+		recoRepo:     recoRepo,
+		seenRepo:     seenRepo,
+		affinityRepo: affinityRepo,
+		weights:      weights,
+	}
+}
+
+// GetRankedFeed 回傳排序後從 offset 開始的一頁。
+// rankedAt 是第一頁的排序時間：之後的頁面以同一個時間點計算時間衰減，並忽略在此之後發布的貼文與看過紀錄，
+// 讓使用者捲動 (並回報曝光) 時排序不會變動，也就不會在下一頁重複出現或漏掉貼文。
+// 符合靜音條件的貼文在分頁前就被排除，因此每一頁仍有 limit 篇。
+func (s *RankingService) GetRankedFeed(ctx context.Context, userID string, limit, offset int, rankedAt time.Time, filters []models.MuteFilter) (*RankedFeedPage, error) {
+	// 1. 收集候選貼文
+	sources := make(map[string]string)
+	var candidateIDs []string
+	feed, err := s.feedService.GetUserFeed(ctx, userID, rankingFeedCandidates, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range feed.Items {
+		if _, ok := sources[item.PostID]; !ok {
+			sources[item.PostID] = RankingSourceFeed
+			candidateIDs = append(candidateIDs, item.PostID)
+		}
+	}
+	trending, err := s.recoRepo.GetGlobalTrending(ctx, recommendation.TrendingAlgorithmKey, rankingTrendingCandidates)
+	if err != nil {
+		// 推薦失敗時仍以追蹤 Feed 排序
+		log.Printf("Could not fetch global recommendations for ranked feed: %v", err)
+	}
+	for _, rec := range trending {
+		if _, ok := sources[rec.PostID]; !ok {
+			sources[rec.PostID] = RankingSourceTrending
+			candidateIDs = append(candidateIDs, rec.PostID)
+		}
+	}
+	if len(candidateIDs) == 0 {
+		return &RankedFeedPage{}, nil
+	}
+
+	posts, err := s.postRepo.GetPostsByIDs(ctx, candidateIDs)
+	if err != nil {
+		return nil, err
+	}
+	posts = FilterPosts(ctx, s.postRepo, posts, filters)
+
+	// 2. 評分所需的訊號；讀取失敗時該項訊號視為 0
+	affinities, err := s.affinityRepo.GetAffinities(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load affinities for user %s, ranking without them: %v", userID, err)
+	}
+	seenTimes, err := s.seenRepo.GetSeenTimes(ctx, userID, candidateIDs)
+	if err != nil {
+		log.Printf("Failed to load seen history for user %s, ranking without it: %v", userID, err)
+	}
+	rankedAtStr := rankedAt.UTC().Format(time.RFC3339Nano)
+
+	// 3. 評分與排序
+	type scored struct {
+		post  models.Post
+		score models.ScoreBreakdown
+	}
+	var ranked []scored
+	for _, post := range posts {
+		if post.CreatedAt > rankedAtStr {
+			continue
+		}
+		seenAt, seen := seenTimes[post.PostID]
+		seenBefore := seen && seenAt < rankedAtStr
+		breakdown := s.score(post, affinities[post.AuthorID], seenBefore, rankedAt)
+		breakdown.Source = sources[post.PostID]
+		ranked = append(ranked, scored{post: post, score: breakdown})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score.Total != ranked[j].score.Total {
+			return ranked[i].score.Total > ranked[j].score.Total
+		}
+		if ranked[i].post.CreatedAt != ranked[j].post.CreatedAt {
+			return ranked[i].post.CreatedAt > ranked[j].post.CreatedAt
+		}
+		return ranked[i].post.PostID < ranked[j].post.PostID
+	})
+
+	// 4. 分頁
+	page := &RankedFeedPage{}
+	if offset >= len(ranked) {
+		return page, nil
+	}
+	end := offset + limit
+	if end > len(ranked) {
+		end = len(ranked)
+	}
+	for _, r := range ranked[offset:end] {
+		page.Posts = append(page.Posts, r.post)
+		page.Scores = append(page.Scores, r.score)
+	}
+	page.HasMore = end < len(ranked)
+	return page, nil
+}
+
+// score 計算單篇貼文的分數
+func (s *RankingService) score(post models.Post, affinity models.Affinity, seen bool, now time.Time) models.ScoreBreakdown {
+	w := s.weights
+	ageHours := 0.0
+	if createdAt, err := time.Parse(time.RFC3339Nano, post.CreatedAt); err == nil {
+		ageHours = math.Max(0, now.Sub(createdAt).Hours())
+	}
+
+	b := models.ScoreBreakdown{PostID: post.PostID}
+	// 時間衰減：每經過一個半衰期分數減半
+	if halfLife := w.RecencyHalfLife.Hours(); halfLife > 0 {
+		b.Recency = w.Recency * math.Pow(0.5, ageHours/halfLife)
+	}
+	// 作者親密度：評論比按讚代表更強的關係
+	interactions := float64(affinity.LikeCount + 2*affinity.CommentCount)
+	b.Affinity = w.Affinity * math.Log1p(math.Max(0, interactions))
+	// 互動速度：每小時的按讚與評論數，加上 2 小時避免剛發布的貼文分數爆衝
+	engagement := float64(post.LikeCount + 2*post.CommentCount)
+	b.Velocity = w.Velocity * math.Log1p(math.Max(0, engagement)/(ageHours+2))
+	if seen {
+		b.SeenPenalty = w.SeenPenalty
+	}
+	b.Total = b.Recency + b.Affinity + b.Velocity - b.SeenPenalty
+	return b
+}
@@ -2,23 +2,57 @@
 package service
 
 import (
+	"backend/internal/models"
 	"backend/internal/recommendation"
+	"backend/internal/repository"
+	"backend/internal/textparse"
 	"context"
 )
 
 // RecommendationService 結構
 type RecommendationService struct {
-	trendingRecommender *recommendation.TrendingRecommender
+	trendingRecommender     *recommendation.TrendingRecommender
+	trendingTagsRecommender *recommendation.TrendingTagsRecommender
+	recoRepo                repository.RecommendationRepository
 }
 
 // NewRecommendationService 是 RecommendationService 的建構子
-func NewRecommendationService(recommender *recommendation.TrendingRecommender) *RecommendationService {
+func NewRecommendationService(recommender *recommendation.TrendingRecommender, tagsRecommender *recommendation.TrendingTagsRecommender, recoRepo repository.RecommendationRepository) *RecommendationService {
 	return &RecommendationService{
-		trendingRecommender: recommender,
+		trendingRecommender:     recommender,
+		trendingTagsRecommender: tagsRecommender,
+		recoRepo:                recoRepo,
 	}
 }
 
 // GenerateTrendingRecommendations 觸發熱門推薦的生成邏輯
 func (s *RecommendationService) GenerateTrendingRecommendations(ctx context.Context) error {
 	return s.trendingRecommender.GenerateRecommendations(ctx)
-}
\ No newline at end of file
+}
+
+// GetTrendingTags 讀取最近一次計算出的熱門標籤列表，略過被 filters 靜音的標籤
+func (s *RecommendationService) GetTrendingTags(ctx context.Context, limit int32, filters []models.MuteFilter) ([]models.TrendingTagItem, error) {
+	muted := make(map[string]bool)
+	for _, f := range filters {
+		if f.Kind == models.MuteKindHashtag {
+			muted[f.Value] = true
+		}
+	}
+
+	// 多讀被靜音的數量，過濾後仍盡量湊滿 limit
+	tags, err := s.recoRepo.GetTrendingTags(ctx, recommendation.TrendingTagsAlgorithmKey, limit+int32(len(muted)))
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]models.TrendingTagItem, 0, len(tags))
+	for _, tag := range tags {
+		if muted[textparse.NormalizeTag(tag.Tag)] {
+			continue
+		}
+		kept = append(kept, tag)
+	}
+	if int32(len(kept)) > limit {
+		kept = kept[:limit]
+	}
+	return kept, nil
+}
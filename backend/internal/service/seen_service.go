@@ -0,0 +1,122 @@
+// internal/service/seen_service.go
+package service
+
+import (
+	"backend/internal/models"
+	"backend/internal/repository"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+const (
+	// seenRetention 是看過紀錄的存活時間，過期後熱門貼文可以再次被推薦
+	seenRetention = 30 * 24 * time.Hour
+	// maxImpressionsPerRequest 是單次回報的貼文數上限
+	maxImpressionsPerRequest = 100
+)
+
+var (
+	// ErrTooManyImpressions 表示單次回報的貼文數超過上限
+	ErrTooManyImpressions = errors.New("too many post ids in one request")
+	// ErrNotPostAuthor 表示只有貼文作者可以執行此操作
+	ErrNotPostAuthor = errors.New("only the post author can view this")
+)
+
+// SeenService 記錄使用者看過的貼文，用於避免重複推薦，並提供作者觀看人數
+type SeenService struct {
+	seenRepo repository.SeenHistoryRepository
+	postRepo repository.PostRepository
+}
+
+// NewSeenService 是 SeenService 的建構子
+func NewSeenService(seenRepo repository.SeenHistoryRepository, postRepo repository.PostRepository) *SeenService {
+	return &SeenService{
+		seenRepo: seenRepo,
+		postRepo: postRepo,
+	}
+}
+
+// RecordImpressions 記錄使用者看過的貼文，回傳實際記錄的筆數。
+// 不存在的貼文與使用者自己的貼文會被略過，後者不計入作者的觀看人數。
+func (s *SeenService) RecordImpressions(ctx context.Context, userID string, postIDs []string) (int, error) {
+	if len(postIDs) > maxImpressionsPerRequest {
+		return 0, ErrTooManyImpressions
+	}
+
+	unique := make([]string, 0, len(postIDs))
+	added := make(map[string]bool, len(postIDs))
+	for _, id := range postIDs {
+		if id != "" && !added[id] {
+			added[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return 0, nil
+	}
+
+	posts, err := s.postRepo.GetPostsByIDs(ctx, unique)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	items := make([]models.SeenItem, 0, len(posts))
+	for _, post := range posts {
+		if post.AuthorID == userID {
+			continue
+		}
+		items = append(items, models.SeenItem{
+			PK:           "USER#" + userID,
+			SK:           "POST#" + post.PostID,
+			GSI1PK:       "POST#" + post.PostID,
+			GSI1SK:       "USER#" + userID,
+			SeenAt:       now.Format(time.RFC3339Nano),
+			AuthorID:     post.AuthorID,
+			TTLTimestamp: now.Add(seenRetention).Unix(),
+		})
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+	if err := s.seenRepo.RecordSeen(ctx, items); err != nil {
+		log.Printf("Failed to record impressions for user %s: %v", userID, err)
+		return 0, err
+	}
+	return len(items), nil
+}
+
+// FilterUnseen 依原順序回傳使用者尚未看過的貼文；查詢失敗時不過濾，避免 Feed 因此變空
+func (s *SeenService) FilterUnseen(ctx context.Context, userID string, postIDs []string) []string {
+	seen, err := s.seenRepo.GetSeenTimes(ctx, userID, postIDs)
+	if err != nil {
+		log.Printf("Failed to load seen history for user %s, serving unfiltered: %v", userID, err)
+		return postIDs
+	}
+	unseen := make([]string, 0, len(postIDs))
+	for _, id := range postIDs {
+		if _, ok := seen[id]; !ok {
+			unseen = append(unseen, id)
+		}
+	}
+	return unseen
+}
+
+// GetViewStats 回傳貼文的觀看人數，只有作者本人可以查看
+func (s *SeenService) GetViewStats(ctx context.Context, viewerID, postID string) (*models.PostViewStats, error) {
+	post, err := s.postRepo.GetPostByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post.AuthorID != viewerID {
+		return nil, ErrNotPostAuthor
+	}
+
+	count, err := s.seenRepo.CountViewers(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.PostViewStats{PostID: postID, ViewerCount: count}, nil
+}
@@ -3,18 +3,23 @@ package service
 import (
 	"backend/internal/repository"
 	"backend/internal/models"
+	"context"
 	"errors"
 )
 
 // UserService 結構體
 type UserService struct {
-	userRepo repository.UserRepository
+	userRepo            repository.UserRepository
+	notificationService *NotificationService
+	feedService         *FeedService
 }
 
 // NewUserService 是 UserService 的建構子
-func NewUserService(userRepo repository.UserRepository) *UserService {
+func NewUserService(userRepo repository.UserRepository, notificationService *NotificationService, feedService *FeedService) *UserService {
 	return &UserService{
-		userRepo: userRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		feedService:         feedService,
 	}
 }
 
@@ -33,10 +38,27 @@ func (s *UserService) FollowUser(followerID, followedID string) error {
     if followerID == followedID {
         return errors.New("user cannot follow themselves")
     }
-    return s.userRepo.FollowUser(followerID, followedID)
+    if err := s.userRepo.FollowUser(followerID, followedID); err != nil {
+        return err
+    }
+
+    if s.feedService != nil {
+        s.feedService.ScheduleBackfill(context.Background(), followerID, followedID)
+    }
+    if s.notificationService != nil {
+        go s.notificationService.NotifyFollowed(context.Background(), followedID, followerID)
+    }
+    return nil
 }
 
 // UnfollowUser 處理取消追蹤使用者的邏輯
 func (s *UserService) UnfollowUser(followerID, followedID string) error {
-    return s.userRepo.UnfollowUser(followerID, followedID)
+    if err := s.userRepo.UnfollowUser(followerID, followedID); err != nil {
+        return err
+    }
+
+    if s.feedService != nil {
+        s.feedService.SchedulePrune(context.Background(), followerID, followedID)
+    }
+    return nil
 }
\ No newline at end of file
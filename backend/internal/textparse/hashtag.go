@@ -0,0 +1,48 @@
+// internal/textparse/hashtag.go
+package textparse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hashtagPattern 比對內文中的 #hashtag。
+// 前面必須是開頭或非文字字元，避免把網址片段 (example.com/#top) 或 "abc#def" 當成標籤。
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/])#([\p{L}\p{N}_]+)`)
+
+// NormalizeTag 將標籤轉成索引用的標準形式：去掉開頭的 #、前後空白並轉小寫
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// ExtractHashtags 從內文解析出 #hashtag，依出現順序回傳並去除重複 (不分大小寫)
+func ExtractHashtags(content string) []string {
+	matches := hashtagPattern.FindAllStringSubmatch(content, -1)
+	var tags []string
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		key := NormalizeTag(m[1])
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, m[1])
+	}
+	return tags
+}
+
+// UniqueTags 清理使用者提供的標籤：去掉 #、空白與重複項目，保留原始大小寫
+func UniqueTags(tags []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		cleaned := strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		key := NormalizeTag(cleaned)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, cleaned)
+	}
+	return result
+}
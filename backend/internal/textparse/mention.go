@@ -0,0 +1,59 @@
+// internal/textparse/mention.go
+package textparse
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf16"
+)
+
+// MaxMentions 是單篇內文最多解析的不同使用者數量，超過的 @username 不會被當成提及，
+// 避免一篇貼文觸發大量的使用者查詢與通知
+const MaxMentions = 10
+
+// mentionPattern 比對內文中的 @username，前面必須是開頭或非文字字元，避免把 email 當成提及
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@])@([\p{L}\p{N}_.]+)`)
+
+// MentionToken 是內文中的一個 @username，範圍包含開頭的 @。
+// Start/End 以 UTF-16 code unit 計算，與前端 JavaScript 字串的索引 (String.prototype.slice) 一致
+type MentionToken struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// ExtractMentions 從內文解析出 @username 及其位置，最多包含 MaxMentions 位不同的使用者
+func ExtractMentions(content string) []MentionToken {
+	var tokens []MentionToken
+	usernames := make(map[string]bool)
+	for _, idx := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		nameStart, nameEnd := idx[2], idx[3]
+		// 句尾的句點不屬於使用者名稱，例如 "謝謝 @bob."
+		username := strings.TrimRight(content[nameStart:nameEnd], ".")
+		if username == "" {
+			continue
+		}
+		if !usernames[username] {
+			if len(usernames) >= MaxMentions {
+				continue
+			}
+			usernames[username] = true
+		}
+		start := utf16Len(content[:nameStart-1])
+		tokens = append(tokens, MentionToken{
+			Username: username,
+			Start:    start,
+			End:      start + 1 + utf16Len(username),
+		})
+	}
+	return tokens
+}
+
+// utf16Len 回傳字串以 UTF-16 編碼時的 code unit 數量 (BMP 以外的字元，例如 emoji，佔兩個)
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
@@ -0,0 +1,5 @@
+-- 既有資料庫加上 users.follower_count 並依 follows 回填；以 sns_db.sql 新建的資料庫已有此欄位，不需執行
+USE `sns_db`;
+
+ALTER TABLE `users` ADD COLUMN `follower_count` int NOT NULL DEFAULT 0 AFTER `password_hash`;
+UPDATE `users` u SET u.`follower_count` = (SELECT COUNT(*) FROM `follows` f WHERE f.`followed_id` = u.`id`);
@@ -7,7 +7,7 @@ INSERT INTO SeenHistory VALUE {
   'GSI1SK': 'USER#10',
   'SeenAt': '2025-06-08T16:10:00Z',
   'AuthorID': '1',
-  'TTLTImestamp': '1751962200'
+  'TTLTimestamp': 1751962200
 };
 INSERT INTO SeenHistory VALUE {
   'PK': 'USER#10',
@@ -16,7 +16,7 @@ INSERT INTO SeenHistory VALUE {
   'GSI1SK': 'USER#10',
   'SeenAt': '2025-06-08T16:12:30Z',
   'AuthorID': '2',
-  'TTLTImestamp': '1751962350'
+  'TTLTimestamp': 1751962350
 };
 INSERT INTO SeenHistory VALUE {
   'PK': 'USER#1',
@@ -25,5 +25,5 @@ INSERT INTO SeenHistory VALUE {
   'GSI1SK': 'USER#1',
   'SeenAt': '2025-06-08T15:00:00Z',
   'AuthorID': '2',
-  'TTLTImestamp': '1751958000'
+  'TTLTimestamp': 1751958000
 };
\ No newline at end of file
@@ -20,7 +20,47 @@ export function NavigationCard({
   );
 }
 
+// 轉發 / 引用中附上的原始貼文；原始貼文已刪除或無權查看時顯示提示文字
+function EmbeddedPost({ original, unavailable }) {
+  if (unavailable || !original) {
+    return (
+      <div className="mx-2 mb-4 p-4 rounded-lg border border-gray-200 text-sm text-gray-500">
+        This post is unavailable.
+      </div>
+    );
+  }
+  return (
+    <div className="mx-2 mb-4 p-4 rounded-lg border border-gray-200">
+      <Link href={`/profile/${original.author_id}`}>
+        <h5 className="font-semibold text-gray-800">{original.author_name}</h5>
+      </Link>
+      <p className="mt-2 text-gray-700">{original.content}</p>
+    </div>
+  );
+}
+
 export function PostCard({ post, authorProfile }) {
+  // 單純轉發沒有自己的內容，直接以原始貼文顯示並標註轉發者
+  if (post?.post_type === "repost") {
+    return (
+      <div className="w-full border-t border-gray-200">
+        <p className="px-6 pt-4 text-sm text-gray-500">
+          <Link href={`/profile/${post.author_id}`}>{post.author_name}</Link> reposted
+        </p>
+        {post.original_post && !post.original_unavailable ? (
+          <PostContent post={post.original_post} />
+        ) : (
+          <div className="p-6">
+            <EmbeddedPost unavailable />
+          </div>
+        )}
+      </div>
+    );
+  }
+  return <PostContent post={post} authorProfile={authorProfile} />;
+}
+
+function PostContent({ post, authorProfile }) {
   const {
     post_id = post?.post_id || key,
     author_name = authorProfile?.username || "Default User",
@@ -30,9 +70,12 @@ export function PostCard({ post, authorProfile }) {
     comment_count = 0,
     media = [],
     created_at = "2023-10-01 12:00:00",
+    repost_count = 0,
   } = post || {};
 
   const [likes, setLikes] = useState(like_count);
+  const [reposts, setReposts] = useState(repost_count);
+  const [isReposted, setIsReposted] = useState(post.is_reposted);
   // isLiked
   const [isLiked, setIsLiked] = useState(post.isLiked); // 可根據需求實作
 
@@ -78,6 +121,22 @@ export function PostCard({ post, authorProfile }) {
     }
   };
 
+  const handleRepostOnClicked = async () => {
+    const res = await fetch(
+      `${process.env.NEXT_PUBLIC_API_BASE_URL}${process.env.NEXT_PUBLIC_POSTS_API}/${post_id}/${isReposted ? "unrepost" : "repost"}`,
+      {
+        method: "PUT",
+        credentials: "include",
+      }
+    );
+    if (!res.ok) {
+      console.error(`Failed to ${isReposted ? "unrepost" : "repost"} the post`);
+      return;
+    }
+    setReposts((prev) => (isReposted ? Math.max(prev - 1, 0) : prev + 1));
+    setIsReposted(!isReposted);
+  };
+
   const handleComment = () => {
     // 可根據需求實作
   };
@@ -106,6 +165,12 @@ export function PostCard({ post, authorProfile }) {
       <div className="prose prose-indigo max-w-none p-2 mb-4">
         <p>{content}</p>
       </div>
+      {post.post_type === "quote" && (
+        <EmbeddedPost
+          original={post.original_post}
+          unavailable={post.original_unavailable}
+        />
+      )}
       {/* 圖片展示區塊 */}
       {imageSrcs.length > 0 && (
         <div className="overflow-x-auto flex flex-row gap-2 p-2">
@@ -167,6 +232,28 @@ export function PostCard({ post, authorProfile }) {
             <span className="font-medium">{comment_count}</span>
             <span className="ml-1 hidden sm:inline">Comments</span>
           </button>
+          <button
+            onClick={handleRepostOnClicked}
+            className={`flex items-center hover:text-[#B6B09F] transition-colors duration-150 ${isReposted ? "text-green-600" : "text-gray-600"}`}
+            aria-label={`Repost this post. Currently ${reposts} reposts.`}
+          >
+            <svg
+              xmlns="http://www.w3.org/2000/svg"
+              fill="none"
+              viewBox="0 0 24 24"
+              strokeWidth={1.5}
+              stroke="currentColor"
+              className="size-6 mr-1.5"
+            >
+              <path
+                strokeLinecap="round"
+                strokeLinejoin="round"
+                d="M19.5 12c0-1.232-.046-2.453-.138-3.662a4.006 4.006 0 0 0-3.7-3.7 48.678 48.678 0 0 0-7.324 0 4.006 4.006 0 0 0-3.7 3.7c-.017.22-.032.441-.046.662M19.5 12l3-3m-3 3-3-3m-12 3c0 1.232.046 2.453.138 3.662a4.006 4.006 0 0 0 3.7 3.7 48.656 48.656 0 0 0 7.324 0 4.006 4.006 0 0 0 3.7-3.7c.017-.22.032-.441.046-.662M4.5 12l3 3m-3-3-3 3"
+              />
+            </svg>
+            <span className="font-medium">{reposts}</span>
+            <span className="ml-1 hidden sm:inline">Reposts</span>
+          </button>
         </div>
       </div>
     </article>
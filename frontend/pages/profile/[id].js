@@ -81,7 +81,8 @@ export async function getServerSideProps(context) {
       notFound: true,
     };
   }
-  const postsData = await postsRes.json();
+  const postsJson = await postsRes.json();
+  const postsData = postsJson?.data;
   if (!postsData || !Array.isArray(postsData)) {
     return {
       notFound: true,
@@ -165,6 +166,7 @@ export async function getServerSideProps(context) {
         created_at: profileData.created_at || "",
       },
       postsData: postsData || [],
+      postsNextKey: postsJson.next_key || "",
       followersCount,
       followingCount,
       isFollowing: isFollowing,
@@ -177,6 +179,7 @@ export default function Profile({
   currentUserId,
   profileData,
   postsData,
+  postsNextKey,
   followersCount,
   followingCount,
   isFollowing,
@@ -200,6 +203,15 @@ export default function Profile({
   const [currentFollowersCount, setCurrentFollowersCount] = useState(
     profileData.followersCount || 0
   );
+  const [posts, setPosts] = useState(postsData);
+  const [nextKey, setNextKey] = useState(postsNextKey);
+  const [loadingMore, setLoadingMore] = useState(false);
+
+  // 切換到其他使用者的個人頁時，以新的第一頁取代已載入的貼文
+  useEffect(() => {
+    setPosts(postsData);
+    setNextKey(postsNextKey);
+  }, [postsData, postsNextKey]);
 
   useEffect(() => {
     let interval;
@@ -339,6 +351,29 @@ export default function Profile({
     }
   };
 
+  // 讀取下一頁貼文 (由新到舊)
+  const handleLoadMore = async () => {
+    if (!nextKey || loadingMore) return;
+    setLoadingMore(true);
+    try {
+      const res = await fetch(
+        `${process.env.NEXT_PUBLIC_API_BASE_URL}${process.env.NEXT_PUBLIC_POSTS_API}/${profileId}?next_key=${encodeURIComponent(nextKey)}`,
+        {
+          method: "GET",
+          credentials: "include",
+        }
+      );
+      if (!res.ok) throw new Error("讀取貼文失敗，請稍後再試");
+      const json = await res.json();
+      setPosts((prev) => [...prev, ...(json.data || [])]);
+      setNextKey(json.next_key || "");
+    } catch (error) {
+      alert(error.message);
+    } finally {
+      setLoadingMore(false);
+    }
+  };
+
   // follow/unfollow 按鈕的處理函式
   const handleFollowClick = async () => {
     if (isOwnProfile) return;
@@ -528,7 +563,18 @@ export default function Profile({
                 />
               </div>
             </div>
-            <Feed feedData={postsData} />
+            <Feed feedData={posts} />
+            {nextKey && (
+              <div className="flex justify-center p-4">
+                <button
+                  className="px-4 py-2 rounded-md bg-[#B6B09F] text-white disabled:opacity-50"
+                  onClick={handleLoadMore}
+                  disabled={loadingMore}
+                >
+                  {loadingMore ? "Loading..." : "Load more"}
+                </button>
+              </div>
+            )}
           </div>
         </main>
       </div>